@@ -0,0 +1,308 @@
+package datafeed
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cacheflow.internal/datafeed/entities"
+	datastores "code.cacheflow.internal/datastores/mongo"
+
+	"github.com/charmbracelet/log"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CachedProvider wraps a MarketDataProvider with a Mongo-backed bar store.
+// Bars are read from the store first; only the date ranges that have never
+// been synced are fetched from Upstream and persisted. Every other call is
+// passed straight through.
+type CachedProvider struct {
+	Upstream MarketDataProvider
+}
+
+func NewCachedProvider(upstream MarketDataProvider) *CachedProvider {
+	return &CachedProvider{Upstream: upstream}
+}
+
+func (p *CachedProvider) LastTrade(ctx context.Context, ticker string) (*LastTrade, error) {
+	return p.Upstream.LastTrade(ctx, ticker)
+}
+
+func (p *CachedProvider) Snapshot(ctx context.Context, ticker string) (*Snapshot, error) {
+	return p.Upstream.Snapshot(ctx, ticker)
+}
+
+func (p *CachedProvider) TickerDetails(ctx context.Context, ticker string) (*TickerDetails, error) {
+	return p.Upstream.TickerDetails(ctx, ticker)
+}
+
+func (p *CachedProvider) Bars(ctx context.Context, r BarsRequest) ([]Bar, error) {
+	db := datastores.GetMongoDatabase(ctx)
+	fromMs, okFrom := parseTimeBound(r.From, false)
+	toMs, okTo := parseTimeBound(r.To, true)
+	if db == nil || !okFrom || !okTo || fromMs > toMs {
+		return p.Upstream.Bars(ctx, r)
+	}
+	if r.Multiplier <= 0 {
+		r.Multiplier = 1
+	}
+	r.Ticker = strings.ToUpper(r.Ticker)
+
+	key := bson.M{
+		"ticker":     r.Ticker,
+		"multiplier": r.Multiplier,
+		"timespan":   r.Timespan,
+		"adjusted":   r.Adjusted,
+	}
+
+	var coverage entities.BarCoverageEntity
+	err := db.Collection(datastores.BarCoverage).FindOne(ctx, key).Decode(&coverage)
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	// Anything from today onwards is still forming, so it is fetched every time
+	// and never marked as covered.
+	settled := time.Now().UTC().Truncate(24*time.Hour).UnixMilli() - 1
+
+	var synced []entities.BarRange
+	for _, gap := range missingRanges(coverage.Ranges, entities.BarRange{FromMs: fromMs, ToMs: toMs}) {
+		bars, err := p.Upstream.Bars(ctx, BarsRequest{
+			Ticker:     r.Ticker,
+			Multiplier: r.Multiplier,
+			Timespan:   r.Timespan,
+			From:       strconv.FormatInt(gap.FromMs, 10),
+			To:         strconv.FormatInt(gap.ToMs, 10),
+			Adjusted:   r.Adjusted,
+		})
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			return nil, err
+		}
+		if err := upsertBars(ctx, db, r, bars); err != nil {
+			return nil, err
+		}
+		if done, ok := syncedRange(gap, settled, bars); ok {
+			synced = append(synced, done)
+		}
+	}
+
+	if len(synced) > 0 {
+		// Ranges are pushed rather than rewritten so concurrent syncs of the same
+		// series keep each other's; readers merge them.
+		_, err := db.Collection(datastores.BarCoverage).UpdateOne(ctx, key,
+			bson.M{
+				"$push": bson.M{"ranges": bson.M{"$each": synced}},
+				"$set":  bson.M{"updated_at": time.Now().UTC()},
+			},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	filter := bson.M{
+		"ticker":     r.Ticker,
+		"multiplier": r.Multiplier,
+		"timespan":   r.Timespan,
+		"adjusted":   r.Adjusted,
+		"t":          bson.M{"$gte": fromMs, "$lte": toMs},
+	}
+	cur, err := db.Collection(datastores.Bars).Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "t", Value: 1}}))
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	var stored []entities.BarEntity
+	if err := cur.All(ctx, &stored); err != nil {
+		return nil, err
+	}
+
+	out := make([]Bar, 0, len(stored))
+	for _, b := range stored {
+		out = append(out, Bar{
+			Timestamp:    b.Timestamp,
+			Open:         b.Open,
+			High:         b.High,
+			Low:          b.Low,
+			Close:        b.Close,
+			Volume:       b.Volume,
+			VWAP:         b.VWAP,
+			Transactions: b.Transactions,
+		})
+	}
+	return out, nil
+}
+
+func upsertBars(ctx context.Context, db *mongo.Database, r BarsRequest, bars []Bar) error {
+	if len(bars) == 0 {
+		return nil
+	}
+	models := make([]mongo.WriteModel, 0, len(bars))
+	for _, b := range bars {
+		doc := entities.BarEntity{
+			Ticker:       r.Ticker,
+			Multiplier:   r.Multiplier,
+			Timespan:     r.Timespan,
+			Adjusted:     r.Adjusted,
+			Timestamp:    b.Timestamp,
+			Open:         b.Open,
+			High:         b.High,
+			Low:          b.Low,
+			Close:        b.Close,
+			Volume:       b.Volume,
+			VWAP:         b.VWAP,
+			Transactions: b.Transactions,
+		}
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{
+				"ticker":     r.Ticker,
+				"multiplier": r.Multiplier,
+				"timespan":   r.Timespan,
+				"adjusted":   r.Adjusted,
+				"t":          b.Timestamp,
+			}).
+			SetReplacement(doc).
+			SetUpsert(true))
+	}
+	_, err := db.Collection(datastores.Bars).BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	return err
+}
+
+// syncedRange is the part of gap that fetching bars has cached for good. A
+// response can stop short of the gap, so only the span up to the last bar
+// returned is known to be complete; an empty gap has no bars to lose. Nothing
+// after settled counts, since those bars are still forming.
+func syncedRange(gap entities.BarRange, settled int64, bars []Bar) (entities.BarRange, bool) {
+	end := min(gap.ToMs, settled)
+	if len(bars) > 0 {
+		last := bars[0].Timestamp
+		for _, b := range bars[1:] {
+			last = max(last, b.Timestamp)
+		}
+		end = min(end, last)
+	}
+	if end < gap.FromMs {
+		return entities.BarRange{}, false
+	}
+	return entities.BarRange{FromMs: gap.FromMs, ToMs: end}, true
+}
+
+// mergeRanges sorts and coalesces overlapping or adjacent ranges.
+func mergeRanges(ranges []entities.BarRange) []entities.BarRange {
+	if len(ranges) == 0 {
+		return ranges
+	}
+	sorted := make([]entities.BarRange, len(ranges))
+	copy(sorted, ranges)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].FromMs < sorted[j].FromMs })
+
+	out := []entities.BarRange{sorted[0]}
+	for _, r := range sorted[1:] {
+		last := &out[len(out)-1]
+		if r.FromMs <= last.ToMs+1 {
+			last.ToMs = max(last.ToMs, r.ToMs)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// missingRanges returns the parts of want not covered by covered.
+func missingRanges(covered []entities.BarRange, want entities.BarRange) []entities.BarRange {
+	var gaps []entities.BarRange
+	cursor := want.FromMs
+	for _, c := range mergeRanges(covered) {
+		if c.ToMs < cursor {
+			continue
+		}
+		if c.FromMs > want.ToMs {
+			break
+		}
+		if c.FromMs > cursor {
+			gaps = append(gaps, entities.BarRange{FromMs: cursor, ToMs: c.FromMs - 1})
+		}
+		cursor = c.ToMs + 1
+		if cursor > want.ToMs {
+			return gaps
+		}
+	}
+	if cursor <= want.ToMs {
+		gaps = append(gaps, entities.BarRange{FromMs: cursor, ToMs: want.ToMs})
+	}
+	return gaps
+}
+
+// ── Background sync ───────────────────────────────────────────────────────────
+
+// barSyncLookback is how far back the sync job keeps daily bars warm.
+const barSyncLookback = 2 * 365 * 24 * time.Hour
+
+// RunBarSync keeps daily bars for every watchlist ticker current. It syncs once
+// immediately and then on every interval until ctx is cancelled.
+func RunBarSync(ctx context.Context, provider MarketDataProvider, interval time.Duration) {
+	logger := log.NewWithOptions(os.Stderr, log.Options{
+		ReportTimestamp: true,
+		TimeFormat:      "2006-01-02 15:04:05",
+		Prefix:          "DATAFEED (SYNC)",
+	})
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		syncWatchedTickers(ctx, provider, logger)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func syncWatchedTickers(ctx context.Context, provider MarketDataProvider, logger *log.Logger) {
+	db := datastores.GetMongoDatabase(ctx)
+	if db == nil {
+		return
+	}
+
+	raw, err := db.Collection(datastores.Portfolios).Distinct(ctx, "watchlists.tickers", bson.M{})
+	if err != nil {
+		logger.Error("failed to load watched tickers", "err", err)
+		return
+	}
+
+	now := time.Now().UTC()
+	from := now.Add(-barSyncLookback).Format("2006-01-02")
+	to := now.Format("2006-01-02")
+
+	synced := 0
+	for _, v := range raw {
+		t, ok := v.(string)
+		if !ok || strings.TrimSpace(t) == "" {
+			continue
+		}
+		_, err := provider.Bars(ctx, BarsRequest{
+			Ticker:     strings.ToUpper(strings.TrimSpace(t)),
+			Multiplier: 1,
+			Timespan:   "day",
+			From:       from,
+			To:         to,
+			Adjusted:   true,
+		})
+		if err != nil {
+			logger.Error("failed to sync bars", "ticker", t, "err", err)
+			continue
+		}
+		synced++
+	}
+	logger.Info("bar sync complete", "tickers", synced)
+}
@@ -0,0 +1,111 @@
+package datafeed
+
+import (
+	"testing"
+
+	"code.cacheflow.internal/datafeed/entities"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMissingRanges(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Covered []entities.BarRange
+		Want    entities.BarRange
+		Expect  []entities.BarRange
+	}{
+		{
+			Name:   "Nothing cached",
+			Want:   entities.BarRange{FromMs: 10, ToMs: 20},
+			Expect: []entities.BarRange{{FromMs: 10, ToMs: 20}},
+		},
+		{
+			Name:    "Fully cached",
+			Covered: []entities.BarRange{{FromMs: 0, ToMs: 30}},
+			Want:    entities.BarRange{FromMs: 10, ToMs: 20},
+			Expect:  nil,
+		},
+		{
+			Name:    "Hole in the middle",
+			Covered: []entities.BarRange{{FromMs: 0, ToMs: 12}, {FromMs: 16, ToMs: 30}},
+			Want:    entities.BarRange{FromMs: 10, ToMs: 20},
+			Expect:  []entities.BarRange{{FromMs: 13, ToMs: 15}},
+		},
+		{
+			Name:    "Missing both ends",
+			Covered: []entities.BarRange{{FromMs: 12, ToMs: 18}},
+			Want:    entities.BarRange{FromMs: 10, ToMs: 20},
+			Expect:  []entities.BarRange{{FromMs: 10, ToMs: 11}, {FromMs: 19, ToMs: 20}},
+		},
+		{
+			Name:    "Unsorted coverage outside the request",
+			Covered: []entities.BarRange{{FromMs: 40, ToMs: 50}, {FromMs: 0, ToMs: 5}},
+			Want:    entities.BarRange{FromMs: 10, ToMs: 20},
+			Expect:  []entities.BarRange{{FromMs: 10, ToMs: 20}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Expect, missingRanges(tt.Covered, tt.Want))
+		})
+	}
+}
+
+func TestMergeRanges(t *testing.T) {
+	merged := mergeRanges([]entities.BarRange{
+		{FromMs: 20, ToMs: 30},
+		{FromMs: 0, ToMs: 9},
+		{FromMs: 10, ToMs: 15},
+		{FromMs: 40, ToMs: 50},
+	})
+	assert.Equal(t, []entities.BarRange{
+		{FromMs: 0, ToMs: 15},
+		{FromMs: 20, ToMs: 30},
+		{FromMs: 40, ToMs: 50},
+	}, merged)
+}
+
+func TestSyncedRange(t *testing.T) {
+	gap := entities.BarRange{FromMs: 10, ToMs: 100}
+	tests := []struct {
+		Name    string
+		Settled int64
+		Bars    []Bar
+		Expect  entities.BarRange
+		OK      bool
+	}{
+		{
+			Name:    "Empty gap is covered",
+			Settled: 1000,
+			Expect:  entities.BarRange{FromMs: 10, ToMs: 100},
+			OK:      true,
+		},
+		{
+			Name:    "Covered through the last bar returned",
+			Settled: 1000,
+			Bars:    []Bar{{Timestamp: 20}, {Timestamp: 60}},
+			Expect:  entities.BarRange{FromMs: 10, ToMs: 60},
+			OK:      true,
+		},
+		{
+			Name:    "Forming bars are not covered",
+			Settled: 40,
+			Bars:    []Bar{{Timestamp: 20}, {Timestamp: 60}},
+			Expect:  entities.BarRange{FromMs: 10, ToMs: 40},
+			OK:      true,
+		},
+		{
+			Name:    "Gap still forming",
+			Settled: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			got, ok := syncedRange(gap, tt.Settled, tt.Bars)
+			assert.Equal(t, tt.OK, ok)
+			assert.Equal(t, tt.Expect, got)
+		})
+	}
+}
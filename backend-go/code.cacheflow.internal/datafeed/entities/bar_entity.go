@@ -0,0 +1,38 @@
+package entities
+
+import "time"
+
+// BarEntity is one cached OHLCV aggregate. A bar is keyed by
+// (ticker, multiplier, timespan, adjusted, timestamp).
+type BarEntity struct {
+	Ticker       string  `json:"ticker" bson:"ticker"`
+	Multiplier   int     `json:"multiplier" bson:"multiplier"`
+	Timespan     string  `json:"timespan" bson:"timespan"`
+	Adjusted     bool    `json:"adjusted" bson:"adjusted"`
+	Timestamp    int64   `json:"t" bson:"t"` // bar open time, unix ms
+	Open         float64 `json:"o" bson:"o"`
+	High         float64 `json:"h" bson:"h"`
+	Low          float64 `json:"l" bson:"l"`
+	Close        float64 `json:"c" bson:"c"`
+	Volume       float64 `json:"v" bson:"v"`
+	VWAP         float64 `json:"vw" bson:"vw"`
+	Transactions int64   `json:"n" bson:"n"`
+}
+
+// BarRange is a closed [FromMs, ToMs] interval already synced from the provider.
+type BarRange struct {
+	FromMs int64 `json:"from_ms" bson:"from_ms"`
+	ToMs   int64 `json:"to_ms" bson:"to_ms"`
+}
+
+// BarCoverageEntity records which ranges of a bar series are cached, so that
+// gaps (holidays, pre-IPO dates) are not refetched on every request. Ranges
+// may overlap and are merged when read.
+type BarCoverageEntity struct {
+	Ticker     string     `json:"ticker" bson:"ticker"`
+	Multiplier int        `json:"multiplier" bson:"multiplier"`
+	Timespan   string     `json:"timespan" bson:"timespan"`
+	Adjusted   bool       `json:"adjusted" bson:"adjusted"`
+	Ranges     []BarRange `json:"ranges" bson:"ranges"`
+	UpdatedAt  time.Time  `json:"updated_at" bson:"updated_at"`
+}
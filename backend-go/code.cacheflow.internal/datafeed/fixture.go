@@ -0,0 +1,138 @@
+package datafeed
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FixtureProvider serves market data from recorded Massive payloads on disk so the
+// server and the backtester can run offline. Layout under Root:
+//
+//	aggs/<TICKER>/<multiplier>_<timespan>.json    /v2/aggs response
+//	reference/<TICKER>.json                       /v3/reference/tickers response
+//	snapshots/<TICKER>.json                       /v2/snapshot response (optional)
+//
+// Snapshots and last trades fall back to the daily bars when no snapshot file
+// exists.
+type FixtureProvider struct {
+	Root string
+}
+
+func NewFixtureProvider(root string) *FixtureProvider {
+	return &FixtureProvider{Root: root}
+}
+
+func (p *FixtureProvider) read(parts ...string) ([]byte, error) {
+	body, err := os.ReadFile(filepath.Join(append([]string{p.Root}, parts...)...))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotFound
+	}
+	return body, err
+}
+
+func (p *FixtureProvider) Bars(ctx context.Context, r BarsRequest) ([]Bar, error) {
+	mult := r.Multiplier
+	if mult <= 0 {
+		mult = 1
+	}
+	body, err := p.read("aggs", strings.ToUpper(r.Ticker), fmt.Sprintf("%d_%s.json", mult, r.Timespan))
+	if err != nil {
+		return nil, err
+	}
+	bars, err := decodeAggregates(body)
+	if err != nil {
+		return nil, err
+	}
+
+	fromMs, hasFrom := parseTimeBound(r.From, false)
+	toMs, hasTo := parseTimeBound(r.To, true)
+	out := make([]Bar, 0, len(bars))
+	for _, b := range bars {
+		if hasFrom && b.Timestamp < fromMs {
+			continue
+		}
+		if hasTo && b.Timestamp > toMs {
+			continue
+		}
+		out = append(out, b)
+	}
+	return out, nil
+}
+
+func (p *FixtureProvider) LastTrade(ctx context.Context, ticker string) (*LastTrade, error) {
+	snap, err := p.Snapshot(ctx, ticker)
+	if err != nil {
+		return nil, err
+	}
+	return &LastTrade{Ticker: snap.Ticker, Price: snap.LastPrice, Timestamp: snap.Updated}, nil
+}
+
+func (p *FixtureProvider) Snapshot(ctx context.Context, ticker string) (*Snapshot, error) {
+	ticker = strings.ToUpper(ticker)
+	body, err := p.read("snapshots", ticker+".json")
+	if err == nil {
+		return decodeSnapshot(ticker, body)
+	}
+	if !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+
+	// No recorded snapshot: derive one from the last two daily bars.
+	bars, err := p.Bars(ctx, BarsRequest{Ticker: ticker, Multiplier: 1, Timespan: "day"})
+	if err != nil {
+		return nil, err
+	}
+	if len(bars) == 0 {
+		return nil, ErrNotFound
+	}
+	last := bars[len(bars)-1]
+	snap := &Snapshot{
+		Ticker:    ticker,
+		LastPrice: last.Close,
+		DayOpen:   last.Open,
+		DayHigh:   last.High,
+		DayLow:    last.Low,
+		DayClose:  last.Close,
+		DayVolume: last.Volume,
+		Updated:   last.Timestamp,
+	}
+	if len(bars) > 1 {
+		snap.PrevClose = bars[len(bars)-2].Close
+	}
+	return snap, nil
+}
+
+func (p *FixtureProvider) TickerDetails(ctx context.Context, ticker string) (*TickerDetails, error) {
+	body, err := p.read("reference", strings.ToUpper(ticker)+".json")
+	if err != nil {
+		return nil, err
+	}
+	return decodeTickerDetails(body)
+}
+
+// parseTimeBound parses a YYYY-MM-DD or unix-ms bound. An end bound given as a
+// date covers the whole day.
+func parseTimeBound(s string, end bool) (int64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, false
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return ms, true
+	}
+	t, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return 0, false
+	}
+	if end {
+		t = t.Add(24*time.Hour - time.Millisecond)
+	}
+	return t.UnixMilli(), true
+}
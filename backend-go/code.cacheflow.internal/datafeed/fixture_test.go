@@ -0,0 +1,101 @@
+package datafeed
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeFixture records body under root at the joined parts.
+func writeFixture(t *testing.T, root, body string, parts ...string) {
+	t.Helper()
+	path := filepath.Join(append([]string{root}, parts...)...)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(body), 0o644))
+}
+
+func TestFixtureBars(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, `{"results":[
+		{"t":1704119400000,"o":1,"h":1,"l":1,"c":1,"v":10},
+		{"t":1704205800000,"o":2,"h":2,"l":2,"c":2,"v":20},
+		{"t":1704292200000,"o":3,"h":3,"l":3,"c":3,"v":30}
+	]}`, "aggs", "AAPL", "1_day.json")
+	p := NewFixtureProvider(root)
+
+	// Bars open at 14:30 UTC on 2024-01-01, 01-02 and 01-03
+	tests := []struct {
+		Name   string
+		From   string
+		To     string
+		Expect []float64
+	}{
+		{Name: "No bounds", Expect: []float64{1, 2, 3}},
+		{Name: "Date end bound covers the whole day", From: "2024-01-02", To: "2024-01-02", Expect: []float64{2}},
+		{Name: "Millisecond bounds", From: "1704119400000", To: "1704205799999", Expect: []float64{1}},
+		{Name: "Open start", To: "2024-01-02", Expect: []float64{1, 2}},
+		{Name: "Nothing in range", From: "2024-02-01", To: "2024-02-28", Expect: []float64{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			bars, err := p.Bars(context.Background(), BarsRequest{Ticker: "aapl", Timespan: "day", From: tt.From, To: tt.To})
+			require.NoError(t, err)
+			closes := make([]float64, len(bars))
+			for i, b := range bars {
+				closes[i] = b.Close
+			}
+			assert.Equal(t, tt.Expect, closes)
+		})
+	}
+
+	_, err := p.Bars(context.Background(), BarsRequest{Ticker: "MSFT", Timespan: "day"})
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFixtureSnapshotFallsBackToDailyBars(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, `{"results":[
+		{"t":1704119400000,"o":10,"h":12,"l":9,"c":11,"v":100},
+		{"t":1704205800000,"o":11,"h":14,"l":10,"c":13,"v":200}
+	]}`, "aggs", "AAPL", "1_day.json")
+	p := NewFixtureProvider(root)
+
+	snap, err := p.Snapshot(context.Background(), "aapl")
+	require.NoError(t, err)
+	assert.Equal(t, &Snapshot{
+		Ticker:    "AAPL",
+		LastPrice: 13,
+		DayOpen:   11,
+		DayHigh:   14,
+		DayLow:    10,
+		DayClose:  13,
+		DayVolume: 200,
+		PrevClose: 11,
+		Updated:   1704205800000,
+	}, snap)
+
+	trade, err := p.LastTrade(context.Background(), "AAPL")
+	require.NoError(t, err)
+	assert.Equal(t, 13.0, trade.Price)
+
+	_, err = p.Snapshot(context.Background(), "MSFT")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestFixtureTickerDetailsKeepsTheFullPayload(t *testing.T) {
+	root := t.TempDir()
+	writeFixture(t, root, `{"results":{"ticker":"AAPL","name":"Apple Inc.","active":true,
+		"branding":{"logo_url":"https://example.com/logo.svg"},"address":{"city":"CUPERTINO","state":"CA"}}}`,
+		"reference", "AAPL.json")
+
+	details, err := NewFixtureProvider(root).TickerDetails(context.Background(), "aapl")
+	require.NoError(t, err)
+	assert.Equal(t, "Apple Inc.", details.Name)
+	assert.JSONEq(t, `{"ticker":"AAPL","name":"Apple Inc.","active":true,
+		"branding":{"logo_url":"https://example.com/logo.svg"},"address":{"city":"CUPERTINO","state":"CA"}}`, string(details.Raw))
+}
@@ -1,181 +1,442 @@
 package datafeed
 
 import (
-	"fmt"
-	"io"
+	"context"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
+	"code.cacheflow.internal/datafeed/indicators"
 	"code.cacheflow.internal/util"
 	"code.cacheflow.internal/util/httpx"
-	"code.cacheflow.internal/util/secrets"
-
-	"encoding/json"
 )
 
-// proxyIndicator is a generic proxy for RSI / EMA / SMA indicator endpoints.
-// Route: GET /v1/datafeed/indicators/{kind}?ticker=X&timespan=day&window=14&from=YYYY-MM-DD&to=YYYY-MM-DD
-func proxyIndicator(kind string) http.HandlerFunc {
-	return func(res http.ResponseWriter, req *http.Request) {
-		q := req.URL.Query()
-		ticker := strings.ToUpper(strings.TrimSpace(q.Get("ticker")))
-		if ticker == "" {
-			httpx.WriteError(res, req, httpx.BadRequest("ticker is required", nil))
-			return
-		}
+// queryInt reads an integer query param, falling back to def when missing or invalid.
+func queryInt(q map[string][]string, key string, def int) int {
+	vals := q[key]
+	if len(vals) == 0 || vals[0] == "" {
+		return def
+	}
+	n, err := strconv.Atoi(vals[0])
+	if err != nil {
+		return def
+	}
+	return n
+}
 
-		timespan := q.Get("timespan")
-		if timespan == "" {
-			timespan = "day"
-		}
-		window := q.Get("window")
-		if window == "" {
-			window = "14"
-		}
-		from := q.Get("from")
-		to := q.Get("to")
-		order := q.Get("order")
-		if order == "" {
-			order = "asc"
-		}
-		limit := q.Get("limit")
-		if limit == "" {
-			limit = "5000"
-		}
+// WarmupFrom returns the date to start fetching bars so that an indicator with
+// the given lookback (in bars) has values from `from` onward.
+func WarmupFrom(from time.Time, timespan string, lookback int) time.Time {
+	var days int
+	switch timespan {
+	case "minute":
+		days = indicators.TradingDaysToCalendar(lookback/390 + 1)
+	case "hour":
+		days = indicators.TradingDaysToCalendar(lookback/6 + 1)
+	case "week":
+		days = lookback*7 + 7
+	case "month":
+		days = lookback*31 + 31
+	default:
+		days = indicators.TradingDaysToCalendar(lookback)
+	}
+	return from.AddDate(0, 0, -days)
+}
+
+// IndicatorValue is one point of an indicator series as the routes below return
+// it. The optional fields carry the extra lines of multi-line indicators:
+// Signal / Histogram for MACD (Signal is %D for Stochastic), Upper / Lower for
+// Bollinger and Donchian channels, PlusDI / MinusDI for ADX.
+type IndicatorValue struct {
+	Timestamp int64   `json:"timestamp"` // unix ms
+	Value     float64 `json:"value"`
+	Signal    float64 `json:"signal,omitempty"`
+	Histogram float64 `json:"histogram,omitempty"`
+	Upper     float64 `json:"upper,omitempty"`
+	Lower     float64 `json:"lower,omitempty"`
+	PlusDI    float64 `json:"plus_di,omitempty"`
+	MinusDI   float64 `json:"minus_di,omitempty"`
+}
 
-		u := fmt.Sprintf("%s/v1/indicators/%s/%s?timespan=%s&window=%s&order=%s&limit=%s&adjusted=true&apiKey=%s",
-			massiveBaseURL, kind, ticker, timespan, window, order, limit, secrets.MassiveMainApiKeyValue)
-		if from != "" {
-			u += "&timestamp.gte=" + from
+// indicatorQuery is the common part of every /v1/datafeed/indicators/* request.
+type indicatorQuery struct {
+	Ticker   string
+	Timespan string
+	FromMs   int64
+	ToMs     int64
+	Order    string
+	Limit    int
+}
+
+func parseIndicatorQuery(req *http.Request) (*indicatorQuery, error) {
+	q := req.URL.Query()
+	ticker := strings.ToUpper(strings.TrimSpace(q.Get("ticker")))
+	if ticker == "" {
+		return nil, httpx.BadRequest("ticker is required", nil)
+	}
+
+	timespan := q.Get("timespan")
+	if timespan == "" {
+		timespan = "day"
+	}
+
+	now := time.Now().UTC()
+	toMs, ok := parseTimeBound(q.Get("to"), true)
+	if !ok {
+		toMs, _ = parseTimeBound(now.Format("2006-01-02"), true)
+	}
+	fromMs, ok := parseTimeBound(q.Get("from"), false)
+	if !ok {
+		fromMs = time.UnixMilli(toMs).AddDate(-1, 0, 0).UnixMilli()
+	}
+	if fromMs > toMs {
+		return nil, httpx.BadRequest("from must be before to", nil)
+	}
+
+	return &indicatorQuery{
+		Ticker:   ticker,
+		Timespan: timespan,
+		FromMs:   fromMs,
+		ToMs:     toMs,
+		Order:    q.Get("order"),
+		Limit:    queryInt(q, "limit", 5000),
+	}, nil
+}
+
+// loadBars fetches the query's bars plus lookback bars of warm-up history.
+func (iq *indicatorQuery) loadBars(ctx context.Context, lookback int) ([]Bar, error) {
+	warm := WarmupFrom(time.UnixMilli(iq.FromMs).UTC(), iq.Timespan, lookback)
+	return GetProvider().Bars(ctx, BarsRequest{
+		Ticker:     iq.Ticker,
+		Multiplier: 1,
+		Timespan:   iq.Timespan,
+		From:       strconv.FormatInt(warm.UnixMilli(), 10),
+		To:         strconv.FormatInt(iq.ToMs, 10),
+		Adjusted:   true,
+	})
+}
+
+// values trims a computed series to the requested range, order and limit.
+func (iq *indicatorQuery) values(bars []Bar, series []float64, extra func(i int, v *IndicatorValue)) []IndicatorValue {
+	out := []IndicatorValue{}
+	for i, b := range bars {
+		if b.Timestamp < iq.FromMs || b.Timestamp > iq.ToMs || !indicators.Valid(series[i]) {
+			continue
 		}
-		if to != "" {
-			u += "&timestamp.lte=" + to
+		v := IndicatorValue{Timestamp: b.Timestamp, Value: series[i]}
+		if extra != nil {
+			extra(i, &v)
 		}
-
-		httpReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, u, nil)
-		if err != nil {
-			httpx.WriteError(res, req, httpx.Internal("failed to create request"))
-			return
+		out = append(out, v)
+	}
+	if iq.Order == "desc" {
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
 		}
+	}
+	if iq.Limit > 0 && len(out) > iq.Limit {
+		out = out[:iq.Limit]
+	}
+	return out
+}
 
-		client := &http.Client{Timeout: 20 * time.Second}
-		resp, err := client.Do(httpReq)
-		if err != nil {
-			httpx.WriteError(res, req, httpx.Internal("failed to fetch indicator"))
-			return
-		}
-		defer resp.Body.Close()
+func writeIndicatorValues(res http.ResponseWriter, values []IndicatorValue) {
+	util.JSONResponse(res, http.StatusOK, map[string]any{
+		"status":  "OK",
+		"results": map[string]any{"values": values},
+	})
+}
+
+// BarCloses extracts the close series from bars.
+func BarCloses(bars []Bar) []float64 {
+	out := make([]float64, len(bars))
+	for i, b := range bars {
+		out[i] = b.Close
+	}
+	return out
+}
 
-		body, err := io.ReadAll(resp.Body)
+// computeIndicator serves a single-series indicator computed from closes.
+// Route: GET /v1/datafeed/indicators/{kind}?ticker=X&timespan=day&window=14&from=YYYY-MM-DD&to=YYYY-MM-DD
+func computeIndicator(lookback func(window int) int, compute func(closes []float64, window int) []float64, defaultWindow int) http.HandlerFunc {
+	return func(res http.ResponseWriter, req *http.Request) {
+		iq, err := parseIndicatorQuery(req)
 		if err != nil {
-			httpx.WriteError(res, req, httpx.Internal("failed to read response"))
+			httpx.WriteError(res, req, err)
 			return
 		}
-
-		if resp.StatusCode != http.StatusOK {
-			httpx.WriteError(res, req, httpx.NotFound("indicator data not found"))
+		window := queryInt(req.URL.Query(), "window", defaultWindow)
+		if window <= 0 {
+			httpx.WriteError(res, req, httpx.BadRequest("window must be greater than 0", nil))
 			return
 		}
 
-		var result map[string]interface{}
-		if err := json.Unmarshal(body, &result); err != nil {
-			httpx.WriteError(res, req, httpx.Internal("invalid response"))
+		bars, err := iq.loadBars(req.Context(), lookback(window))
+		if err != nil {
+			writeProviderError(res, req, err, "indicator data not found", "failed to fetch indicator")
 			return
 		}
 
-		util.JSONResponse(res, http.StatusOK, result)
+		writeIndicatorValues(res, iq.values(bars, compute(BarCloses(bars), window), nil))
 	}
 }
 
-// GetRSI proxies RSI data from Massive.
+// GetRSI serves Wilder RSI computed from bars.
 func GetRSI(res http.ResponseWriter, req *http.Request) {
-	proxyIndicator("rsi")(res, req)
+	computeIndicator(indicators.RSILookback, indicators.RSI, 14)(res, req)
 }
 
-// GetEMA proxies EMA data from Massive.
+// GetEMA serves the exponential moving average computed from bars.
 func GetEMA(res http.ResponseWriter, req *http.Request) {
-	proxyIndicator("ema")(res, req)
+	computeIndicator(indicators.EMALookback, indicators.EMA, 14)(res, req)
 }
 
-// GetSMA proxies SMA data from Massive.
+// GetSMA serves the simple moving average computed from bars.
 func GetSMA(res http.ResponseWriter, req *http.Request) {
-	proxyIndicator("sma")(res, req)
+	computeIndicator(indicators.SMALookback, indicators.SMA, 14)(res, req)
 }
 
-// GetMACD proxies MACD data from Massive.
+// GetMACD serves MACD computed from bars.
 // Route: GET /v1/datafeed/indicators/macd?ticker=X&timespan=day&fast_period=12&slow_period=26&signal_period=9&from=...&to=...
 func GetMACD(res http.ResponseWriter, req *http.Request) {
+	iq, err := parseIndicatorQuery(req)
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
 	q := req.URL.Query()
-	ticker := strings.ToUpper(strings.TrimSpace(q.Get("ticker")))
-	if ticker == "" {
-		httpx.WriteError(res, req, httpx.BadRequest("ticker is required", nil))
+	fast := queryInt(q, "fast_period", 12)
+	slow := queryInt(q, "slow_period", 26)
+	signal := queryInt(q, "signal_period", 9)
+	if fast <= 0 || slow <= 0 || signal <= 0 {
+		httpx.WriteError(res, req, httpx.BadRequest("fast_period, slow_period and signal_period must be greater than 0", nil))
 		return
 	}
 
-	timespan := q.Get("timespan")
-	if timespan == "" {
-		timespan = "day"
+	bars, err := iq.loadBars(req.Context(), indicators.MACDLookback(fast, slow, signal))
+	if err != nil {
+		writeProviderError(res, req, err, "MACD data not found", "failed to fetch MACD")
+		return
 	}
-	fastPeriod := q.Get("fast_period")
-	if fastPeriod == "" {
-		fastPeriod = "12"
+
+	line, sig, hist := indicators.MACD(BarCloses(bars), fast, slow, signal)
+	writeIndicatorValues(res, iq.values(bars, line, func(i int, v *IndicatorValue) {
+		if indicators.Valid(sig[i]) {
+			v.Signal = sig[i]
+			v.Histogram = hist[i]
+		}
+	}))
+}
+
+// GetVWAP serves a rolling VWAP of the typical price.
+// Route: GET /v1/datafeed/indicators/vwap?ticker=X&timespan=day&window=20&from=...&to=...
+// window=0 anchors the VWAP at `from`.
+func GetVWAP(res http.ResponseWriter, req *http.Request) {
+	iq, err := parseIndicatorQuery(req)
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+	window := queryInt(req.URL.Query(), "window", 0)
+	if window < 0 {
+		httpx.WriteError(res, req, httpx.BadRequest("window must not be negative", nil))
+		return
+	}
+
+	bars, err := iq.loadBars(req.Context(), window)
+	if err != nil {
+		writeProviderError(res, req, err, "VWAP data not found", "failed to fetch VWAP")
+		return
+	}
+	if window == 0 {
+		// Anchored: drop the warm-up bars so the average starts at `from`
+		start := 0
+		for start < len(bars) && bars[start].Timestamp < iq.FromMs {
+			start++
+		}
+		bars = bars[start:]
+	}
+
+	highs, lows, closes, volumes := BarHLCV(bars)
+	writeIndicatorValues(res, iq.values(bars, indicators.VWAP(highs, lows, closes, volumes, window), nil))
+}
+
+// BarHLCV splits bars into high, low, close and volume series.
+func BarHLCV(bars []Bar) (highs, lows, closes, volumes []float64) {
+	highs = make([]float64, len(bars))
+	lows = make([]float64, len(bars))
+	closes = make([]float64, len(bars))
+	volumes = make([]float64, len(bars))
+	for i, b := range bars {
+		highs[i], lows[i], closes[i], volumes[i] = b.High, b.Low, b.Close, b.Volume
+	}
+	return highs, lows, closes, volumes
+}
+
+// GetBollinger serves Bollinger Bands; value is the middle band.
+// Route: GET /v1/datafeed/indicators/bollinger?ticker=X&timespan=day&window=20&std_dev=2&from=...&to=...
+func GetBollinger(res http.ResponseWriter, req *http.Request) {
+	iq, err := parseIndicatorQuery(req)
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+	q := req.URL.Query()
+	window := queryInt(q, "window", 20)
+	k, err := strconv.ParseFloat(q.Get("std_dev"), 64)
+	if err != nil || k <= 0 {
+		k = 2
+	}
+	if window <= 0 {
+		httpx.WriteError(res, req, httpx.BadRequest("window must be greater than 0", nil))
+		return
+	}
+
+	bars, err := iq.loadBars(req.Context(), indicators.BollingerLookback(window))
+	if err != nil {
+		writeProviderError(res, req, err, "Bollinger data not found", "failed to fetch Bollinger Bands")
+		return
+	}
+
+	middle, upper, lower := indicators.Bollinger(BarCloses(bars), window, k)
+	writeIndicatorValues(res, iq.values(bars, middle, func(i int, v *IndicatorValue) {
+		v.Upper = upper[i]
+		v.Lower = lower[i]
+	}))
+}
+
+// GetATR serves Wilder's average true range.
+// Route: GET /v1/datafeed/indicators/atr?ticker=X&timespan=day&window=14&from=...&to=...
+func GetATR(res http.ResponseWriter, req *http.Request) {
+	iq, err := parseIndicatorQuery(req)
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
 	}
-	slowPeriod := q.Get("slow_period")
-	if slowPeriod == "" {
-		slowPeriod = "26"
+	window := queryInt(req.URL.Query(), "window", 14)
+	if window <= 0 {
+		httpx.WriteError(res, req, httpx.BadRequest("window must be greater than 0", nil))
+		return
 	}
-	signalPeriod := q.Get("signal_period")
-	if signalPeriod == "" {
-		signalPeriod = "9"
+
+	bars, err := iq.loadBars(req.Context(), indicators.ATRLookback(window))
+	if err != nil {
+		writeProviderError(res, req, err, "ATR data not found", "failed to fetch ATR")
+		return
 	}
-	from := q.Get("from")
-	to := q.Get("to")
-	order := q.Get("order")
-	if order == "" {
-		order = "asc"
+
+	highs, lows, closes, _ := BarHLCV(bars)
+	writeIndicatorValues(res, iq.values(bars, indicators.ATR(highs, lows, closes, window), nil))
+}
+
+// GetStochastic serves the slow stochastic; value is %K and signal is %D.
+// Route: GET /v1/datafeed/indicators/stochastic?ticker=X&timespan=day&k_period=14&k_smoothing=3&d_period=3&from=...&to=...
+func GetStochastic(res http.ResponseWriter, req *http.Request) {
+	iq, err := parseIndicatorQuery(req)
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+	q := req.URL.Query()
+	kPeriod := queryInt(q, "k_period", 14)
+	smoothing := queryInt(q, "k_smoothing", 3)
+	dPeriod := queryInt(q, "d_period", 3)
+	if kPeriod <= 0 || smoothing <= 0 || dPeriod <= 0 {
+		httpx.WriteError(res, req, httpx.BadRequest("k_period, k_smoothing and d_period must be greater than 0", nil))
+		return
 	}
 
-	u := fmt.Sprintf(
-		"%s/v1/indicators/macd/%s?timespan=%s&fast_period=%s&slow_period=%s&signal_period=%s&order=%s&limit=5000&adjusted=true&apiKey=%s",
-		massiveBaseURL, ticker, timespan, fastPeriod, slowPeriod, signalPeriod, order, secrets.MassiveMainApiKeyValue)
-	if from != "" {
-		u += "&timestamp.gte=" + from
+	bars, err := iq.loadBars(req.Context(), indicators.StochasticLookback(kPeriod, smoothing, dPeriod))
+	if err != nil {
+		writeProviderError(res, req, err, "Stochastic data not found", "failed to fetch Stochastic")
+		return
 	}
-	if to != "" {
-		u += "&timestamp.lte=" + to
+
+	highs, lows, closes, _ := BarHLCV(bars)
+	k, d := indicators.Stochastic(highs, lows, closes, kPeriod, smoothing, dPeriod)
+	writeIndicatorValues(res, iq.values(bars, k, func(i int, v *IndicatorValue) {
+		if indicators.Valid(d[i]) {
+			v.Signal = d[i]
+		}
+	}))
+}
+
+// GetADX serves the average directional index with its +DI / −DI lines.
+// Route: GET /v1/datafeed/indicators/adx?ticker=X&timespan=day&window=14&from=...&to=...
+func GetADX(res http.ResponseWriter, req *http.Request) {
+	iq, err := parseIndicatorQuery(req)
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+	window := queryInt(req.URL.Query(), "window", 14)
+	if window <= 0 {
+		httpx.WriteError(res, req, httpx.BadRequest("window must be greater than 0", nil))
+		return
 	}
 
-	httpReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, u, nil)
+	bars, err := iq.loadBars(req.Context(), indicators.ADXLookback(window))
 	if err != nil {
-		httpx.WriteError(res, req, httpx.Internal("failed to create request"))
+		writeProviderError(res, req, err, "ADX data not found", "failed to fetch ADX")
 		return
 	}
 
-	client := &http.Client{Timeout: 20 * time.Second}
-	resp, err := client.Do(httpReq)
+	highs, lows, closes, _ := BarHLCV(bars)
+	adx, plusDI, minusDI := indicators.ADX(highs, lows, closes, window)
+	writeIndicatorValues(res, iq.values(bars, adx, func(i int, v *IndicatorValue) {
+		v.PlusDI = plusDI[i]
+		v.MinusDI = minusDI[i]
+	}))
+}
+
+// GetOBV serves on-balance volume, accumulated from `from`.
+// Route: GET /v1/datafeed/indicators/obv?ticker=X&timespan=day&from=...&to=...
+func GetOBV(res http.ResponseWriter, req *http.Request) {
+	iq, err := parseIndicatorQuery(req)
 	if err != nil {
-		httpx.WriteError(res, req, httpx.Internal("failed to fetch MACD"))
+		httpx.WriteError(res, req, err)
 		return
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	bars, err := iq.loadBars(req.Context(), 0)
 	if err != nil {
-		httpx.WriteError(res, req, httpx.Internal("failed to read response"))
+		writeProviderError(res, req, err, "OBV data not found", "failed to fetch OBV")
 		return
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		httpx.WriteError(res, req, httpx.NotFound("MACD data not found"))
+	_, _, closes, volumes := BarHLCV(bars)
+	writeIndicatorValues(res, iq.values(bars, indicators.OBV(closes, volumes), nil))
+}
+
+// GetDonchian serves the Donchian channel; value is the channel midpoint.
+// Route: GET /v1/datafeed/indicators/donchian?ticker=X&timespan=day&window=20&from=...&to=...
+func GetDonchian(res http.ResponseWriter, req *http.Request) {
+	iq, err := parseIndicatorQuery(req)
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+	window := queryInt(req.URL.Query(), "window", 20)
+	if window <= 0 {
+		httpx.WriteError(res, req, httpx.BadRequest("window must be greater than 0", nil))
 		return
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		httpx.WriteError(res, req, httpx.Internal("invalid response"))
+	bars, err := iq.loadBars(req.Context(), indicators.DonchianLookback(window))
+	if err != nil {
+		writeProviderError(res, req, err, "Donchian data not found", "failed to fetch Donchian channel")
 		return
 	}
 
-	util.JSONResponse(res, http.StatusOK, result)
+	highs, lows, _, _ := BarHLCV(bars)
+	upper, lower := indicators.Donchian(highs, lows, window)
+	middle := make([]float64, len(bars))
+	for i := range bars {
+		middle[i] = (upper[i] + lower[i]) / 2
+	}
+	writeIndicatorValues(res, iq.values(bars, middle, func(i int, v *IndicatorValue) {
+		v.Upper = upper[i]
+		v.Lower = lower[i]
+	}))
 }
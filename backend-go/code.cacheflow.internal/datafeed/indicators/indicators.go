@@ -0,0 +1,32 @@
+// Package indicators computes technical indicators locally from bar series.
+//
+// Every function returns a slice aligned index-for-index with its input. Values
+// that cannot be computed yet (the warm-up period) are NaN; use Valid to test.
+// Callers that need values from a specific date onward should fetch at least
+// the matching *Lookback number of extra bars before that date.
+package indicators
+
+import "math"
+
+// Valid reports whether v is a computed value rather than warm-up padding.
+func Valid(v float64) bool {
+	return !math.IsNaN(v)
+}
+
+func nanSeries(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
+
+// firstValid returns the index of the first non-NaN value, or len(values).
+func firstValid(values []float64) int {
+	for i, v := range values {
+		if Valid(v) {
+			return i
+		}
+	}
+	return len(values)
+}
@@ -0,0 +1,164 @@
+package indicators
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMA(t *testing.T) {
+	out := SMA([]float64{1, 2, 3, 4, 5}, 3)
+
+	assert.False(t, Valid(out[0]))
+	assert.False(t, Valid(out[1]))
+	assert.InDelta(t, 2, out[2], 1e-9)
+	assert.InDelta(t, 3, out[3], 1e-9)
+	assert.InDelta(t, 4, out[4], 1e-9)
+}
+
+func TestEMA(t *testing.T) {
+	// Seeded with SMA(1,2,3)=2, then k = 2/(3+1) = 0.5
+	out := EMA([]float64{1, 2, 3, 4, 5}, 3)
+
+	assert.False(t, Valid(out[1]))
+	assert.InDelta(t, 2, out[2], 1e-9)
+	assert.InDelta(t, 3, out[3], 1e-9)
+	assert.InDelta(t, 4, out[4], 1e-9)
+}
+
+func TestRSI(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Closes []float64
+		Expect float64
+	}{
+		{
+			Name:   "Only gains",
+			Closes: []float64{1, 2, 3, 4, 5},
+			Expect: 100,
+		},
+		{
+			Name:   "Only losses",
+			Closes: []float64{5, 4, 3, 2, 1},
+			Expect: 0,
+		},
+		{
+			Name:   "Balanced",
+			Closes: []float64{1, 2, 1, 2, 1},
+			Expect: 50,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			out := RSI(tt.Closes, 4)
+			assert.False(t, Valid(out[3]))
+			assert.InDelta(t, tt.Expect, out[4], 1e-9)
+		})
+	}
+}
+
+func TestMACD(t *testing.T) {
+	closes := make([]float64, 60)
+	for i := range closes {
+		closes[i] = float64(100 + i)
+	}
+
+	line, sig, hist := MACD(closes, 12, 26, 9)
+
+	assert.False(t, Valid(line[24]))
+	assert.True(t, Valid(line[25]))
+	assert.False(t, Valid(sig[32]))
+	assert.True(t, Valid(sig[33]))
+	// A steady uptrend keeps the fast EMA above the slow EMA
+	assert.Greater(t, line[59], 0.0)
+	assert.InDelta(t, line[59]-sig[59], hist[59], 1e-9)
+}
+
+func TestVWAP(t *testing.T) {
+	highs := []float64{11, 12, 13}
+	lows := []float64{9, 10, 11}
+	closes := []float64{10, 11, 12}
+	volumes := []float64{100, 100, 200}
+
+	anchored := VWAP(highs, lows, closes, volumes, 0)
+	assert.InDelta(t, 10, anchored[0], 1e-9)
+	assert.InDelta(t, (10*100+11*100+12*200)/400.0, anchored[2], 1e-9)
+
+	rolling := VWAP(highs, lows, closes, volumes, 2)
+	assert.False(t, Valid(rolling[0]))
+	assert.InDelta(t, (11*100+12*200)/300.0, rolling[2], 1e-9)
+}
+
+func TestBollinger(t *testing.T) {
+	middle, upper, lower := Bollinger([]float64{2, 4, 4, 4, 5, 5, 7, 9}, 8, 2)
+
+	// Population stddev of the series is 2
+	assert.InDelta(t, 5, middle[7], 1e-9)
+	assert.InDelta(t, 9, upper[7], 1e-9)
+	assert.InDelta(t, 1, lower[7], 1e-9)
+	assert.False(t, Valid(upper[6]))
+}
+
+func TestATR(t *testing.T) {
+	highs := []float64{10, 11, 12, 13}
+	lows := []float64{9, 10, 11, 12}
+	closes := []float64{9.5, 10.5, 11.5, 12.5}
+
+	// True range is 1.5 after the first bar (high − prev close)
+	tr := TrueRange(highs, lows, closes)
+	assert.InDelta(t, 1, tr[0], 1e-9)
+	assert.InDelta(t, 1.5, tr[1], 1e-9)
+
+	atr := ATR(highs, lows, closes, 2)
+	assert.InDelta(t, 1.25, atr[1], 1e-9)
+	assert.InDelta(t, 1.375, atr[2], 1e-9)
+}
+
+func TestStochastic(t *testing.T) {
+	highs := []float64{10, 10, 10, 10}
+	lows := []float64{0, 0, 0, 0}
+	closes := []float64{0, 5, 10, 10}
+
+	k, d := Stochastic(highs, lows, closes, 2, 1, 2)
+	assert.InDelta(t, 50, k[1], 1e-9)
+	assert.InDelta(t, 100, k[2], 1e-9)
+	assert.InDelta(t, 75, d[2], 1e-9)
+}
+
+func TestADX(t *testing.T) {
+	n := 80
+	highs := make([]float64, n)
+	lows := make([]float64, n)
+	closes := make([]float64, n)
+	for i := 0; i < n; i++ {
+		highs[i] = float64(101 + i)
+		lows[i] = float64(99 + i)
+		closes[i] = float64(100 + i)
+	}
+
+	adx, plusDI, minusDI := ADX(highs, lows, closes, 14)
+	// A one-way trend has no negative directional movement at all
+	assert.Greater(t, plusDI[n-1], minusDI[n-1])
+	assert.InDelta(t, 100, adx[n-1], 1e-6)
+}
+
+func TestOBVDivergence(t *testing.T) {
+	closes := []float64{10, 9, 9.5, 8}
+	volumes := []float64{0, 100, 500, 100}
+
+	// Price fell over 3 bars while OBV rose (−100 + 500 − 100 = +300)
+	div := OBVDivergence(closes, volumes, 3)
+	assert.InDelta(t, 300, OBV(closes, volumes)[3], 1e-9)
+	assert.InDelta(t, 1, div[3], 1e-9)
+}
+
+func TestDonchian(t *testing.T) {
+	upper, lower := Donchian([]float64{1, 3, 2, 5}, []float64{0, 1, 1, 2}, 3)
+
+	assert.False(t, Valid(upper[1]))
+	assert.InDelta(t, 3, upper[2], 1e-9)
+	assert.InDelta(t, 0, lower[2], 1e-9)
+	assert.InDelta(t, 5, upper[3], 1e-9)
+	assert.InDelta(t, 1, lower[3], 1e-9)
+}
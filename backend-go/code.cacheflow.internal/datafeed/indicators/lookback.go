@@ -0,0 +1,52 @@
+package indicators
+
+// Lookbacks are the number of bars to fetch ahead of the first date a value is
+// needed. Recursive indicators (EMA, RSI, MACD) get several windows of history
+// so the seed has decayed and values match a full-history computation.
+
+func SMALookback(window int) int {
+	return window
+}
+
+func EMALookback(window int) int {
+	return 4 * window
+}
+
+func RSILookback(window int) int {
+	return 5*window + 1
+}
+
+func MACDLookback(fast, slow, signal int) int {
+	return 4*max(fast, slow) + signal
+}
+
+// TradingDaysToCalendar converts a bar count on daily bars into a calendar-day
+// span, padded for weekends and market holidays.
+func TradingDaysToCalendar(bars int) int {
+	return bars*7/5 + 10
+}
+
+func BollingerLookback(window int) int {
+	return window
+}
+
+func ATRLookback(window int) int {
+	return 5*window + 1
+}
+
+func StochasticLookback(kWindow, smoothing, dWindow int) int {
+	return kWindow + smoothing + dWindow
+}
+
+// ADXLookback covers the two stacked Wilder smoothings ADX is built from.
+func ADXLookback(window int) int {
+	return 8*window + 1
+}
+
+func DonchianLookback(window int) int {
+	return window + 1
+}
+
+func OBVLookback(window int) int {
+	return window + 1
+}
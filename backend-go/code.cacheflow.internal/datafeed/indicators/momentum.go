@@ -0,0 +1,129 @@
+package indicators
+
+import "math"
+
+// RSI is Wilder's relative strength index over window periods.
+func RSI(closes []float64, window int) []float64 {
+	out := nanSeries(len(closes))
+	if window <= 0 || len(closes) <= window {
+		return out
+	}
+
+	var gain, loss float64
+	for i := 1; i <= window; i++ {
+		ch := closes[i] - closes[i-1]
+		if ch > 0 {
+			gain += ch
+		} else {
+			loss -= ch
+		}
+	}
+	avgGain := gain / float64(window)
+	avgLoss := loss / float64(window)
+	out[window] = rsiValue(avgGain, avgLoss)
+
+	for i := window + 1; i < len(closes); i++ {
+		ch := closes[i] - closes[i-1]
+		g, l := 0.0, 0.0
+		if ch > 0 {
+			g = ch
+		} else {
+			l = -ch
+		}
+		avgGain = (avgGain*float64(window-1) + g) / float64(window)
+		avgLoss = (avgLoss*float64(window-1) + l) / float64(window)
+		out[i] = rsiValue(avgGain, avgLoss)
+	}
+	return out
+}
+
+func rsiValue(avgGain, avgLoss float64) float64 {
+	if avgLoss == 0 {
+		if avgGain == 0 {
+			return 50
+		}
+		return 100
+	}
+	rs := avgGain / avgLoss
+	return 100 - 100/(1+rs)
+}
+
+// MACD returns the MACD line (fast EMA − slow EMA), its signal line (EMA of
+// the MACD line) and the histogram (MACD − signal).
+func MACD(closes []float64, fast, slow, signal int) (line, sig, hist []float64) {
+	fastEMA := EMA(closes, fast)
+	slowEMA := EMA(closes, slow)
+
+	line = nanSeries(len(closes))
+	for i := range closes {
+		if Valid(fastEMA[i]) && Valid(slowEMA[i]) {
+			line[i] = fastEMA[i] - slowEMA[i]
+		}
+	}
+
+	sig = EMA(line, signal)
+	hist = nanSeries(len(closes))
+	for i := range closes {
+		if Valid(line[i]) && Valid(sig[i]) {
+			hist[i] = line[i] - sig[i]
+		}
+	}
+	return line, sig, hist
+}
+
+// ROC is the rate of change in percent over window periods.
+func ROC(closes []float64, window int) []float64 {
+	out := nanSeries(len(closes))
+	if window <= 0 {
+		return out
+	}
+	for i := window; i < len(closes); i++ {
+		if closes[i-window] != 0 {
+			out[i] = (closes[i] - closes[i-window]) / closes[i-window] * 100
+		}
+	}
+	return out
+}
+
+// StdDev is the rolling population standard deviation over window values.
+func StdDev(values []float64, window int) []float64 {
+	out := nanSeries(len(values))
+	mean := SMA(values, window)
+	for i := range values {
+		if !Valid(mean[i]) {
+			continue
+		}
+		var ss float64
+		for j := i - window + 1; j <= i; j++ {
+			d := values[j] - mean[i]
+			ss += d * d
+		}
+		out[i] = math.Sqrt(ss / float64(window))
+	}
+	return out
+}
+
+// Stochastic returns the slow stochastic oscillator: %K is the raw
+// kWindow stochastic smoothed over smoothing bars, %D is the SMA of %K over
+// dWindow bars. A smoothing of 1 gives the fast stochastic.
+func Stochastic(highs, lows, closes []float64, kWindow, smoothing, dWindow int) (k, d []float64) {
+	raw := nanSeries(len(closes))
+	hh, ll := Donchian(highs, lows, kWindow)
+	for i := range closes {
+		if !Valid(hh[i]) {
+			continue
+		}
+		if hh[i] == ll[i] {
+			raw[i] = 50
+			continue
+		}
+		raw[i] = 100 * (closes[i] - ll[i]) / (hh[i] - ll[i])
+	}
+
+	k = raw
+	if smoothing > 1 {
+		k = SMA(raw, smoothing)
+	}
+	d = SMA(k, dWindow)
+	return k, d
+}
@@ -0,0 +1,71 @@
+package indicators
+
+// SMA is the simple moving average over window values.
+func SMA(values []float64, window int) []float64 {
+	out := nanSeries(len(values))
+	if window <= 0 {
+		return out
+	}
+	start := firstValid(values)
+	var sum float64
+	for i := start; i < len(values); i++ {
+		sum += values[i]
+		if i-start >= window {
+			sum -= values[i-window]
+		}
+		if i-start >= window-1 {
+			out[i] = sum / float64(window)
+		}
+	}
+	return out
+}
+
+// EMA is the exponential moving average, seeded with the SMA of the first
+// window values. Leading NaNs in values are skipped, so EMA can be chained.
+func EMA(values []float64, window int) []float64 {
+	return smooth(values, window, 2/float64(window+1))
+}
+
+// WMA is the linearly weighted moving average over window values.
+func WMA(values []float64, window int) []float64 {
+	out := nanSeries(len(values))
+	if window <= 0 {
+		return out
+	}
+	denom := float64(window*(window+1)) / 2
+	start := firstValid(values)
+	for i := start + window - 1; i < len(values); i++ {
+		var sum float64
+		for j := 0; j < window; j++ {
+			sum += values[i-j] * float64(window-j)
+		}
+		out[i] = sum / denom
+	}
+	return out
+}
+
+// smooth is an SMA-seeded exponential smoother with factor alpha. EMA uses
+// 2/(n+1); Wilder's smoothing (RSI, ATR, ADX) uses 1/n.
+func smooth(values []float64, window int, alpha float64) []float64 {
+	out := nanSeries(len(values))
+	if window <= 0 {
+		return out
+	}
+	start := firstValid(values)
+	if len(values)-start < window {
+		return out
+	}
+
+	var seed float64
+	for i := start; i < start+window; i++ {
+		seed += values[i]
+	}
+	prev := seed / float64(window)
+	out[start+window-1] = prev
+
+	for i := start + window; i < len(values); i++ {
+		prev = alpha*values[i] + (1-alpha)*prev
+		out[i] = prev
+	}
+	return out
+}
@@ -0,0 +1,56 @@
+package indicators
+
+import "math"
+
+// ADX is Wilder's average directional index over window periods, returned
+// together with the +DI and −DI lines it is derived from.
+func ADX(highs, lows, closes []float64, window int) (adx, plusDI, minusDI []float64) {
+	n := len(closes)
+	adx = nanSeries(n)
+	plusDI = nanSeries(n)
+	minusDI = nanSeries(n)
+	if window <= 0 || n < 2 {
+		return adx, plusDI, minusDI
+	}
+
+	// Directional movement starts at the second bar
+	tr := nanSeries(n)
+	plusDM := nanSeries(n)
+	minusDM := nanSeries(n)
+	ranges := TrueRange(highs, lows, closes)
+	for i := 1; i < n; i++ {
+		up := highs[i] - highs[i-1]
+		down := lows[i-1] - lows[i]
+		plusDM[i], minusDM[i] = 0, 0
+		if up > down && up > 0 {
+			plusDM[i] = up
+		}
+		if down > up && down > 0 {
+			minusDM[i] = down
+		}
+		tr[i] = ranges[i]
+	}
+
+	alpha := 1 / float64(window)
+	sTR := smooth(tr, window, alpha)
+	sPlus := smooth(plusDM, window, alpha)
+	sMinus := smooth(minusDM, window, alpha)
+
+	dx := nanSeries(n)
+	for i := range closes {
+		if !Valid(sTR[i]) || sTR[i] == 0 {
+			continue
+		}
+		plusDI[i] = 100 * sPlus[i] / sTR[i]
+		minusDI[i] = 100 * sMinus[i] / sTR[i]
+		sum := plusDI[i] + minusDI[i]
+		if sum == 0 {
+			dx[i] = 0
+			continue
+		}
+		dx[i] = 100 * math.Abs(plusDI[i]-minusDI[i]) / sum
+	}
+
+	adx = smooth(dx, window, alpha)
+	return adx, plusDI, minusDI
+}
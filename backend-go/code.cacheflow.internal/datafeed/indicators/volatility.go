@@ -0,0 +1,58 @@
+package indicators
+
+import "math"
+
+// Bollinger returns the middle band (SMA) and the upper / lower bands k
+// standard deviations away.
+func Bollinger(closes []float64, window int, k float64) (middle, upper, lower []float64) {
+	middle = SMA(closes, window)
+	sd := StdDev(closes, window)
+	upper = nanSeries(len(closes))
+	lower = nanSeries(len(closes))
+	for i := range closes {
+		if Valid(middle[i]) {
+			upper[i] = middle[i] + k*sd[i]
+			lower[i] = middle[i] - k*sd[i]
+		}
+	}
+	return middle, upper, lower
+}
+
+// TrueRange is max(high−low, |high−prevClose|, |low−prevClose|). The first
+// bar has no previous close and uses high−low.
+func TrueRange(highs, lows, closes []float64) []float64 {
+	out := make([]float64, len(closes))
+	for i := range closes {
+		tr := highs[i] - lows[i]
+		if i > 0 {
+			tr = math.Max(tr, math.Max(math.Abs(highs[i]-closes[i-1]), math.Abs(lows[i]-closes[i-1])))
+		}
+		out[i] = tr
+	}
+	return out
+}
+
+// ATR is Wilder's average true range over window periods.
+func ATR(highs, lows, closes []float64, window int) []float64 {
+	return smooth(TrueRange(highs, lows, closes), window, 1/float64(window))
+}
+
+// Donchian returns the highest high and lowest low of the last window bars,
+// including the current bar.
+func Donchian(highs, lows []float64, window int) (upper, lower []float64) {
+	upper = nanSeries(len(highs))
+	lower = nanSeries(len(lows))
+	if window <= 0 {
+		return upper, lower
+	}
+	for i := window - 1; i < len(highs); i++ {
+		hi, lo := highs[i], lows[i]
+		for j := i - window + 1; j < i; j++ {
+			hi = math.Max(hi, highs[j])
+			lo = math.Min(lo, lows[j])
+		}
+		upper[i] = hi
+		lower[i] = lo
+	}
+	return upper, lower
+}
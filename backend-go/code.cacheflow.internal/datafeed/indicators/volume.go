@@ -0,0 +1,63 @@
+package indicators
+
+// VWAP is the volume-weighted average of the typical price (H+L+C)/3 over the
+// last window bars. A window of 0 anchors at the first bar (cumulative VWAP).
+func VWAP(highs, lows, closes, volumes []float64, window int) []float64 {
+	out := nanSeries(len(closes))
+	var pv, vol float64
+	for i := range closes {
+		tp := (highs[i] + lows[i] + closes[i]) / 3
+		pv += tp * volumes[i]
+		vol += volumes[i]
+		if window > 0 && i >= window {
+			old := (highs[i-window] + lows[i-window] + closes[i-window]) / 3
+			pv -= old * volumes[i-window]
+			vol -= volumes[i-window]
+		}
+		if window > 0 && i < window-1 {
+			continue
+		}
+		if vol > 0 {
+			out[i] = pv / vol
+		}
+	}
+	return out
+}
+
+// OBV is on-balance volume: volume is added on up closes and subtracted on
+// down closes, starting from 0 at the first bar.
+func OBV(closes, volumes []float64) []float64 {
+	out := make([]float64, len(closes))
+	for i := 1; i < len(closes); i++ {
+		out[i] = out[i-1]
+		switch {
+		case closes[i] > closes[i-1]:
+			out[i] += volumes[i]
+		case closes[i] < closes[i-1]:
+			out[i] -= volumes[i]
+		}
+	}
+	return out
+}
+
+// OBVDivergence compares price and OBV over the last window bars: +1 when
+// price fell while OBV rose (bullish), −1 when price rose while OBV fell
+// (bearish), 0 otherwise.
+func OBVDivergence(closes, volumes []float64, window int) []float64 {
+	out := nanSeries(len(closes))
+	if window <= 0 {
+		return out
+	}
+	obv := OBV(closes, volumes)
+	for i := window; i < len(closes); i++ {
+		switch {
+		case closes[i] < closes[i-window] && obv[i] > obv[i-window]:
+			out[i] = 1
+		case closes[i] > closes[i-window] && obv[i] < obv[i-window]:
+			out[i] = -1
+		default:
+			out[i] = 0
+		}
+	}
+	return out
+}
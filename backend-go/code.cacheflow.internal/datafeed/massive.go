@@ -1,12 +1,243 @@
 package datafeed
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
 	"code.cacheflow.internal/util/secrets"
 	massive "github.com/massive-com/client-go/v2/rest"
+	"github.com/massive-com/client-go/v2/rest/models"
 )
 
+const massiveBaseURL = "https://api.massive.com"
+
 func GetMassiveClient() *massive.Client {
 	c := massive.New(secrets.MassiveMainApiKeyValue)
-	
+
 	return c
-}
\ No newline at end of file
+}
+
+// MassiveProvider is the MarketDataProvider backed by the Massive REST API.
+type MassiveProvider struct {
+	BaseURL string
+	APIKey  string // empty → secrets.MassiveMainApiKeyValue at call time
+	Timeout time.Duration
+}
+
+// NewMassiveProvider returns a provider using the cached Massive main API key.
+func NewMassiveProvider() *MassiveProvider {
+	return &MassiveProvider{
+		BaseURL: massiveBaseURL,
+		Timeout: 30 * time.Second,
+	}
+}
+
+func (p *MassiveProvider) apiKey() string {
+	if p.APIKey != "" {
+		return p.APIKey
+	}
+	return secrets.MassiveMainApiKeyValue
+}
+
+// get performs a GET against the Massive API and returns the raw body.
+func (p *MassiveProvider) get(ctx context.Context, path string, q url.Values) ([]byte, error) {
+	if q == nil {
+		q = url.Values{}
+	}
+	q.Set("apiKey", p.apiKey())
+	return p.fetch(ctx, p.BaseURL+path+"?"+q.Encode())
+}
+
+// next follows a next_url pagination link, which Massive returns without the
+// API key.
+func (p *MassiveProvider) next(ctx context.Context, link string) ([]byte, error) {
+	u, err := url.Parse(link)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("apiKey", p.apiKey())
+	u.RawQuery = q.Encode()
+	return p.fetch(ctx, u.String())
+}
+
+func (p *MassiveProvider) fetch(ctx context.Context, u string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{Timeout: p.Timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("massive api error: status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+func (p *MassiveProvider) Bars(ctx context.Context, r BarsRequest) ([]Bar, error) {
+	mult := r.Multiplier
+	if mult <= 0 {
+		mult = 1
+	}
+	path := fmt.Sprintf("/v2/aggs/ticker/%s/range/%d/%s/%s/%s", r.Ticker, mult, r.Timespan, r.From, r.To)
+	q := url.Values{}
+	q.Set("sort", "asc")
+	q.Set("limit", "50000")
+	q.Set("adjusted", strconv.FormatBool(r.Adjusted))
+
+	// Responses are capped at limit bars; follow next_url for the rest
+	var out []Bar
+	body, err := p.get(ctx, path, q)
+	for {
+		if err != nil {
+			return nil, err
+		}
+		bars, next, err := decodeAggregatesPage(body)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, bars...)
+		if next == "" {
+			return out, nil
+		}
+		body, err = p.next(ctx, next)
+	}
+}
+
+func (p *MassiveProvider) LastTrade(ctx context.Context, ticker string) (*LastTrade, error) {
+	resp, err := GetMassiveClient().GetLastTrade(ctx, &models.GetLastTradeParams{Ticker: ticker})
+	if err != nil {
+		return nil, err
+	}
+	if resp.ErrorMessage != "" {
+		return nil, fmt.Errorf("massive api error: %s", resp.ErrorMessage)
+	}
+	if resp.Results.Price == 0 {
+		return nil, ErrNotFound
+	}
+	return &LastTrade{
+		Ticker:    ticker,
+		Price:     resp.Results.Price,
+		Size:      resp.Results.Size,
+		Timestamp: time.Time(resp.Results.Timestamp).UnixMilli(),
+	}, nil
+}
+
+func (p *MassiveProvider) Snapshot(ctx context.Context, ticker string) (*Snapshot, error) {
+	body, err := p.get(ctx, "/v2/snapshot/locale/us/markets/stocks/tickers/"+ticker, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeSnapshot(ticker, body)
+}
+
+func (p *MassiveProvider) TickerDetails(ctx context.Context, ticker string) (*TickerDetails, error) {
+	body, err := p.get(ctx, "/v3/reference/tickers/"+ticker, nil)
+	if err != nil {
+		return nil, err
+	}
+	return decodeTickerDetails(body)
+}
+
+// ── Massive payload decoding (shared with the fixture provider) ───────────────
+
+func decodeAggregates(body []byte) ([]Bar, error) {
+	bars, _, err := decodeAggregatesPage(body)
+	return bars, err
+}
+
+// decodeAggregatesPage also returns the link to the next page, empty on the last.
+func decodeAggregatesPage(body []byte) ([]Bar, string, error) {
+	var raw struct {
+		Results []Bar  `json:"results"`
+		NextURL string `json:"next_url"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, "", err
+	}
+	if raw.Results == nil {
+		return []Bar{}, raw.NextURL, nil
+	}
+	return raw.Results, raw.NextURL, nil
+}
+
+func decodeTickerDetails(body []byte) (*TickerDetails, error) {
+	var raw struct {
+		Results json.RawMessage `json:"results"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	if len(raw.Results) == 0 || string(raw.Results) == "null" {
+		return nil, ErrNotFound
+	}
+	var details TickerDetails
+	if err := json.Unmarshal(raw.Results, &details); err != nil {
+		return nil, err
+	}
+	details.Raw = raw.Results
+	return &details, nil
+}
+
+func decodeSnapshot(ticker string, body []byte) (*Snapshot, error) {
+	type ohlcv struct {
+		O float64 `json:"o"`
+		H float64 `json:"h"`
+		L float64 `json:"l"`
+		C float64 `json:"c"`
+		V float64 `json:"v"`
+	}
+	var raw struct {
+		Ticker *struct {
+			Day       ohlcv `json:"day"`
+			PrevDay   ohlcv `json:"prevDay"`
+			LastTrade struct {
+				P float64 `json:"p"`
+			} `json:"lastTrade"`
+			Updated int64 `json:"updated"` // unix ns
+		} `json:"ticker"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return nil, err
+	}
+	if raw.Ticker == nil {
+		return nil, ErrNotFound
+	}
+
+	t := raw.Ticker
+	last := t.LastTrade.P
+	if last == 0 {
+		last = t.Day.C
+	}
+	return &Snapshot{
+		Ticker:    ticker,
+		LastPrice: last,
+		DayOpen:   t.Day.O,
+		DayHigh:   t.Day.H,
+		DayLow:    t.Day.L,
+		DayClose:  t.Day.C,
+		DayVolume: t.Day.V,
+		PrevClose: t.PrevDay.C,
+		Updated:   t.Updated / int64(time.Millisecond),
+	}, nil
+}
@@ -0,0 +1,33 @@
+package datafeed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMassiveBarsFollowsNextURL(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(res http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "key", req.URL.Query().Get("apiKey"))
+		switch req.URL.Query().Get("cursor") {
+		case "":
+			_, _ = res.Write([]byte(`{"results":[{"t":1,"c":1},{"t":2,"c":2}],"next_url":"` + server.URL + `/v2/aggs/page?cursor=abc"}`))
+		case "abc":
+			_, _ = res.Write([]byte(`{"results":[{"t":3,"c":3}]}`))
+		default:
+			res.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	p := &MassiveProvider{BaseURL: server.URL, APIKey: "key", Timeout: time.Second}
+	bars, err := p.Bars(context.Background(), BarsRequest{Ticker: "AAPL", Timespan: "minute", From: "2024-01-01", To: "2024-12-31"})
+	require.NoError(t, err)
+	assert.Equal(t, []Bar{{Timestamp: 1, Close: 1}, {Timestamp: 2, Close: 2}, {Timestamp: 3, Close: 3}}, bars)
+}
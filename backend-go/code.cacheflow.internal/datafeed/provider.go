@@ -0,0 +1,120 @@
+package datafeed
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// ErrNotFound is returned by a MarketDataProvider when the vendor has no data
+// for the requested ticker / range.
+var ErrNotFound = errors.New("market data not found")
+
+// Bar is a single OHLCV aggregate. JSON tags mirror the Massive aggregates
+// payload so handlers can hand bars straight to the frontend.
+type Bar struct {
+	Timestamp    int64   `json:"t"` // bar open time, unix ms
+	Open         float64 `json:"o"`
+	High         float64 `json:"h"`
+	Low          float64 `json:"l"`
+	Close        float64 `json:"c"`
+	Volume       float64 `json:"v"`
+	VWAP         float64 `json:"vw,omitempty"`
+	Transactions int64   `json:"n,omitempty"`
+}
+
+// BarsRequest describes an aggregates query. From / To accept YYYY-MM-DD or unix ms.
+type BarsRequest struct {
+	Ticker     string
+	Multiplier int
+	Timespan   string // minute, hour, day, week, month
+	From       string
+	To         string
+	Adjusted   bool
+}
+
+// LastTrade is the most recent trade print for a ticker.
+type LastTrade struct {
+	Ticker    string  `json:"ticker"`
+	Price     float64 `json:"price"`
+	Size      float64 `json:"size"`
+	Timestamp int64   `json:"timestamp"` // unix ms
+}
+
+// Snapshot is the current day's state for a ticker.
+type Snapshot struct {
+	Ticker    string  `json:"ticker"`
+	LastPrice float64 `json:"last_price"` // last trade, falling back to the day's close
+	DayOpen   float64 `json:"day_open"`
+	DayHigh   float64 `json:"day_high"`
+	DayLow    float64 `json:"day_low"`
+	DayClose  float64 `json:"day_close"`
+	DayVolume float64 `json:"day_volume"`
+	PrevClose float64 `json:"prev_close"` // 0 when unknown
+	Updated   int64   `json:"updated"`    // unix ms
+}
+
+// TickerDetails is reference data for a ticker. JSON tags mirror Massive's
+// /v3/reference/tickers payload, which the frontend reads directly.
+type TickerDetails struct {
+	Ticker                      string  `json:"ticker"`
+	Name                        string  `json:"name"`
+	Market                      string  `json:"market,omitempty"`
+	Locale                      string  `json:"locale,omitempty"`
+	PrimaryExchange             string  `json:"primary_exchange,omitempty"`
+	Type                        string  `json:"type,omitempty"`
+	Active                      bool    `json:"active"`
+	CurrencyName                string  `json:"currency_name,omitempty"`
+	MarketCap                   float64 `json:"market_cap,omitempty"`
+	Description                 string  `json:"description,omitempty"`
+	HomepageURL                 string  `json:"homepage_url,omitempty"`
+	ListDate                    string  `json:"list_date,omitempty"`
+	SICDescription              string  `json:"sic_description,omitempty"`
+	TotalEmployees              int64   `json:"total_employees,omitempty"`
+	ShareClassSharesOutstanding float64 `json:"share_class_shares_outstanding,omitempty"`
+	WeightedSharesOutstanding   float64 `json:"weighted_shares_outstanding,omitempty"`
+
+	// The vendor's full reference object, such as branding and address, which
+	// the ticker overview passes through unchanged; nil when there is none
+	Raw json.RawMessage `json:"-"`
+}
+
+// MarketDataProvider is the single seam between CacheFlow and a market data vendor.
+// Routes and the strategy engine only ever talk to this interface.
+type MarketDataProvider interface {
+	Bars(ctx context.Context, r BarsRequest) ([]Bar, error)
+	LastTrade(ctx context.Context, ticker string) (*LastTrade, error)
+	Snapshot(ctx context.Context, ticker string) (*Snapshot, error)
+	TickerDetails(ctx context.Context, ticker string) (*TickerDetails, error)
+}
+
+// MARK: Global Provider
+var (
+	providerMu     sync.RWMutex
+	globalProvider MarketDataProvider
+)
+
+// SetProvider installs the provider used by the datafeed routes. Call once at startup.
+func SetProvider(p MarketDataProvider) {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	globalProvider = p
+}
+
+// GetProvider returns the installed provider, defaulting to Massive.
+func GetProvider() MarketDataProvider {
+	providerMu.RLock()
+	p := globalProvider
+	providerMu.RUnlock()
+	if p != nil {
+		return p
+	}
+
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	if globalProvider == nil {
+		globalProvider = NewMassiveProvider()
+	}
+	return globalProvider
+}
@@ -1,23 +1,19 @@
 package datafeed
 
 import (
-	"encoding/json"
-	"fmt"
-	"io"
+	"errors"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"code.cacheflow.internal/util"
 	"code.cacheflow.internal/util/httpx"
-	"code.cacheflow.internal/util/secrets"
 
 	"github.com/charmbracelet/log"
 )
 
-const massiveBaseURL = "https://api.massive.com"
-
 func GetTickerOverview(res http.ResponseWriter, req *http.Request) {
 	logger := log.NewWithOptions(os.Stderr, log.Options{
 		Prefix: "DATAFEED (TO)",
@@ -28,44 +24,30 @@ func GetTickerOverview(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	url := fmt.Sprintf("%s/v3/reference/tickers/%s?apiKey=%s", massiveBaseURL, ticker, secrets.MassiveMainApiKeyValue)
-	httpReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, url, nil)
+	details, err := GetProvider().TickerDetails(req.Context(), strings.ToUpper(ticker))
 	if err != nil {
-		logger.Error("failed to create request", "err", err)
-		httpx.WriteError(res, req, httpx.Internal("failed to request ticker details"))
+		logger.Error("ticker details request failed", "ticker", ticker, "err", err)
+		writeProviderError(res, req, err, "Ticker not found or API error", "failed to fetch ticker details")
 		return
 	}
 
-	client := &http.Client{Timeout: 15 * time.Second}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		logger.Error("massive api request failed", "err", err)
-		httpx.WriteError(res, req, httpx.Internal("failed to fetch ticker details"))
-		return
-	}
-	defer resp.Body.Close()
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		logger.Error("failed to read response", "err", err)
-		httpx.WriteError(res, req, httpx.Internal("failed to read ticker response"))
-		return
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		logger.Error("massive api error", "status", resp.StatusCode, "body", string(body))
-		httpx.WriteError(res, req, httpx.NotFound("Ticker not found or API error"))
-		return
+	var results any = details
+	if details.Raw != nil {
+		results = details.Raw
 	}
+	util.JSONResponse(res, http.StatusOK, map[string]any{
+		"status":  "OK",
+		"results": results,
+	})
+}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		logger.Error("failed to parse response", "err", err)
-		httpx.WriteError(res, req, httpx.Internal("invalid ticker response"))
+// writeProviderError maps a MarketDataProvider error onto the standard API error.
+func writeProviderError(res http.ResponseWriter, req *http.Request, err error, notFoundMsg, internalMsg string) {
+	if errors.Is(err, ErrNotFound) {
+		httpx.WriteError(res, req, httpx.NotFound(notFoundMsg))
 		return
 	}
-
-	util.JSONResponse(res, http.StatusOK, result)
+	httpx.WriteError(res, req, httpx.Internal(internalMsg))
 }
 
 // timeframeToRange returns multiplier, timespan, from, to for Massive aggregates API.
@@ -147,46 +129,33 @@ func GetTickerAggregates(res http.ResponseWriter, req *http.Request) {
 }
 
 func proxyAggregates(res http.ResponseWriter, req *http.Request, logger *log.Logger, ticker, multiplier, timespan, from, to string) {
-	url := fmt.Sprintf("%s/v2/aggs/ticker/%s/range/%s/%s/%s/%s?apiKey=%s&sort=asc&limit=5000",
-		massiveBaseURL, ticker, multiplier, timespan, from, to, secrets.MassiveMainApiKeyValue)
-
-	httpReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, url, nil)
-	if err != nil {
-		logger.Error("failed to create request", "err", err)
-		httpx.WriteError(res, req, httpx.Internal("failed to request aggregates"))
-		return
-	}
-
-	client := &http.Client{Timeout: 20 * time.Second}
-	resp, err := client.Do(httpReq)
-	if err != nil {
-		logger.Error("massive api request failed", "err", err)
-		httpx.WriteError(res, req, httpx.Internal("failed to fetch aggregates"))
+	mult, err := strconv.Atoi(multiplier)
+	if err != nil || mult <= 0 {
+		httpx.WriteError(res, req, httpx.BadRequest("multiplier must be a positive integer", nil))
 		return
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	bars, err := GetProvider().Bars(req.Context(), BarsRequest{
+		Ticker:     strings.ToUpper(ticker),
+		Multiplier: mult,
+		Timespan:   timespan,
+		From:       from,
+		To:         to,
+		Adjusted:   true,
+	})
 	if err != nil {
-		logger.Error("failed to read response", "err", err)
-		httpx.WriteError(res, req, httpx.Internal("failed to read aggregates response"))
-		return
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		logger.Error("massive api error", "status", resp.StatusCode, "body", string(body))
-		httpx.WriteError(res, req, httpx.NotFound("Aggregates not found or API error"))
+		logger.Error("aggregates request failed", "ticker", ticker, "err", err)
+		writeProviderError(res, req, err, "Aggregates not found or API error", "failed to fetch aggregates")
 		return
 	}
 
-	var result map[string]interface{}
-	if err := json.Unmarshal(body, &result); err != nil {
-		logger.Error("failed to parse response", "err", err)
-		httpx.WriteError(res, req, httpx.Internal("invalid aggregates response"))
-		return
-	}
-
-	util.JSONResponse(res, http.StatusOK, result)
+	util.JSONResponse(res, http.StatusOK, map[string]any{
+		"ticker":       strings.ToUpper(ticker),
+		"adjusted":     true,
+		"status":       "OK",
+		"resultsCount": len(bars),
+		"results":      bars,
+	})
 }
 
 // GetTickerSnapshots returns latest price and intraday change for one or more tickers.
@@ -223,96 +192,42 @@ func GetTickerSnapshots(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-
 	type Snapshot struct {
-		Ticker         string   `json:"ticker"`
-		LastPrice      float64  `json:"last_price"`
-		PrevClose      *float64 `json:"prev_close,omitempty"`
-		Change         *float64 `json:"change,omitempty"`
-		ChangePercent  *float64 `json:"change_percent,omitempty"`
-		Raw            any      `json:"raw,omitempty"`
+		Ticker        string   `json:"ticker"`
+		LastPrice     float64  `json:"last_price"`
+		PrevClose     *float64 `json:"prev_close,omitempty"`
+		Change        *float64 `json:"change,omitempty"`
+		ChangePercent *float64 `json:"change_percent,omitempty"`
 	}
 
+	provider := GetProvider()
 	var snapshots []Snapshot
 
 	for _, t := range tickers {
-		url := fmt.Sprintf("%s/v2/snapshot/locale/us/markets/stocks/tickers/%s?apiKey=%s",
-			massiveBaseURL, t, secrets.MassiveMainApiKeyValue)
-
-		httpReq, err := http.NewRequestWithContext(req.Context(), http.MethodGet, url, nil)
-		if err != nil {
-			logger.Error("failed to create snapshot request", "ticker", t, "err", err)
-			continue
-		}
-
-		resp, err := client.Do(httpReq)
+		snap, err := provider.Snapshot(req.Context(), t)
 		if err != nil {
 			logger.Error("snapshot request failed", "ticker", t, "err", err)
 			continue
 		}
 
-		body, err := io.ReadAll(resp.Body)
-		resp.Body.Close()
-		if err != nil {
-			logger.Error("failed to read snapshot response", "ticker", t, "err", err)
-			continue
-		}
-
-		if resp.StatusCode != http.StatusOK {
-			logger.Error("snapshot api error", "ticker", t, "status", resp.StatusCode, "body", string(body))
-			continue
-		}
-
-		var parsed struct {
-			Status string                 `json:"status"`
-			Ticker map[string]any         `json:"ticker"`
-		}
-		if err := json.Unmarshal(body, &parsed); err != nil {
-			logger.Error("failed to parse snapshot response", "ticker", t, "err", err)
-			continue
-		}
-
-		var lastPrice float64
 		var prevClosePtr *float64
 		var changePtr *float64
 		var changePctPtr *float64
 
-		if lastTradeRaw, ok := parsed.Ticker["lastTrade"]; ok {
-			if m, ok := lastTradeRaw.(map[string]any); ok {
-				if p, ok := m["p"].(float64); ok {
-					lastPrice = p
-				}
-			}
-		}
-		if lastPrice == 0 {
-			if dayRaw, ok := parsed.Ticker["day"]; ok {
-				if m, ok := dayRaw.(map[string]any); ok {
-					if c, ok := m["c"].(float64); ok {
-						lastPrice = c
-					}
-				}
-			}
-		}
-
-		if prevDayRaw, ok := parsed.Ticker["prevDay"]; ok {
-			if m, ok := prevDayRaw.(map[string]any); ok {
-				if c, ok := m["c"].(float64); ok {
-					prevClose := c
-					prevClosePtr = &prevClose
-					if lastPrice != 0 {
-						ch := lastPrice - c
-						cp := (ch / c) * 100
-						changePtr = &ch
-						changePctPtr = &cp
-					}
-				}
+		if snap.PrevClose != 0 {
+			prevClose := snap.PrevClose
+			prevClosePtr = &prevClose
+			if snap.LastPrice != 0 {
+				ch := snap.LastPrice - prevClose
+				cp := (ch / prevClose) * 100
+				changePtr = &ch
+				changePctPtr = &cp
 			}
 		}
 
 		snapshots = append(snapshots, Snapshot{
 			Ticker:        t,
-			LastPrice:     lastPrice,
+			LastPrice:     snap.LastPrice,
 			PrevClose:     prevClosePtr,
 			Change:        changePtr,
 			ChangePercent: changePctPtr,
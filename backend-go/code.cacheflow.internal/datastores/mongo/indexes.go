@@ -53,4 +53,144 @@ func EnsureIndexes() {
 	} else {
 		log.Info("portfolio indexes ensured")
 	}
-}
\ No newline at end of file
+
+	// Bar cache collection
+	barsCollection := db.Collection(Bars)
+
+	barIndexes := []mongodriver.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "ticker", Value: 1},
+				{Key: "multiplier", Value: 1},
+				{Key: "timespan", Value: 1},
+				{Key: "adjusted", Value: 1},
+				{Key: "t", Value: 1},
+			},
+			Options: options.Index().SetName("series_t_1").SetUnique(true),
+		},
+	}
+
+	_, err = barsCollection.Indexes().CreateMany(context.Background(), barIndexes)
+	if err != nil {
+		log.Error("failed to create bar indexes", "err", err)
+	} else {
+		log.Info("bar indexes ensured")
+	}
+
+	barCoverageIndexes := []mongodriver.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "ticker", Value: 1},
+				{Key: "multiplier", Value: 1},
+				{Key: "timespan", Value: 1},
+				{Key: "adjusted", Value: 1},
+			},
+			Options: options.Index().SetName("series_1").SetUnique(true),
+		},
+	}
+
+	_, err = db.Collection(BarCoverage).Indexes().CreateMany(context.Background(), barCoverageIndexes)
+	if err != nil {
+		log.Error("failed to create bar coverage indexes", "err", err)
+	} else {
+		log.Info("bar coverage indexes ensured")
+	}
+
+	// Backtest equity curves
+	equityIndexes := []mongodriver.IndexModel{
+		{
+			Keys:    bson.D{{Key: "backtest_uuid", Value: 1}},
+			Options: options.Index().SetName("backtest_uuid_1").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "strategy_uuid", Value: 1}},
+			Options: options.Index().SetName("strategy_uuid_1"),
+		},
+	}
+
+	_, err = db.Collection(BacktestEquity).Indexes().CreateMany(context.Background(), equityIndexes)
+	if err != nil {
+		log.Error("failed to create backtest equity indexes", "err", err)
+	} else {
+		log.Info("backtest equity indexes ensured")
+	}
+
+	// Backtest job queue
+	jobIndexes := []mongodriver.IndexModel{
+		{
+			Keys:    bson.D{{Key: "uuid", Value: 1}},
+			Options: options.Index().SetName("uuid_1").SetUnique(true),
+		},
+		{
+			Keys:    bson.D{{Key: "status", Value: 1}, {Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("status_1_created_at_1"),
+		},
+		{
+			Keys:    bson.D{{Key: "account_id", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("account_id_1_created_at_-1"),
+		},
+	}
+
+	_, err = db.Collection(BacktestJobs).Indexes().CreateMany(context.Background(), jobIndexes)
+	if err != nil {
+		log.Error("failed to create backtest job indexes", "err", err)
+	} else {
+		log.Info("backtest job indexes ensured")
+	}
+
+	// Monte Carlo runs
+	monteCarloIndexes := []mongodriver.IndexModel{
+		{
+			Keys:    bson.D{{Key: "backtest_uuid", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("backtest_uuid_1_created_at_-1"),
+		},
+	}
+
+	_, err = db.Collection(MonteCarlo).Indexes().CreateMany(context.Background(), monteCarloIndexes)
+	if err != nil {
+		log.Error("failed to create monte carlo indexes", "err", err)
+	} else {
+		log.Info("monte carlo indexes ensured")
+	}
+
+	// Live strategy state: one document per strategy
+	_, err = db.Collection(StrategyLive).Indexes().CreateOne(context.Background(), mongodriver.IndexModel{
+		Keys:    bson.D{{Key: "strategy_uuid", Value: 1}},
+		Options: options.Index().SetName("strategy_uuid_1").SetUnique(true),
+	})
+	if err != nil {
+		log.Error("failed to create live strategy indexes", "err", err)
+	} else {
+		log.Info("live strategy indexes ensured")
+	}
+
+	// Strategy revisions
+	_, err = db.Collection(StrategyRevisions).Indexes().CreateOne(context.Background(), mongodriver.IndexModel{
+		Keys:    bson.D{{Key: "strategy_uuid", Value: 1}, {Key: "revision", Value: -1}},
+		Options: options.Index().SetName("strategy_uuid_1_revision_-1").SetUnique(true),
+	})
+	if err != nil {
+		log.Error("failed to create strategy revision indexes", "err", err)
+	} else {
+		log.Info("strategy revision indexes ensured")
+	}
+
+	// Live decisions, kept for 90 days
+	decisionIndexes := []mongodriver.IndexModel{
+		{
+			Keys:    bson.D{{Key: "strategy_uuid", Value: 1}, {Key: "created_at", Value: -1}},
+			Options: options.Index().SetName("strategy_uuid_1_created_at_-1"),
+		},
+		{
+			Keys:    bson.D{{Key: "created_at", Value: 1}},
+			Options: options.Index().SetName("created_at_ttl").SetExpireAfterSeconds(90 * 24 * 60 * 60),
+		},
+	}
+
+	_, err = db.Collection(StrategyDecisions).Indexes().CreateMany(context.Background(), decisionIndexes)
+	if err != nil {
+		log.Error("failed to create live decision indexes", "err", err)
+	} else {
+		log.Info("live decision indexes ensured")
+	}
+}
@@ -7,4 +7,12 @@ const (
 	Orders                      = "orders"
 	Strategies                  = "strategies"
 	Backtests                   = "backtests"
+	BacktestEquity              = "backtests-equity"
+	BacktestJobs                = "backtests-jobs"
+	MonteCarlo                  = "backtests-montecarlo"
+	StrategyLive                = "strategies-live"
+	StrategyDecisions           = "strategies-decisions"
+	StrategyRevisions           = "strategies-revisions"
+	Bars                        = "bars"
+	BarCoverage                 = "bars-coverage"
 )
@@ -30,6 +30,7 @@ require (
 	github.com/awa/go-iap v1.43.2
 	github.com/bybit-exchange/bybit.go.api v0.0.0-20250727214011-c9347d6804d6
 	github.com/diegobernardes/ctrader v0.0.0-20250109002714-4ec2415062f4
+	github.com/massive-com/client-go/v2 v2.0.0
 	github.com/redis/go-redis/v9 v9.12.0
 	google.golang.org/genai v1.19.0
 )
@@ -54,7 +55,6 @@ require (
 	github.com/go-jose/go-jose/v4 v4.1.3 // indirect
 	github.com/google/go-cmp v0.7.0 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
-	github.com/massive-com/client-go/v2 v2.0.0 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/oliveroneill/exponent-server-sdk-golang v0.0.0-20210823140141-d050598be512 // indirect
@@ -1,8 +1,10 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"os"
+	"time"
 
 	"code.cacheflow.internal/account/oauth"
 	accountRoutes "code.cacheflow.internal/account/routes"
@@ -36,6 +38,26 @@ func main() {
 
 	datastores.ConnectDB(secrets.DatabaseSecretValue)
 	datastores.EnsureIndexes()
+	strategyRoutes.MigrateRuleTrees(context.Background())
+
+	// Market data provider: Massive by default, recorded fixtures for offline runs.
+	// Bars are served through the Mongo bar cache either way.
+	var upstream datafeed.MarketDataProvider = datafeed.NewMassiveProvider()
+	if dir := os.Getenv("CACHEFLOW_FIXTURE_DIR"); dir != "" {
+		upstream = datafeed.NewFixtureProvider(dir)
+		logger.Info("Using fixture market data", "dir", dir)
+	}
+	provider := datafeed.NewCachedProvider(upstream)
+	datafeed.SetProvider(provider)
+
+	// Keep watchlist tickers' daily bars current
+	go datafeed.RunBarSync(context.Background(), provider, time.Hour)
+
+	// Run queued backtests in the background, two at a time
+	strategyRoutes.StartBacktestWorkers(context.Background(), 2)
+
+	// Paper-trade strategies with live trading enabled
+	go strategyRoutes.RunLiveTrading(context.Background(), provider, 15*time.Minute)
 
 	r := chi.NewRouter()
 
@@ -90,7 +112,7 @@ func main() {
 		datafeed.GetCompanyData(w, r)
 	})
 
-	// Stock data from Massive API (ticker overview + aggregates)
+	// Stock data from the market data provider (ticker overview + aggregates)
 	r.Get("/v1/datafeed/stock", datafeed.GetTickerOverview)
 	r.Get("/v1/datafeed/stock/aggregates", datafeed.GetTickerAggregatesWithTimeframe)
 	r.Get("/v1/datafeed/stock/aggregates-range", datafeed.GetTickerAggregates)
@@ -118,20 +140,44 @@ func main() {
 	r.Get("/v1/orders", orderRoutes.GetOrders)
 	r.Get("/v1/portfolio/positions", orderRoutes.GetPositions)
 
-	// Indicator proxies (for frontend charts)
+	// Indicators computed from bars (for frontend charts)
 	r.Get("/v1/datafeed/indicators/rsi", datafeed.GetRSI)
 	r.Get("/v1/datafeed/indicators/ema", datafeed.GetEMA)
 	r.Get("/v1/datafeed/indicators/sma", datafeed.GetSMA)
 	r.Get("/v1/datafeed/indicators/macd", datafeed.GetMACD)
+	r.Get("/v1/datafeed/indicators/vwap", datafeed.GetVWAP)
+	r.Get("/v1/datafeed/indicators/bollinger", datafeed.GetBollinger)
+	r.Get("/v1/datafeed/indicators/atr", datafeed.GetATR)
+	r.Get("/v1/datafeed/indicators/stochastic", datafeed.GetStochastic)
+	r.Get("/v1/datafeed/indicators/adx", datafeed.GetADX)
+	r.Get("/v1/datafeed/indicators/obv", datafeed.GetOBV)
+	r.Get("/v1/datafeed/indicators/donchian", datafeed.GetDonchian)
 
 	// Strategies + Backtests + Monte Carlo
 	r.Post("/v1/strategy", strategyRoutes.CreateStrategy)
 	r.Get("/v1/strategies", strategyRoutes.GetStrategies)
 	r.Put("/v1/strategy", strategyRoutes.UpdateStrategy)
 	r.Delete("/v1/strategy", strategyRoutes.DeleteStrategy)
+	r.Get("/v1/strategy/revisions", strategyRoutes.GetStrategyRevisions)
+	r.Get("/v1/strategy/revisions/diff", strategyRoutes.DiffStrategyRevisions)
+	r.Post("/v1/strategy/validate", strategyRoutes.ValidateStrategy)
+	r.Get("/v1/strategy/dsl", strategyRoutes.GetStrategyDSL)
+	r.Post("/v1/strategy/dsl/validate", strategyRoutes.ValidateStrategyDSL)
 	r.Post("/v1/strategy/backtest", strategyRoutes.RunBacktest)
 	r.Get("/v1/strategy/backtests", strategyRoutes.GetBacktests)
+	r.Get("/v1/strategy/backtest/equity", strategyRoutes.GetBacktestEquity)
+	r.Post("/v1/strategy/backtest/rerun", strategyRoutes.RerunBacktest)
+	r.Post("/v1/strategy/backtest/jobs", strategyRoutes.SubmitBacktestJob)
+	r.Get("/v1/strategy/backtest/jobs", strategyRoutes.GetBacktestJobs)
+	r.Get("/v1/strategy/backtest/job", strategyRoutes.GetBacktestJob)
+	r.Delete("/v1/strategy/backtest/job", strategyRoutes.CancelBacktestJob)
 	r.Post("/v1/strategy/montecarlo", strategyRoutes.RunMonteCarlo)
+	r.Get("/v1/strategy/montecarlos", strategyRoutes.GetMonteCarloRuns)
+	r.Post("/v1/strategy/optimize", strategyRoutes.OptimizeStrategy)
+	r.Post("/v1/strategy/walkforward", strategyRoutes.WalkForward)
+	r.Put("/v1/strategy/live", strategyRoutes.SetStrategyLive)
+	r.Get("/v1/strategy/live", strategyRoutes.GetStrategyLive)
+	r.Get("/v1/strategy/live/decisions", strategyRoutes.GetStrategyDecisions)
 
 	logger.Info("Server started at http://localhost:8080")
 	if err := http.ListenAndServe(":8080", r); err != nil {
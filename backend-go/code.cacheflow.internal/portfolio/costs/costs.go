@@ -0,0 +1,123 @@
+// Package costs models what a fill really costs: broker commission, regulatory
+// fees and slippage. Portfolios carry a Model for paper orders and backtests
+// may override it per request. A nil Model is free, frictionless execution.
+package costs
+
+import (
+	"errors"
+	"math"
+)
+
+// Order sides understood by Apply.
+const (
+	Buy  = "BUY"
+	Sell = "SELL"
+)
+
+// Regulatory fee defaults, charged on sells only.
+const (
+	DefaultSECFeeRate  = 0.0000278 // SEC Section 31 fee per $ of sell notional
+	DefaultTAFPerShare = 0.000166  // FINRA trading activity fee per share sold
+	DefaultTAFMax      = 8.30      // FINRA TAF cap per trade
+	defaultTickSize    = 0.01
+)
+
+// Model is a commission, fee and slippage schedule.
+type Model struct {
+	PerShare       float64 `json:"per_share" bson:"per_share"`             // commission $ per share
+	PerTrade       float64 `json:"per_trade" bson:"per_trade"`             // flat commission $ per order
+	NotionalBps    float64 `json:"notional_bps" bson:"notional_bps"`       // commission in bps of notional
+	MinCommission  float64 `json:"min_commission" bson:"min_commission"`   // commission floor per order
+	RegulatoryFees bool    `json:"regulatory_fees" bson:"regulatory_fees"` // charge SEC and TAF fees on sells
+	SECFeeRate     float64 `json:"sec_fee_rate" bson:"sec_fee_rate"`       // overrides DefaultSECFeeRate
+	TAFPerShare    float64 `json:"taf_per_share" bson:"taf_per_share"`     // overrides DefaultTAFPerShare
+	SlippageBps    float64 `json:"slippage_bps" bson:"slippage_bps"`       // adverse price move in bps
+	SlippageTicks  float64 `json:"slippage_ticks" bson:"slippage_ticks"`   // adverse price move in ticks
+	TickSize       float64 `json:"tick_size" bson:"tick_size"`             // default $0.01
+	BorrowRate     float64 `json:"borrow_rate" bson:"borrow_rate"`         // annual % charged on short notional, by calendar day
+}
+
+// Fill is the outcome of executing an order against a reference price.
+type Fill struct {
+	Price      float64 `json:"price"`      // execution price after slippage
+	Commission float64 `json:"commission"` // broker commission
+	Fees       float64 `json:"fees"`       // regulatory fees
+	Slippage   float64 `json:"slippage"`   // $ given up to slippage versus the reference price
+}
+
+// Costs is the cash charged on top of the notional.
+func (f Fill) Costs() float64 {
+	return f.Commission + f.Fees
+}
+
+// Validate rejects negative parameters.
+func (m *Model) Validate() error {
+	if m == nil {
+		return nil
+	}
+	for _, v := range []float64{m.PerShare, m.PerTrade, m.NotionalBps, m.MinCommission,
+		m.SECFeeRate, m.TAFPerShare, m.SlippageBps, m.SlippageTicks, m.TickSize, m.BorrowRate} {
+		if v < 0 || math.IsNaN(v) {
+			return errors.New("cost model values cannot be negative")
+		}
+	}
+	return nil
+}
+
+// Apply prices an order of qty shares on side at the reference price. Buys
+// fill higher and sells lower by the configured slippage.
+func (m *Model) Apply(side string, price, qty float64) Fill {
+	if m == nil || qty <= 0 {
+		return Fill{Price: price}
+	}
+
+	tick := m.TickSize
+	if tick <= 0 {
+		tick = defaultTickSize
+	}
+	slip := price*m.SlippageBps/10000 + m.SlippageTicks*tick
+	fillPrice := price + slip
+	if side == Sell {
+		fillPrice = math.Max(0, price-slip)
+	}
+	notional := fillPrice * qty
+
+	commission := m.PerTrade + m.PerShare*qty + notional*m.NotionalBps/10000
+	if commission > 0 {
+		commission = math.Max(commission, m.MinCommission)
+	}
+
+	var fees float64
+	if m.RegulatoryFees && side == Sell {
+		secRate := m.SECFeeRate
+		if secRate == 0 {
+			secRate = DefaultSECFeeRate
+		}
+		tafRate := m.TAFPerShare
+		if tafRate == 0 {
+			tafRate = DefaultTAFPerShare
+		}
+		// Both fees are rounded up to the next cent, as brokers pass them on
+		fees = ceilCents(notional*secRate) + ceilCents(math.Min(qty*tafRate, DefaultTAFMax))
+	}
+
+	return Fill{
+		Price:      fillPrice,
+		Commission: commission,
+		Fees:       fees,
+		Slippage:   math.Abs(fillPrice-price) * qty,
+	}
+}
+
+// Borrow is the stock loan fee for holding a short of notional dollars for days
+// calendar days.
+func (m *Model) Borrow(notional float64, days int) float64 {
+	if m == nil || days <= 0 {
+		return 0
+	}
+	return notional * m.BorrowRate / 100 * float64(days) / 365
+}
+
+func ceilCents(v float64) float64 {
+	return math.Ceil(v*100-1e-9) / 100
+}
@@ -0,0 +1,58 @@
+package costs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Model  *Model
+		Side   string
+		Expect Fill
+	}{
+		{
+			Name:   "No model is free",
+			Model:  nil,
+			Side:   Buy,
+			Expect: Fill{Price: 100},
+		},
+		{
+			Name:   "Buy slips up by bps and ticks",
+			Model:  &Model{SlippageBps: 10, SlippageTicks: 2, PerShare: 0.005, MinCommission: 1},
+			Side:   Buy,
+			Expect: Fill{Price: 100.12, Commission: 1, Slippage: 12},
+		},
+		{
+			Name:   "Sell slips down and pays regulatory fees",
+			Model:  &Model{SlippageBps: 10, PerTrade: 5, RegulatoryFees: true},
+			Side:   Sell,
+			Expect: Fill{Price: 99.9, Commission: 5, Fees: 0.28 + 0.02, Slippage: 10},
+		},
+		{
+			Name:   "Regulatory fees never apply to buys",
+			Model:  &Model{NotionalBps: 5, RegulatoryFees: true},
+			Side:   Buy,
+			Expect: Fill{Price: 100, Commission: 5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			fill := tt.Model.Apply(tt.Side, 100, 100)
+			assert.InDelta(t, tt.Expect.Price, fill.Price, 1e-9)
+			assert.InDelta(t, tt.Expect.Commission, fill.Commission, 1e-9)
+			assert.InDelta(t, tt.Expect.Fees, fill.Fees, 1e-9)
+			assert.InDelta(t, tt.Expect.Slippage, fill.Slippage, 1e-9)
+		})
+	}
+}
+
+func TestBorrow(t *testing.T) {
+	model := &Model{BorrowRate: 3.65}
+	assert.InDelta(t, 10.0, model.Borrow(10000, 10), 1e-9)
+	assert.Zero(t, model.Borrow(10000, 0))
+	assert.Zero(t, (*Model)(nil).Borrow(10000, 10))
+}
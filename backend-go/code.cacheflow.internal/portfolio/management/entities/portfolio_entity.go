@@ -2,6 +2,8 @@ package entities
 
 import (
 	"time"
+
+	"code.cacheflow.internal/portfolio/costs"
 )
 
 type WatchlistEntity struct {
@@ -33,6 +35,9 @@ type PortfolioEntity struct {
 	// Watchlists scoped to this portfolio
 	Watchlists []*WatchlistEntity `json:"watchlists,omitempty" bson:"watchlists,omitempty"`
 
+	// Commission, fee and slippage schedule applied to orders (nil = free execution)
+	CostModel *costs.Model `json:"cost_model,omitempty" bson:"cost_model,omitempty"`
+
 	// Timestamp for when the portfolio was created / updated
 	CreatedAt *time.Time `json:"created_at" bson:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at" bson:"updated_at"`
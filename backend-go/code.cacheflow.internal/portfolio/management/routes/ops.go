@@ -9,6 +9,7 @@ import (
 
 	accountEntities "code.cacheflow.internal/account/entities"
 	datastores "code.cacheflow.internal/datastores/mongo"
+	"code.cacheflow.internal/portfolio/costs"
 	orderEntities "code.cacheflow.internal/portfolio/order/entities"
 	portfolioEntities "code.cacheflow.internal/portfolio/management/entities"
 	"code.cacheflow.internal/util"
@@ -250,6 +251,7 @@ type UpdatePortfolioBody struct {
 	Name *string `json:"name"`
 	Description *string `json:"description"`
 	StartingBalance *float64 `json:"starting_balance"`
+	CostModel *costs.Model `json:"cost_model"`
 }
 
 func UpdatePortfolio(res http.ResponseWriter, req *http.Request) {
@@ -302,6 +304,13 @@ func UpdatePortfolio(res http.ResponseWriter, req *http.Request) {
 		return	
 	}
 
+	if err := body.CostModel.Validate(); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest(err.Error(), map[string]string{
+			"email": email,
+		}))
+		return
+	}
+
 	portfolioCollection := datastores.GetMongoDatabase(req.Context()).Collection(datastores.Portfolios)
 
 	// Load existing portfolio to determine if starting/current balance can be changed.
@@ -321,6 +330,11 @@ func UpdatePortfolio(res http.ResponseWriter, req *http.Request) {
 		"updated_at":  time.Now(),
 	}
 
+	// Cost model is only replaced when sent, so older clients don't clear it.
+	if body.CostModel != nil {
+		update["cost_model"] = body.CostModel
+	}
+
 	// Only allow changing starting/current balance if they are currently equal (no activity yet).
 	if body.StartingBalance != nil &&
 		existing.StartingBalance != nil &&
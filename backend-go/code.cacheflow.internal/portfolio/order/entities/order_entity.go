@@ -12,7 +12,11 @@ type OrderEntity struct {
 	Price *float64 `json:"price" bson:"price"`
 	TotalCost *float64 `json:"total_cost" bson:"total_cost"`
 	Realized *float64 `json:"realized" bson:"realized"`
+	Commission *float64 `json:"commission,omitempty" bson:"commission,omitempty"`
+	Fees *float64 `json:"fees,omitempty" bson:"fees,omitempty"`
+	Slippage *float64 `json:"slippage,omitempty" bson:"slippage,omitempty"`
 	Timestamp *time.Time `json:"timestamp" bson:"timestamp"`
 	AccountID *string `json:"account_id" bson:"account_id"`
 	PortfolioUUID *string `json:"portfolio_uuid" bson:"portfolio_uuid"`
+	StrategyUUID *string `json:"strategy_uuid,omitempty" bson:"strategy_uuid,omitempty"`
 }
\ No newline at end of file
@@ -46,9 +46,17 @@ func buildLotsForTicker(ctx context.Context, ticker, portfolioUUID string) ([]fi
 		switch *o.Side {
 		case "BUY":
 			if *o.Quantity > 0 {
+				// Commission and fees paid on the buy are part of the cost basis
+				cost := *o.Price
+				if o.Commission != nil {
+					cost += *o.Commission / float64(*o.Quantity)
+				}
+				if o.Fees != nil {
+					cost += *o.Fees / float64(*o.Quantity)
+				}
 				lots = append(lots, fifoLot{
 					Qty:       *o.Quantity,
-					CostPerSh: *o.Price,
+					CostPerSh: cost,
 				})
 			}
 		case "SELL":
@@ -81,7 +89,8 @@ func buildLotsForTicker(ctx context.Context, ticker, portfolioUUID string) ([]fi
 }
 
 // CalculateRealizedPnLForSell takes a SELL order (quantity + price) and returns the realized PnL
-// using FIFO based on all existing orders for that ticker + portfolio.
+// using FIFO based on all existing orders for that ticker + portfolio. The caller subtracts the
+// sell's own commission and fees.
 func CalculateRealizedPnLForSell(ctx context.Context, ticker, portfolioUUID string, sellQty int64, sellPrice float64) (float64, error) {
 	if ticker == "" || portfolioUUID == "" || sellQty <= 0 {
 		return 0, nil
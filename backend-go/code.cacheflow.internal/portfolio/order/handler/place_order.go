@@ -0,0 +1,133 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"code.cacheflow.internal/datafeed"
+	datastores "code.cacheflow.internal/datastores/mongo"
+	portfolioEntities "code.cacheflow.internal/portfolio/management/entities"
+	orderEntities "code.cacheflow.internal/portfolio/order/entities"
+	"code.cacheflow.internal/util/ptr"
+
+	"github.com/pborman/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// OrderRequest is a market order against a paper portfolio.
+type OrderRequest struct {
+	AccountID     string
+	PortfolioUUID string
+	Ticker        string
+	Side          string // BUY or SELL
+	Quantity      int64
+
+	// Set when a live strategy places the order
+	StrategyUUID string
+}
+
+// PlaceOrder fills req at the last trade price, priced through the portfolio's
+// cost model, records the order and moves the portfolio's cash. Error messages
+// are safe to show to the user.
+func PlaceOrder(ctx context.Context, req OrderRequest) (*orderEntities.OrderEntity, error) {
+	trade, err := datafeed.GetProvider().LastTrade(ctx, req.Ticker)
+	if err != nil {
+		return nil, errors.New("failed to get stock snapshot")
+	}
+
+	currentPrice := trade.Price
+
+	portfolioCollection := datastores.GetMongoDatabase(ctx).Collection(datastores.Portfolios)
+
+	var portfolio portfolioEntities.PortfolioEntity
+	err = portfolioCollection.FindOne(ctx, bson.M{"uuid": req.PortfolioUUID, "account_id": req.AccountID}).Decode(&portfolio)
+	if err != nil {
+		return nil, errors.New("portfolio not found")
+	}
+
+	// make sure portfolio belongs to account
+	if portfolio.AccountID == nil || *portfolio.AccountID != req.AccountID {
+		return nil, errors.New("portfolio does not belong to account")
+	}
+
+	// Price the fill with the portfolio's commission, fee and slippage schedule
+	fill := portfolio.CostModel.Apply(req.Side, currentPrice, float64(req.Quantity))
+	currentPrice = fill.Price
+	totalCost := float64(req.Quantity) * currentPrice
+	fees := fill.Costs()
+
+	var realizedPtr *float64
+
+	if req.Side == "BUY" {
+		if portfolio.CurrentBalance == nil || *portfolio.CurrentBalance < totalCost+fees {
+			return nil, errors.New("insufficient funds")
+		}
+	} else {
+
+		// check if the portfolio has enough shares to sell
+		activeShares, err := GetActiveShares(req.Ticker, req.PortfolioUUID)
+		if err != nil {
+			return nil, errors.New("failed to get active shares")
+		}
+
+		if activeShares < req.Quantity {
+			return nil, errors.New("insufficient shares")
+		}
+
+		// calculate realized PnL for this sell using FIFO
+		realized, err := CalculateRealizedPnLForSell(ctx, req.Ticker, req.PortfolioUUID, req.Quantity, currentPrice)
+		if err != nil {
+			return nil, errors.New("failed to calculate realized PnL")
+		}
+		realized -= fees
+		realizedPtr = &realized
+	}
+
+	// create order
+	order := &orderEntities.OrderEntity{
+		UUID:          ptr.String(uuid.NewRandom().String()),
+		Ticker:        ptr.String(req.Ticker),
+		Side:          ptr.String(req.Side),
+		Quantity:      &req.Quantity,
+		Price:         &currentPrice,
+		TotalCost:     &totalCost,
+		Realized:      realizedPtr,
+		Commission:    &fill.Commission,
+		Fees:          &fill.Fees,
+		Slippage:      &fill.Slippage,
+		Timestamp:     ptr.Time(time.Now()),
+		AccountID:     ptr.String(req.AccountID),
+		PortfolioUUID: ptr.String(req.PortfolioUUID),
+	}
+	if req.StrategyUUID != "" {
+		order.StrategyUUID = ptr.String(req.StrategyUUID)
+	}
+
+	ordersCollection := datastores.GetMongoDatabase(ctx).Collection(datastores.Orders)
+
+	if _, err := ordersCollection.InsertOne(ctx, order); err != nil {
+		return nil, errors.New("failed to create order")
+	}
+
+	// Update portfolio current balance: decrease on BUY, increase on SELL by trade notional,
+	// less commission and fees either way.
+	delta := totalCost - fees
+	if req.Side == "BUY" {
+		delta = -(totalCost + fees)
+	}
+
+	_, err = portfolioCollection.UpdateOne(
+		ctx,
+		bson.M{"uuid": req.PortfolioUUID, "account_id": req.AccountID},
+		bson.M{
+			"$inc": bson.M{"current_balance": delta},
+			"$set": bson.M{"updated_at": time.Now()},
+		},
+	)
+	if err != nil {
+		return nil, errors.New("failed to update portfolio balance")
+	}
+
+	return order, nil
+}
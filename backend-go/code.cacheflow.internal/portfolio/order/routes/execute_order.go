@@ -4,19 +4,12 @@ import (
 	"encoding/json"
 	"net/http"
 	"strings"
-	"time"
 
 	accountEntities "code.cacheflow.internal/account/entities"
-	"code.cacheflow.internal/datafeed"
 	datastores "code.cacheflow.internal/datastores/mongo"
-	portfolioEntities "code.cacheflow.internal/portfolio/management/entities"
-	orderEntities "code.cacheflow.internal/portfolio/order/entities"
 	orderHandler "code.cacheflow.internal/portfolio/order/handler"
 	"code.cacheflow.internal/util/httpx"
-	"code.cacheflow.internal/util/ptr"
 
-	"github.com/massive-com/client-go/v2/rest/models"
-	"github.com/pborman/uuid"
 	"go.mongodb.org/mongo-driver/bson"
 )
 
@@ -85,133 +78,19 @@ func ExecuteOrder(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	c := datafeed.GetMassiveClient()
-
-	resp, err := c.GetLastTrade(
-		req.Context(),
-		&models.GetLastTradeParams{
-			Ticker: *body.Ticker,
-		},
-	)
-	if err != nil {
-		httpx.WriteError(res, req, httpx.BadRequest("failed to get stock snapshot", map[string]string{
-			"email": email,
-		}))
-		return
-	}
-
-	if resp.ErrorMessage != "" {
-		httpx.WriteError(res, req, httpx.BadRequest("failed to get stock snapshot", map[string]string{
-			"email": email,
-		}))
-		return
-	}
-
-	currentPrice := resp.Results.Price
-
-	totalCost := float64(*body.Quantity) * currentPrice
-
-	portfolioCollection := datastores.GetMongoDatabase(req.Context()).Collection(datastores.Portfolios)
-
-	var portfolio portfolioEntities.PortfolioEntity
-	err = portfolioCollection.FindOne(req.Context(), bson.M{"uuid": *body.PortfolioUUID, "account_id": account.AccountID}).Decode(&portfolio)
+	order, err := orderHandler.PlaceOrder(req.Context(), orderHandler.OrderRequest{
+		AccountID: *account.AccountID,
+		PortfolioUUID: *body.PortfolioUUID,
+		Ticker: *body.Ticker,
+		Side: *body.Side,
+		Quantity: *body.Quantity,
+	})
 	if err != nil {
-		httpx.WriteError(res, req, httpx.BadRequest("portfolio not found", map[string]string{
-			"email": email,
-		}))
-		return	
-	}
-
-	// make sure portfolio belongs to account
-	if *portfolio.AccountID != *account.AccountID {
-		httpx.WriteError(res, req, httpx.BadRequest("portfolio does not belong to account", map[string]string{
-			"email": email,
-		}))
-		return
-	}
-
-	var realizedPtr *float64
-
-	if *body.Side == "BUY" {
-		if *portfolio.CurrentBalance < totalCost {
-			httpx.WriteError(res, req, httpx.BadRequest("insufficient funds", map[string]string{
-				"email": email,
-			}))
-			return
-		}
-	} else {
-
-		// check if the portfolio has enough shares to sell
-		activeShares, err := orderHandler.GetActiveShares(*body.Ticker, *body.PortfolioUUID)
-		if err != nil {
-			httpx.WriteError(res, req, httpx.BadRequest("failed to get active shares", map[string]string{
-				"email": email,
-			}))
-			return
-		}
-
-		if activeShares < *body.Quantity {
-			httpx.WriteError(res, req, httpx.BadRequest("insufficient shares", map[string]string{
-				"email": email,
-			}))
-			return
-		}
-
-		// calculate realized PnL for this sell using FIFO
-		realized, err := orderHandler.CalculateRealizedPnLForSell(req.Context(), *body.Ticker, *body.PortfolioUUID, *body.Quantity, currentPrice)
-		if err != nil {
-			httpx.WriteError(res, req, httpx.BadRequest("failed to calculate realized PnL", map[string]string{
-				"email": email,
-			}))
-			return
-		}
-		realizedPtr = &realized
-	}
-
-	// create order
-	order := &orderEntities.OrderEntity{
-		UUID: ptr.String(uuid.NewRandom().String()),
-		Ticker: body.Ticker,
-		Side: body.Side,
-		Quantity: body.Quantity,
-		Price: &currentPrice,
-		TotalCost: &totalCost,
-		Realized: realizedPtr,
-		Timestamp: ptr.Time(time.Now()),
-		AccountID: account.AccountID,
-		PortfolioUUID: body.PortfolioUUID,
-	}
-
-	ordersCollection := datastores.GetMongoDatabase(req.Context()).Collection(datastores.Orders)
-
-	_, err = ordersCollection.InsertOne(req.Context(), order)
-	if err != nil {
-		httpx.WriteError(res, req, httpx.BadRequest("failed to create order", map[string]string{
-			"email": email,
-		}))
-		return
-	}
-
-	// Update portfolio current balance: decrease on BUY, increase on SELL by trade notional.
-	delta := totalCost
-	if *body.Side == "BUY" {
-		delta = -totalCost
-	}
-
-	_, err = portfolioCollection.UpdateOne(
-		req.Context(),
-		bson.M{"uuid": *body.PortfolioUUID, "account_id": account.AccountID},
-		bson.M{
-			"$inc": bson.M{"current_balance": delta},
-			"$set": bson.M{"updated_at": time.Now()},
-		},
-	)
-	if err != nil {
-		httpx.WriteError(res, req, httpx.BadRequest("failed to update portfolio balance", map[string]string{
+		httpx.WriteError(res, req, httpx.BadRequest(err.Error(), map[string]string{
 			"email": email,
 		}))
 		return
 	}
 
 	httpx.WriteJSON(res, http.StatusCreated, order)
-}
\ No newline at end of file
+}
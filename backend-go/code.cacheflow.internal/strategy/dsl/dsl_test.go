@@ -0,0 +1,174 @@
+package dsl
+
+import (
+	"testing"
+
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	strategy, err := Parse(`
+		# mean reversion in an uptrend
+		buy when rsi(14) crosses_below 30 AND close > sma(200);
+		SELL WHEN trailing_stop 8% OR rsi(14) > 70
+	`)
+	assert.NoError(t, err)
+
+	assert.Equal(t, &strategyEntities.RuleGroup{Op: strategyEntities.RuleAnd, Children: []strategyEntities.RuleGroup{
+		{Rule: &strategyEntities.Rule{Type: strategyEntities.RuleRSICrossBelow, Window: 14, Value: 30}},
+		{Rule: &strategyEntities.Rule{Type: strategyEntities.RulePriceAboveSMA, Window: 200}},
+	}}, strategy.EntryRules)
+	assert.Equal(t, []strategyEntities.SellCondition{{Type: strategyEntities.SellTrailingStop, Percent: 8}}, strategy.SellConditions)
+	assert.Equal(t, &strategyEntities.RuleGroup{Rule: &strategyEntities.Rule{Type: strategyEntities.RuleRSIAbove, Window: 14, Value: 70}}, strategy.ExitRules)
+}
+
+func TestParsePrecedence(t *testing.T) {
+	strategy, err := Parse("BUY WHEN NOT adx(14) < 20 AND obv_bullish_divergence OR (close > ema(9) AND close > vwap + 1.5%)")
+	assert.NoError(t, err)
+
+	entry := strategy.EntryRules
+	assert.Equal(t, strategyEntities.RuleOr, entry.Op)
+	assert.Equal(t, strategyEntities.RuleAnd, entry.Children[0].Op)
+	assert.Equal(t, strategyEntities.RuleNot, entry.Children[0].Children[0].Op)
+	assert.Equal(t, 1.5, entry.Children[1].Children[1].Rule.VWAPDeviation)
+}
+
+func TestFormatRoundTrip(t *testing.T) {
+	sources := []string{
+		"BUY WHEN rsi(14) crosses_below 30 AND close > sma(200);\nSELL WHEN trailing_stop 8% OR rsi(14) > 70",
+		"BUY WHEN rsi < 30 OR rsi(7) crosses_above 50",
+		"BUY WHEN ema(12) crosses_above ema(26) AND NOT (sma(50) crosses_below sma(200) OR close < ema)",
+		"BUY WHEN macd(12, 26, 9) crosses_above signal AND macd > 0;\nSELL WHEN macd crosses_below signal OR macd(8, 21, 5) < 0",
+		"BUY WHEN close > vwap + 2% AND close < vwap - 0.5%;\nSELL WHEN close < vwap",
+		"BUY WHEN low touches bb_lower(20, 2.5) AND close crosses_above bb_upper(30);\nSELL WHEN high touches bb_upper",
+		"BUY WHEN close crosses_below bb_lower AND atr_pct(14) > 1.5 AND atr_pct < 4",
+		"BUY WHEN stoch(14, 3, 3) crosses_above signal AND adx(14) > 25;\nSELL WHEN stoch crosses_below signal OR adx < 20",
+		"BUY WHEN obv_bullish_divergence(20) AND close > donchian_high(55);\nSELL WHEN obv_bearish_divergence OR close < donchian_low(20)",
+		"BUY WHEN NOT NOT rsi > 50;\nSELL WHEN take_profit 12.5% OR stop_loss 4% OR rsi > 80 AND adx < 20",
+	}
+
+	for _, src := range sources {
+		t.Run(src, func(t *testing.T) {
+			strategy, err := Parse(src)
+			assert.NoError(t, err)
+
+			text, err := Format(strategy)
+			assert.NoError(t, err)
+			assert.Equal(t, src, text)
+		})
+	}
+}
+
+func TestFormatLegacyStrategy(t *testing.T) {
+	text, err := Format(&strategyEntities.StrategyEntity{
+		BuyRules: []strategyEntities.Rule{{Type: strategyEntities.RuleRSIBelow, Window: 14, Value: 30}},
+		SellConditions: []strategyEntities.SellCondition{
+			{Type: strategyEntities.SellStopLoss, Percent: 5},
+			{Type: strategyEntities.SellIndicator, Rule: &strategyEntities.Rule{Type: strategyEntities.RuleRSIAbove, Window: 14, Value: 70}},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "BUY WHEN rsi(14) < 30;\nSELL WHEN stop_loss 5% OR rsi(14) > 70", text)
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Source  string
+		Line    int
+		Column  int
+		Message string
+	}{
+		{
+			Name:    "Missing WHEN",
+			Source:  "BUY rsi > 30",
+			Line:    1,
+			Column:  5,
+			Message: `expected WHEN, found "rsi"`,
+		},
+		{
+			Name:    "Unknown condition",
+			Source:  "BUY WHEN close > rsi(14)",
+			Line:    1,
+			Column:  10,
+			Message: `unsupported condition "close > rsi(14)"`,
+		},
+		{
+			Name:    "Missing comparator",
+			Source:  "BUY WHEN\n  rsi(14) 30",
+			Line:    2,
+			Column:  11,
+			Message: `expected >, <, crosses_above, crosses_below or touches after rsi(14), found "30"`,
+		},
+		{
+			Name:    "Stop inside an AND",
+			Source:  "BUY WHEN rsi < 30; SELL WHEN rsi > 70 AND stop_loss 5%",
+			Line:    1,
+			Column:  43,
+			Message: "stop_loss can only be joined with OR at the top of a SELL statement",
+		},
+		{
+			Name:    "Fractional period",
+			Source:  "BUY WHEN close > sma(20.5)",
+			Line:    1,
+			Column:  22,
+			Message: "sma periods must be whole numbers up to 10000",
+		},
+		{
+			Name:    "Wrong argument count",
+			Source:  "BUY WHEN macd(12, 26) > 0",
+			Line:    1,
+			Column:  10,
+			Message: "macd takes 3 arguments or none",
+		},
+		{
+			Name:    "Unclosed group",
+			Source:  "BUY WHEN (rsi < 30 OR adx > 20",
+			Line:    1,
+			Column:  31,
+			Message: `expected ")", found end of input`,
+		},
+		{
+			Name:    "Unexpected character",
+			Source:  "BUY WHEN rsi <= 30",
+			Line:    1,
+			Column:  15,
+			Message: "unexpected character '='",
+		},
+		{
+			Name:    "Stop percent",
+			Source:  "BUY WHEN rsi < 30; SELL WHEN stop_loss 0%",
+			Line:    1,
+			Column:  40,
+			Message: "stop_loss needs a positive percent",
+		},
+		{
+			Name:    "No entry",
+			Source:  "SELL WHEN take_profit 10%",
+			Line:    1,
+			Column:  26,
+			Message: "a BUY WHEN statement is required",
+		},
+		{
+			Name:    "Two entries",
+			Source:  "BUY WHEN rsi < 30;\nBUY WHEN adx > 20",
+			Line:    2,
+			Column:  1,
+			Message: "only one BUY statement is allowed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			_, err := Parse(tt.Source)
+			var dslErr *Error
+			if assert.ErrorAs(t, err, &dslErr) {
+				assert.Equal(t, tt.Line, dslErr.Line)
+				assert.Equal(t, tt.Column, dslErr.Column)
+				assert.Equal(t, tt.Message, dslErr.Message)
+			}
+		})
+	}
+}
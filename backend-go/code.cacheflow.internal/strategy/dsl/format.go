@@ -0,0 +1,206 @@
+package dsl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+)
+
+// Operator precedence, loosest first.
+const (
+	precOr = iota + 1
+	precAnd
+	precNot
+)
+
+// Format writes a strategy's rules as text that Parse reads back to the same
+// rules. Indicator sell conditions are written as SELL conditions, so they
+// parse back into the exit rule tree, which trades identically. Legacy
+// strategies without an entry tree are formatted from BuyRules. The text only
+// covers the long side and full exits on the strategy's own bars, so
+// strategies with short rules, partial exits or rules on other timeframes
+// cannot be formatted.
+func Format(s *strategyEntities.StrategyEntity) (string, error) {
+	if s.ShortEntryRules != nil || s.ShortExitRules != nil {
+		return "", fmt.Errorf("short rules cannot be written as text")
+	}
+	entry, err := formatGroup(s.Entry(), 0)
+	if err != nil {
+		return "", err
+	}
+	if entry == "" {
+		return "", fmt.Errorf("strategy has no entry rules")
+	}
+	out := "BUY WHEN " + entry
+
+	var exits []string
+	for _, sc := range s.SellConditions {
+		if sc.Partial() {
+			return "", fmt.Errorf("partial exits cannot be written as text")
+		}
+		if name, ok := stops[sc.Type]; ok {
+			exits = append(exits, name+" "+number(sc.Percent)+"%")
+			continue
+		}
+		if sc.Type != strategyEntities.SellIndicator || sc.Rule == nil {
+			return "", fmt.Errorf("unsupported sell condition %q", sc.Type)
+		}
+		text, err := formatRule(sc.Rule)
+		if err != nil {
+			return "", err
+		}
+		exits = append(exits, text)
+	}
+
+	if g := s.ExitRules; g != nil {
+		terms := []strategyEntities.RuleGroup{*g}
+		if g.Rule == nil && g.Op == strategyEntities.RuleOr {
+			terms = g.Children
+		}
+		for i := range terms {
+			text, err := formatGroup(&terms[i], precOr)
+			if err != nil {
+				return "", err
+			}
+			exits = append(exits, text)
+		}
+	}
+
+	if len(exits) > 0 {
+		out += ";\nSELL WHEN " + strings.Join(exits, " OR ")
+	}
+	return out, nil
+}
+
+// formatGroup writes g, parenthesised when it binds looser than the context.
+func formatGroup(g *strategyEntities.RuleGroup, parent int) (string, error) {
+	if g == nil {
+		return "", nil
+	}
+	if g.Rule != nil {
+		return formatRule(g.Rule)
+	}
+
+	var prec int
+	var sep string
+	switch g.Op {
+	case strategyEntities.RuleNot:
+		if len(g.Children) != 1 {
+			return "", fmt.Errorf("NOT group needs exactly one child")
+		}
+		child, err := formatGroup(&g.Children[0], precNot)
+		if err != nil {
+			return "", err
+		}
+		return "NOT " + child, nil
+	case strategyEntities.RuleAnd:
+		prec, sep = precAnd, " AND "
+	case strategyEntities.RuleOr:
+		prec, sep = precOr, " OR "
+	default:
+		return "", fmt.Errorf("unknown rule operator %q", g.Op)
+	}
+
+	if len(g.Children) == 1 {
+		return formatGroup(&g.Children[0], parent)
+	}
+	parts := make([]string, len(g.Children))
+	for i := range g.Children {
+		text, err := formatGroup(&g.Children[i], prec)
+		if err != nil {
+			return "", err
+		}
+		parts[i] = text
+	}
+	text := strings.Join(parts, sep)
+	if parent > prec {
+		text = "(" + text + ")"
+	}
+	return text, nil
+}
+
+// formatRule writes one rule in the shape listed in conditions or predicates.
+func formatRule(r *strategyEntities.Rule) (string, error) {
+	if r.Timeframe != "" {
+		return "", fmt.Errorf("rules on other timeframes cannot be written as text")
+	}
+	if name, ok := predicates[r.Type]; ok {
+		return call(name, r.Window), nil
+	}
+	shape, ok := conditions[r.Type]
+	if !ok {
+		return "", fmt.Errorf("unsupported rule type %q", r.Type)
+	}
+	parts := strings.Fields(shape)
+	left, op, right := parts[0], parts[1], parts[2]
+
+	switch r.Type {
+	case strategyEntities.RuleRSIAbove, strategyEntities.RuleRSIBelow,
+		strategyEntities.RuleRSICrossAbove, strategyEntities.RuleRSICrossBelow,
+		strategyEntities.RuleATRAbovePct, strategyEntities.RuleATRBelowPct,
+		strategyEntities.RuleADXAbove, strategyEntities.RuleADXBelow:
+		left, right = call(left, r.Window), number(r.Value)
+
+	case strategyEntities.RuleEMACrossAbove, strategyEntities.RuleEMACrossBelow,
+		strategyEntities.RuleSMACrossAbove, strategyEntities.RuleSMACrossBelow:
+		left, right = call(left, r.FastWindow), call(right, r.SlowWindow)
+
+	case strategyEntities.RulePriceAboveEMA, strategyEntities.RulePriceBelowEMA,
+		strategyEntities.RulePriceAboveSMA, strategyEntities.RulePriceBelowSMA,
+		strategyEntities.RuleDonchianBreakAbove, strategyEntities.RuleDonchianBreakBelow:
+		right = call(right, r.Window)
+
+	case strategyEntities.RuleMACDCrossSignalAbove, strategyEntities.RuleMACDCrossSignalBelow:
+		left = call(left, r.FastPeriod, r.SlowPeriod, r.SignalPeriod)
+	case strategyEntities.RuleMACDAboveZero, strategyEntities.RuleMACDBelowZero:
+		left, right = call(left, r.FastPeriod, r.SlowPeriod, r.SignalPeriod), "0"
+
+	case strategyEntities.RuleStochCrossAbove, strategyEntities.RuleStochCrossBelow:
+		left = call(left, r.KPeriod, r.KSmoothing, r.DPeriod)
+
+	case strategyEntities.RulePriceAboveVWAP:
+		right = vwap(r.VWAPDeviation)
+	case strategyEntities.RulePriceBelowVWAP:
+		right = vwap(-r.VWAPDeviation)
+
+	case strategyEntities.RuleBBTouchUpper, strategyEntities.RuleBBTouchLower,
+		strategyEntities.RuleBBBreakoutAbove, strategyEntities.RuleBBBreakoutBelow:
+		switch {
+		case r.StdDev != 0:
+			right += "(" + strconv.Itoa(r.Window) + ", " + number(r.StdDev) + ")"
+		case r.Window != 0:
+			right = call(right, r.Window)
+		}
+	}
+	return left + " " + op + " " + right, nil
+}
+
+// call writes an indicator with its periods, or bare when all are defaults.
+func call(name string, periods ...int) string {
+	for _, p := range periods {
+		if p != 0 {
+			args := make([]string, len(periods))
+			for i, p := range periods {
+				args[i] = strconv.Itoa(p)
+			}
+			return name + "(" + strings.Join(args, ", ") + ")"
+		}
+	}
+	return name
+}
+
+func vwap(offset float64) string {
+	switch {
+	case offset > 0:
+		return "vwap + " + number(offset) + "%"
+	case offset < 0:
+		return "vwap - " + number(-offset) + "%"
+	}
+	return "vwap"
+}
+
+func number(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
@@ -0,0 +1,131 @@
+// Package dsl reads and writes strategy rules as text:
+//
+//	BUY WHEN rsi(14) crosses_below 30 AND close > sma(200);
+//	SELL WHEN trailing_stop 8% OR rsi(14) > 70
+//
+// A BUY statement sets the entry rule tree. SELL statements list exits joined
+// with OR: take_profit, stop_loss and trailing_stop become sell conditions and
+// the remaining conditions form the exit rule tree; any one firing sells.
+// AND binds tighter than OR, NOT binds tightest and parentheses group.
+// Keywords and indicator names are case-insensitive; # starts a comment.
+package dsl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Pos is a location in the source. Line and Column start at 1 and count
+// characters; Offset is the byte offset.
+type Pos struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is a syntax error at a position in the source.
+type Error struct {
+	Pos
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+func errorf(pos Pos, format string, args ...any) *Error {
+	return &Error{Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokNumber
+	tokPunct
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	num  float64 // tokNumber only
+	pos  Pos
+	end  int // byte offset just past the token
+}
+
+// describe names the token for error messages.
+func (t token) describe() string {
+	if t.kind == tokEOF {
+		return "end of input"
+	}
+	return strconv.Quote(t.text)
+}
+
+const punctuation = "(),;%+-<>"
+
+func isLetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '_' }
+func isDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// lex splits src into tokens, ending with a tokEOF.
+func lex(src string) ([]token, error) {
+	var toks []token
+	line, col := 1, 1
+
+	for i := 0; i < len(src); {
+		c := src[i]
+		pos := Pos{Offset: i, Line: line, Column: col}
+
+		switch {
+		case c == '\n':
+			i, line, col = i+1, line+1, 1
+			continue
+		case c == ' ' || c == '\t' || c == '\r':
+			i, col = i+1, col+1
+			continue
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				_, size := utf8.DecodeRuneInString(src[i:])
+				i, col = i+size, col+1
+			}
+			continue
+		}
+
+		j := i
+		switch {
+		case isLetter(c):
+			for j < len(src) && (isLetter(src[j]) || isDigit(src[j])) {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: src[i:j], pos: pos, end: j})
+		case isDigit(c) || c == '.' && i+1 < len(src) && isDigit(src[i+1]):
+			for j < len(src) && isDigit(src[j]) {
+				j++
+			}
+			if j < len(src) && src[j] == '.' {
+				j++
+				for j < len(src) && isDigit(src[j]) {
+					j++
+				}
+			}
+			num, err := strconv.ParseFloat(src[i:j], 64)
+			if err != nil {
+				return nil, errorf(pos, "invalid number %q", src[i:j])
+			}
+			toks = append(toks, token{kind: tokNumber, text: src[i:j], num: num, pos: pos, end: j})
+		case strings.IndexByte(punctuation, c) >= 0:
+			j++
+			toks = append(toks, token{kind: tokPunct, text: src[i:j], pos: pos, end: j})
+		default:
+			r, _ := utf8.DecodeRuneInString(src[i:])
+			return nil, errorf(pos, "unexpected character %q", r)
+		}
+		col += j - i
+		i = j
+	}
+
+	end := Pos{Offset: len(src), Line: line, Column: col}
+	return append(toks, token{kind: tokEOF, pos: end, end: len(src)}), nil
+}
@@ -0,0 +1,499 @@
+package dsl
+
+import (
+	"math"
+	"strings"
+
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+)
+
+// conditions maps each comparison rule to its shape: left operand, operator,
+// right operand, where # stands for a number.
+var conditions = map[strategyEntities.RuleType]string{
+	strategyEntities.RuleRSIAbove:             "rsi > #",
+	strategyEntities.RuleRSIBelow:             "rsi < #",
+	strategyEntities.RuleRSICrossAbove:        "rsi crosses_above #",
+	strategyEntities.RuleRSICrossBelow:        "rsi crosses_below #",
+	strategyEntities.RuleEMACrossAbove:        "ema crosses_above ema",
+	strategyEntities.RuleEMACrossBelow:        "ema crosses_below ema",
+	strategyEntities.RuleSMACrossAbove:        "sma crosses_above sma",
+	strategyEntities.RuleSMACrossBelow:        "sma crosses_below sma",
+	strategyEntities.RulePriceAboveEMA:        "close > ema",
+	strategyEntities.RulePriceBelowEMA:        "close < ema",
+	strategyEntities.RulePriceAboveSMA:        "close > sma",
+	strategyEntities.RulePriceBelowSMA:        "close < sma",
+	strategyEntities.RuleMACDCrossSignalAbove: "macd crosses_above signal",
+	strategyEntities.RuleMACDCrossSignalBelow: "macd crosses_below signal",
+	strategyEntities.RuleMACDAboveZero:        "macd > #",
+	strategyEntities.RuleMACDBelowZero:        "macd < #",
+	strategyEntities.RulePriceAboveVWAP:       "close > vwap",
+	strategyEntities.RulePriceBelowVWAP:       "close < vwap",
+	strategyEntities.RuleBBTouchUpper:         "high touches bb_upper",
+	strategyEntities.RuleBBTouchLower:         "low touches bb_lower",
+	strategyEntities.RuleBBBreakoutAbove:      "close crosses_above bb_upper",
+	strategyEntities.RuleBBBreakoutBelow:      "close crosses_below bb_lower",
+	strategyEntities.RuleATRAbovePct:          "atr_pct > #",
+	strategyEntities.RuleATRBelowPct:          "atr_pct < #",
+	strategyEntities.RuleStochCrossAbove:      "stoch crosses_above signal",
+	strategyEntities.RuleStochCrossBelow:      "stoch crosses_below signal",
+	strategyEntities.RuleADXAbove:             "adx > #",
+	strategyEntities.RuleADXBelow:             "adx < #",
+	strategyEntities.RuleDonchianBreakAbove:   "close > donchian_high",
+	strategyEntities.RuleDonchianBreakBelow:   "close < donchian_low",
+}
+
+// predicates are rules written as a single call.
+var predicates = map[strategyEntities.RuleType]string{
+	strategyEntities.RuleOBVBullishDivergence: "obv_bullish_divergence",
+	strategyEntities.RuleOBVBearishDivergence: "obv_bearish_divergence",
+}
+
+var stops = map[strategyEntities.SellConditionType]string{
+	strategyEntities.SellTakeProfit:   "take_profit",
+	strategyEntities.SellStopLoss:     "stop_loss",
+	strategyEntities.SellTrailingStop: "trailing_stop",
+}
+
+var (
+	conditionTypes = invert(conditions)
+	predicateTypes = invert(predicates)
+	stopTypes      = invert(stops)
+)
+
+func invert[K comparable](m map[K]string) map[string]K {
+	out := make(map[string]K, len(m))
+	for k, v := range m {
+		out[v] = k
+	}
+	return out
+}
+
+var keywords = map[string]bool{"buy": true, "sell": true, "when": true, "and": true, "or": true, "not": true}
+
+var comparators = map[string]bool{">": true, "<": true, "crosses_above": true, "crosses_below": true, "touches": true}
+
+// Parse reads a strategy's rules from src. The result carries EntryRules,
+// ExitRules and SellConditions; every other field is left empty. Errors are
+// *Error.
+func Parse(src string) (*strategyEntities.StrategyEntity, error) {
+	toks, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{src: src, toks: toks}
+
+	strategy := &strategyEntities.StrategyEntity{}
+	var exits []strategyEntities.RuleGroup
+	var sellPos Pos
+	for p.peek().kind != tokEOF {
+		if p.accept(";") {
+			continue
+		}
+
+		start := p.next()
+		switch strings.ToLower(start.text) {
+		case "buy":
+			if strategy.EntryRules != nil {
+				return nil, errorf(start.pos, "only one BUY statement is allowed")
+			}
+			if err := p.expectKeyword("when"); err != nil {
+				return nil, err
+			}
+			entry, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := entry.Validate(); err != nil {
+				return nil, errorf(start.pos, "%s", err.Error())
+			}
+			strategy.EntryRules = entry
+		case "sell":
+			if len(exits) == 0 {
+				sellPos = start.pos
+			}
+			if err := p.expectKeyword("when"); err != nil {
+				return nil, err
+			}
+			if err := p.exits(strategy, &exits); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, errorf(start.pos, "expected BUY or SELL, found %s", start.describe())
+		}
+
+		if t := p.peek(); t.kind != tokEOF && !p.accept(";") {
+			return nil, errorf(t.pos, "expected ; or end of input, found %s", t.describe())
+		}
+	}
+
+	if strategy.EntryRules == nil {
+		return nil, errorf(p.peek().pos, "a BUY WHEN statement is required")
+	}
+	switch len(exits) {
+	case 0:
+	case 1:
+		strategy.ExitRules = &exits[0]
+	default:
+		strategy.ExitRules = &strategyEntities.RuleGroup{Op: strategyEntities.RuleOr, Children: exits}
+	}
+	if strategy.ExitRules != nil {
+		if err := strategy.ExitRules.Validate(); err != nil {
+			return nil, errorf(sellPos, "%s", err.Error())
+		}
+	}
+	return strategy, nil
+}
+
+type parser struct {
+	src  string
+	toks []token
+	i    int
+}
+
+func (p *parser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *parser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+// accept consumes the next token if it is the punctuation text.
+func (p *parser) accept(text string) bool {
+	if t := p.peek(); t.kind == tokPunct && t.text == text {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) acceptKeyword(kw string) bool {
+	if t := p.peek(); t.kind == tokIdent && strings.EqualFold(t.text, kw) {
+		p.i++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(text string) error {
+	if t := p.peek(); !p.accept(text) {
+		return errorf(t.pos, "expected %q, found %s", text, t.describe())
+	}
+	return nil
+}
+
+func (p *parser) expectKeyword(kw string) error {
+	if t := p.peek(); !p.acceptKeyword(kw) {
+		return errorf(t.pos, "expected %s, found %s", strings.ToUpper(kw), t.describe())
+	}
+	return nil
+}
+
+// exits parses `exit (OR exit)*` where an exit is a stop or an AND expression.
+func (p *parser) exits(strategy *strategyEntities.StrategyEntity, exits *[]strategyEntities.RuleGroup) error {
+	for {
+		t := p.peek()
+		if kind, ok := stopTypes[strings.ToLower(t.text)]; ok && t.kind == tokIdent {
+			p.next()
+			n := p.next()
+			if n.kind != tokNumber {
+				return errorf(n.pos, "expected a percent after %s, found %s", t.text, n.describe())
+			}
+			p.accept("%")
+			if n.num <= 0 {
+				return errorf(n.pos, "%s needs a positive percent", t.text)
+			}
+			if n.num >= 100 && kind != strategyEntities.SellTakeProfit {
+				return errorf(n.pos, "%s needs a percent below 100", t.text)
+			}
+			strategy.SellConditions = append(strategy.SellConditions, strategyEntities.SellCondition{Type: kind, Percent: n.num})
+		} else {
+			g, err := p.and()
+			if err != nil {
+				return err
+			}
+			*exits = append(*exits, *g)
+		}
+
+		if !p.acceptKeyword("or") {
+			return nil
+		}
+	}
+}
+
+// expr parses `and (OR and)*`.
+func (p *parser) expr() (*strategyEntities.RuleGroup, error) {
+	return p.chain("or", strategyEntities.RuleOr, p.and)
+}
+
+// and parses `unary (AND unary)*`.
+func (p *parser) and() (*strategyEntities.RuleGroup, error) {
+	return p.chain("and", strategyEntities.RuleAnd, p.unary)
+}
+
+func (p *parser) chain(kw string, op strategyEntities.RuleOperator, operand func() (*strategyEntities.RuleGroup, error)) (*strategyEntities.RuleGroup, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	children := []strategyEntities.RuleGroup{*first}
+	for p.acceptKeyword(kw) {
+		g, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, *g)
+	}
+	if len(children) == 1 {
+		return first, nil
+	}
+	return &strategyEntities.RuleGroup{Op: op, Children: children}, nil
+}
+
+// unary parses `NOT unary | ( expr ) | condition`.
+func (p *parser) unary() (*strategyEntities.RuleGroup, error) {
+	if p.acceptKeyword("not") {
+		g, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &strategyEntities.RuleGroup{Op: strategyEntities.RuleNot, Children: []strategyEntities.RuleGroup{*g}}, nil
+	}
+	if p.accept("(") {
+		g, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return g, nil
+	}
+	rule, err := p.condition()
+	if err != nil {
+		return nil, err
+	}
+	return &strategyEntities.RuleGroup{Rule: rule}, nil
+}
+
+type arg struct {
+	val float64
+	pos Pos
+}
+
+// operand is one side of a condition: a number, a price, an indicator call or
+// vwap with an optional percent offset.
+type operand struct {
+	pos    Pos
+	end    int
+	name   string // lower-cased; "#" for numbers
+	num    float64
+	args   []arg
+	offset float64 // vwap ± percent
+}
+
+func (p *parser) operand() (operand, error) {
+	t := p.next()
+	if t.kind == tokPunct && t.text == "-" && p.peek().kind == tokNumber {
+		n := p.next()
+		return operand{pos: t.pos, end: n.end, name: "#", num: -n.num}, nil
+	}
+	switch t.kind {
+	case tokNumber:
+		return operand{pos: t.pos, end: t.end, name: "#", num: t.num}, nil
+	case tokIdent:
+	default:
+		return operand{}, errorf(t.pos, "expected a condition, found %s", t.describe())
+	}
+
+	o := operand{pos: t.pos, end: t.end, name: strings.ToLower(t.text)}
+	if keywords[o.name] {
+		return operand{}, errorf(t.pos, "expected a condition, found %s", strings.ToUpper(o.name))
+	}
+	if _, ok := stopTypes[o.name]; ok {
+		return operand{}, errorf(t.pos, "%s can only be joined with OR at the top of a SELL statement", t.text)
+	}
+
+	if p.accept("(") {
+		for !p.accept(")") {
+			if len(o.args) > 0 {
+				if err := p.expect(","); err != nil {
+					return operand{}, err
+				}
+			}
+			n := p.next()
+			if n.kind != tokNumber {
+				return operand{}, errorf(n.pos, "expected a number, found %s", n.describe())
+			}
+			o.args = append(o.args, arg{val: n.num, pos: n.pos})
+		}
+		o.end = p.toks[p.i-1].end
+	}
+
+	if o.name == "vwap" {
+		sign := 0.0
+		if p.accept("+") {
+			sign = 1
+		} else if p.accept("-") {
+			sign = -1
+		}
+		if sign != 0 {
+			n := p.next()
+			if n.kind != tokNumber {
+				return operand{}, errorf(n.pos, "expected a percent, found %s", n.describe())
+			}
+			o.offset = sign * n.num
+			o.end = n.end
+			if p.accept("%") {
+				o.end = p.toks[p.i-1].end
+			}
+		}
+	}
+	return o, nil
+}
+
+// condition parses `operand comparator operand` or a predicate call.
+func (p *parser) condition() (*strategyEntities.Rule, error) {
+	left, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+	if kind, ok := predicateTypes[left.name]; ok {
+		window, err := left.window()
+		if err != nil {
+			return nil, err
+		}
+		return &strategyEntities.Rule{Type: kind, Window: window}, nil
+	}
+
+	t := p.next()
+	op := strings.ToLower(t.text)
+	if t.kind == tokEOF || !comparators[op] {
+		return nil, errorf(t.pos, "expected >, <, crosses_above, crosses_below or touches after %s, found %s",
+			p.src[left.pos.Offset:left.end], t.describe())
+	}
+	right, err := p.operand()
+	if err != nil {
+		return nil, err
+	}
+
+	kind, ok := conditionTypes[left.name+" "+op+" "+right.name]
+	if !ok {
+		return nil, errorf(left.pos, "unsupported condition %q", p.src[left.pos.Offset:right.end])
+	}
+	return buildRule(kind, left, right)
+}
+
+// buildRule fills the parameters of a rule of the given type from its operands.
+func buildRule(kind strategyEntities.RuleType, left, right operand) (*strategyEntities.Rule, error) {
+	rule := &strategyEntities.Rule{Type: kind}
+
+	// Prices, signal lines and numbers take no arguments
+	for _, o := range []operand{left, right} {
+		switch o.name {
+		case "close", "high", "low", "signal", "vwap":
+			if len(o.args) > 0 {
+				return nil, errorf(o.pos, "%s takes no arguments", o.name)
+			}
+		}
+	}
+
+	var err error
+	switch kind {
+	case strategyEntities.RuleRSIAbove, strategyEntities.RuleRSIBelow,
+		strategyEntities.RuleRSICrossAbove, strategyEntities.RuleRSICrossBelow,
+		strategyEntities.RuleATRAbovePct, strategyEntities.RuleATRBelowPct,
+		strategyEntities.RuleADXAbove, strategyEntities.RuleADXBelow:
+		rule.Window, err = left.window()
+		rule.Value = right.num
+
+	case strategyEntities.RuleEMACrossAbove, strategyEntities.RuleEMACrossBelow,
+		strategyEntities.RuleSMACrossAbove, strategyEntities.RuleSMACrossBelow:
+		if rule.FastWindow, err = left.window(); err == nil {
+			rule.SlowWindow, err = right.window()
+		}
+
+	case strategyEntities.RulePriceAboveEMA, strategyEntities.RulePriceBelowEMA,
+		strategyEntities.RulePriceAboveSMA, strategyEntities.RulePriceBelowSMA,
+		strategyEntities.RuleDonchianBreakAbove, strategyEntities.RuleDonchianBreakBelow:
+		rule.Window, err = right.window()
+
+	case strategyEntities.RuleMACDCrossSignalAbove, strategyEntities.RuleMACDCrossSignalBelow,
+		strategyEntities.RuleMACDAboveZero, strategyEntities.RuleMACDBelowZero:
+		if right.name == "#" && right.num != 0 {
+			return nil, errorf(right.pos, "macd is compared against 0")
+		}
+		var w []int
+		if w, err = left.ints(3); err == nil {
+			rule.FastPeriod, rule.SlowPeriod, rule.SignalPeriod = w[0], w[1], w[2]
+		}
+
+	case strategyEntities.RuleStochCrossAbove, strategyEntities.RuleStochCrossBelow:
+		var w []int
+		if w, err = left.ints(3); err == nil {
+			rule.KPeriod, rule.KSmoothing, rule.DPeriod = w[0], w[1], w[2]
+		}
+
+	case strategyEntities.RulePriceAboveVWAP:
+		rule.VWAPDeviation = right.offset
+	case strategyEntities.RulePriceBelowVWAP:
+		rule.VWAPDeviation = -right.offset
+
+	case strategyEntities.RuleBBTouchUpper, strategyEntities.RuleBBTouchLower,
+		strategyEntities.RuleBBBreakoutAbove, strategyEntities.RuleBBBreakoutBelow:
+		if len(right.args) > 2 {
+			return nil, errorf(right.pos, "%s takes a window and a standard deviation", right.name)
+		}
+		if len(right.args) > 0 {
+			rule.Window, err = right.args[0].int(right.name)
+		}
+		if len(right.args) > 1 {
+			rule.StdDev = right.args[1].val
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// window reads an optional single window argument; 0 when omitted.
+func (o operand) window() (int, error) {
+	if len(o.args) > 1 {
+		return 0, errorf(o.args[1].pos, "%s takes one window", o.name)
+	}
+	if len(o.args) == 0 {
+		return 0, nil
+	}
+	return o.args[0].int(o.name)
+}
+
+// ints reads either no arguments or exactly n whole-number arguments.
+func (o operand) ints(n int) ([]int, error) {
+	out := make([]int, n)
+	if len(o.args) == 0 {
+		return out, nil
+	}
+	if len(o.args) != n {
+		return nil, errorf(o.pos, "%s takes %d arguments or none", o.name, n)
+	}
+	for i, a := range o.args {
+		v, err := a.int(o.name)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func (a arg) int(name string) (int, error) {
+	if a.val != math.Trunc(a.val) || a.val > strategyEntities.MaxRulePeriod {
+		return 0, errorf(a.pos, "%s periods must be whole numbers up to %d", name, strategyEntities.MaxRulePeriod)
+	}
+	return int(a.val), nil
+}
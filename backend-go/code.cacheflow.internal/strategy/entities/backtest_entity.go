@@ -1,35 +1,125 @@
 package entities
 
-import "time"
+import (
+	"time"
+
+	"code.cacheflow.internal/portfolio/costs"
+	"code.cacheflow.internal/strategy/metrics"
+)
 
 // BacktestTrade is a single trade event in a backtest simulation.
 type BacktestTrade struct {
-	Type       string  `json:"type" bson:"type"`               // "BUY" or "SELL"
+	Type       string  `json:"type" bson:"type"`               // "BUY" / "SELL" a long, "SHORT" / "COVER" a short
 	Date       string  `json:"date" bson:"date"`               // YYYY-MM-DD
 	Price      float64 `json:"price" bson:"price"`
 	Shares     float64 `json:"shares" bson:"shares"`
 	Value      float64 `json:"value" bson:"value"`             // price * shares
-	PnL        float64 `json:"pnl" bson:"pnl"`                 // realized P&L (SELL only)
-	PnLPercent float64 `json:"pnl_percent" bson:"pnl_percent"` // realized P&L % (SELL only)
+	PnL        float64 `json:"pnl" bson:"pnl"`                 // realized P&L (SELL / COVER only)
+	PnLPercent float64 `json:"pnl_percent" bson:"pnl_percent"` // realized P&L % (SELL / COVER only)
 	CashAfter  float64 `json:"cash_after" bson:"cash_after"`   // cash remaining after trade
+
+	// Symbol traded; empty on single-ticker backtests saved before portfolios
+	Ticker string `json:"ticker,omitempty" bson:"ticker,omitempty"`
+
+	// Execution costs of this fill; PnL is net of both legs' costs
+	Commission float64 `json:"commission,omitempty" bson:"commission,omitempty"`
+	Fees       float64 `json:"fees,omitempty" bson:"fees,omitempty"`
+	Slippage   float64 `json:"slippage,omitempty" bson:"slippage,omitempty"` // $ versus the bar price
+	Borrow     float64 `json:"borrow,omitempty" bson:"borrow,omitempty"`     // stock loan fee (COVER only)
+
+	// Entry of the position the trade opened or closed, from 1; closing trades
+	// are split per entry so each carries that lot's realized P&L
+	Lot       int    `json:"lot,omitempty" bson:"lot,omitempty"`
+	EntryDate string `json:"entry_date,omitempty" bson:"entry_date,omitempty"` // closing trades: when the lot opened
+}
+
+// Closes reports whether t closes a position and so carries realized P&L.
+func (t BacktestTrade) Closes() bool {
+	return t.Type == "SELL" || t.Type == "COVER"
+}
+
+// BacktestSymbolResult is one symbol's share of a portfolio backtest.
+type BacktestSymbolResult struct {
+	Ticker        string  `json:"ticker" bson:"ticker"`
+	TotalTrades   int     `json:"total_trades" bson:"total_trades"`
+	WinningTrades int     `json:"winning_trades" bson:"winning_trades"`
+	LosingTrades  int     `json:"losing_trades" bson:"losing_trades"`
+	RealizedPnL   float64 `json:"realized_pnl" bson:"realized_pnl"`
+	Contribution  float64 `json:"contribution" bson:"contribution"` // realized P&L as % of initial balance
+}
+
+// BacktestCosts totals the execution costs of a backtest.
+type BacktestCosts struct {
+	Commission float64 `json:"commission" bson:"commission"`
+	Fees       float64 `json:"fees" bson:"fees"`
+	Slippage   float64 `json:"slippage" bson:"slippage"`
+	Borrow     float64 `json:"borrow,omitempty" bson:"borrow,omitempty"` // stock loan fees on shorts
 }
 
-// BacktestEntity is a saved backtest result.
+// BacktestComparison is a passive alternative held over the same dates as a
+// backtest: buy-and-hold of the backtest's universe or a benchmark index.
+// Relative statistics measure the strategy against it.
+type BacktestComparison struct {
+	Tickers          []string      `json:"tickers" bson:"tickers"`
+	FinalBalance     float64       `json:"final_balance" bson:"final_balance"`
+	ROI              float64       `json:"roi" bson:"roi"`
+	Metrics          metrics.Stats `json:"metrics" bson:"metrics"`
+	metrics.Relative `bson:",inline"`
+
+	// Daily equity aligned to the backtest's curve; stored with the equity curve
+	Equity []BacktestEquityPoint `json:"equity,omitempty" bson:"-"`
+}
+
+// BacktestEntity is a saved backtest result. Ticker is the first symbol of the
+// universe, kept for single-ticker clients; Tickers lists all of them.
 type BacktestEntity struct {
-	UUID           string          `json:"uuid" bson:"uuid"`
-	StrategyUUID   string          `json:"strategy_uuid" bson:"strategy_uuid"`
-	Ticker         string          `json:"ticker" bson:"ticker"`
-	FromDate       string          `json:"from_date" bson:"from_date"`
-	ToDate         string          `json:"to_date" bson:"to_date"`
-	InitialBalance float64         `json:"initial_balance" bson:"initial_balance"`
-	FinalBalance   float64         `json:"final_balance" bson:"final_balance"`
-	ROI            float64         `json:"roi" bson:"roi"`
-	TotalTrades    int             `json:"total_trades" bson:"total_trades"`
-	WinningTrades  int             `json:"winning_trades" bson:"winning_trades"`
-	LosingTrades   int             `json:"losing_trades" bson:"losing_trades"`
-	MaxDrawdown    float64         `json:"max_drawdown" bson:"max_drawdown"`
-	Trades         []BacktestTrade `json:"trades" bson:"trades"`
-	AccountID      string          `json:"account_id" bson:"account_id"`
-	PortfolioUUID  string          `json:"portfolio_uuid" bson:"portfolio_uuid"`
-	CreatedAt      time.Time       `json:"created_at" bson:"created_at"`
+	UUID           string                 `json:"uuid" bson:"uuid"`
+	StrategyUUID   string                 `json:"strategy_uuid" bson:"strategy_uuid"`
+	Ticker         string                 `json:"ticker" bson:"ticker"`
+	Tickers        []string               `json:"tickers,omitempty" bson:"tickers,omitempty"`
+	MaxPositions   int                    `json:"max_positions,omitempty" bson:"max_positions,omitempty"`
+	Symbols        []BacktestSymbolResult `json:"symbols,omitempty" bson:"symbols,omitempty"`
+	CostModel      *costs.Model           `json:"cost_model,omitempty" bson:"cost_model,omitempty"`
+	Execution      ExecutionModel         `json:"execution,omitempty" bson:"execution,omitempty"`
+	TotalCosts     BacktestCosts          `json:"total_costs" bson:"total_costs"`
+	FromDate       string                 `json:"from_date" bson:"from_date"`
+	ToDate         string                 `json:"to_date" bson:"to_date"`
+	InitialBalance float64                `json:"initial_balance" bson:"initial_balance"`
+	FinalBalance   float64                `json:"final_balance" bson:"final_balance"`
+	ROI            float64                `json:"roi" bson:"roi"`
+	TotalTrades    int                    `json:"total_trades" bson:"total_trades"`
+	WinningTrades  int                    `json:"winning_trades" bson:"winning_trades"`
+	LosingTrades   int                    `json:"losing_trades" bson:"losing_trades"`
+	MaxDrawdown    float64                `json:"max_drawdown" bson:"max_drawdown"`
+	Metrics        *metrics.Stats         `json:"metrics,omitempty" bson:"metrics,omitempty"` // nil on backtests saved before metrics
+	BuyAndHold     *BacktestComparison    `json:"buy_and_hold,omitempty" bson:"buy_and_hold,omitempty"`
+	Benchmark      *BacktestComparison    `json:"benchmark,omitempty" bson:"benchmark,omitempty"`
+	Trades         []BacktestTrade        `json:"trades" bson:"trades"`
+	AccountID      string                 `json:"account_id" bson:"account_id"`
+	PortfolioUUID  string                 `json:"portfolio_uuid" bson:"portfolio_uuid"`
+	CreatedAt      time.Time              `json:"created_at" bson:"created_at"`
+
+	// Strategy revision the backtest ran; 0 on backtests saved before revisions
+	StrategyRevision int `json:"strategy_revision,omitempty" bson:"strategy_revision,omitempty"`
+}
+
+// BacktestEquityPoint is one day of a backtest's equity curve.
+type BacktestEquityPoint struct {
+	Date     string  `json:"date" bson:"date"` // YYYY-MM-DD
+	Equity   float64 `json:"equity" bson:"equity"`
+	Cash     float64 `json:"cash" bson:"cash"`
+	Exposure float64 `json:"exposure" bson:"exposure"` // % of equity held in positions
+	Drawdown float64 `json:"drawdown" bson:"drawdown"` // % below the running peak
+}
+
+// BacktestEquityEntity holds a backtest's daily series. It lives in its own
+// collection so backtest listings stay small.
+type BacktestEquityEntity struct {
+	BacktestUUID string                `json:"backtest_uuid" bson:"backtest_uuid"`
+	StrategyUUID string                `json:"strategy_uuid" bson:"strategy_uuid"`
+	AccountID    string                `json:"account_id" bson:"account_id"`
+	Points       []BacktestEquityPoint `json:"points" bson:"points"`
+	BuyAndHold   []BacktestEquityPoint `json:"buy_and_hold,omitempty" bson:"buy_and_hold,omitempty"`
+	Benchmark    []BacktestEquityPoint `json:"benchmark,omitempty" bson:"benchmark,omitempty"`
+	CreatedAt    time.Time             `json:"created_at" bson:"created_at"`
 }
@@ -0,0 +1,82 @@
+package entities
+
+import (
+	"encoding/json"
+	"time"
+
+	"code.cacheflow.internal/portfolio/costs"
+)
+
+// BacktestRequest is the input to a backtest, run directly or queued as a job.
+type BacktestRequest struct {
+	StrategyUUID   string   `json:"strategy_uuid" bson:"strategy_uuid"`
+	Revision       int      `json:"revision" bson:"revision,omitempty"` // optional past revision; defaults to the current one
+	Ticker         string   `json:"ticker" bson:"ticker,omitempty"`     // optional single-symbol override
+	Tickers        []string `json:"tickers" bson:"tickers,omitempty"`   // optional universe override
+	FromDate       string   `json:"from_date" bson:"from_date"`
+	ToDate         string   `json:"to_date" bson:"to_date"`
+	InitialBalance float64  `json:"initial_balance" bson:"initial_balance"`
+
+	// Optional cost model; defaults to the strategy portfolio's
+	Costs *costs.Model `json:"costs" bson:"costs,omitempty"`
+	// Optional execution model; defaults to the strategy's
+	Execution ExecutionModel `json:"execution" bson:"execution,omitempty"`
+
+	// Optional comparisons over the same dates
+	BuyAndHold bool   `json:"buy_and_hold" bson:"buy_and_hold,omitempty"`
+	Benchmark  string `json:"benchmark" bson:"benchmark,omitempty"` // e.g. "SPY"
+}
+
+// BacktestJobStatus is the lifecycle state of a queued backtest.
+type BacktestJobStatus string
+
+const (
+	JobQueued    BacktestJobStatus = "QUEUED"
+	JobRunning   BacktestJobStatus = "RUNNING"
+	JobSucceeded BacktestJobStatus = "SUCCEEDED"
+	JobFailed    BacktestJobStatus = "FAILED"
+	JobCancelled BacktestJobStatus = "CANCELLED"
+)
+
+// Done reports whether the job has stopped for good.
+func (s BacktestJobStatus) Done() bool {
+	return s == JobSucceeded || s == JobFailed || s == JobCancelled
+}
+
+// BacktestJobKind is what a job runs over its BacktestRequest.
+type BacktestJobKind string
+
+const (
+	JobBacktest    BacktestJobKind = "BACKTEST"
+	JobOptimize    BacktestJobKind = "OPTIMIZE"
+	JobWalkForward BacktestJobKind = "WALK_FORWARD"
+)
+
+// BacktestJobEntity is a backtest queued for a worker. On success a backtest's
+// result is saved to the backtests collection under BacktestUUID; other kinds
+// keep their report in Result.
+type BacktestJobEntity struct {
+	UUID         string            `json:"uuid" bson:"uuid"`
+	AccountID    string            `json:"account_id" bson:"account_id"`
+	StrategyUUID string            `json:"strategy_uuid" bson:"strategy_uuid"`
+	Request      BacktestRequest   `json:"request" bson:"request"`
+	Status       BacktestJobStatus `json:"status" bson:"status"`
+	Progress     float64           `json:"progress" bson:"progress"` // 0-100
+	Error        string            `json:"error,omitempty" bson:"error,omitempty"`
+	BacktestUUID string            `json:"backtest_uuid,omitempty" bson:"backtest_uuid,omitempty"`
+	CreatedAt    time.Time         `json:"created_at" bson:"created_at"`
+	StartedAt    *time.Time        `json:"started_at,omitempty" bson:"started_at,omitempty"`
+	FinishedAt   *time.Time        `json:"finished_at,omitempty" bson:"finished_at,omitempty"`
+
+	// The process running the job, and when it last proved it still is; a
+	// RUNNING job whose heartbeat is older than the lease is re-queued
+	WorkerID    string     `json:"worker_id,omitempty" bson:"worker_id,omitempty"`
+	HeartbeatAt *time.Time `json:"heartbeat_at,omitempty" bson:"heartbeat_at,omitempty"`
+
+	// Empty for jobs queued before kinds existed, which are backtests
+	Kind BacktestJobKind `json:"kind" bson:"kind,omitempty"`
+	// The search settings and report of other kinds, as the API reads and
+	// writes them
+	Settings json.RawMessage `json:"settings,omitempty" bson:"settings,omitempty"`
+	Result   json.RawMessage `json:"result,omitempty" bson:"result,omitempty"`
+}
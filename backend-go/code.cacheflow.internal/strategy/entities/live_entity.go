@@ -0,0 +1,91 @@
+package entities
+
+import "time"
+
+// LiveCadence decides when a live strategy is evaluated.
+type LiveCadence string
+
+const (
+	LiveDaily    LiveCadence = "DAILY"    // once per completed daily bar, after the close (default)
+	LiveIntraday LiveCadence = "INTRADAY" // every scheduler tick during market hours, against the forming bar
+)
+
+// Valid reports whether c is a known cadence; empty means DAILY.
+func (c LiveCadence) Valid() bool {
+	return c == "" || c == LiveDaily || c == LiveIntraday
+}
+
+// LiveSettings turns paper trading of a strategy on or off.
+type LiveSettings struct {
+	Enabled bool        `json:"enabled" bson:"enabled"`
+	Cadence LiveCadence `json:"cadence,omitempty" bson:"cadence,omitempty"`
+}
+
+// LivePosition is a holding opened by a live strategy.
+type LivePosition struct {
+	Ticker     string  `json:"ticker" bson:"ticker"`
+	Shares     int64   `json:"shares" bson:"shares"`
+	EntryPrice float64 `json:"entry_price" bson:"entry_price"` // fill price after slippage
+	PeakPrice  float64 `json:"peak_price" bson:"peak_price"`   // highest close since entry, for trailing stops
+	EntryDate  string  `json:"entry_date" bson:"entry_date"`   // YYYY-MM-DD
+	OrderUUID  string  `json:"order_uuid" bson:"order_uuid"`
+}
+
+// LiveStateEntity is what a live strategy remembers between evaluations.
+type LiveStateEntity struct {
+	StrategyUUID  string            `json:"strategy_uuid" bson:"strategy_uuid"`
+	AccountID     string            `json:"account_id" bson:"account_id"`
+	PortfolioUUID string            `json:"portfolio_uuid" bson:"portfolio_uuid"`
+	Positions     []LivePosition    `json:"positions" bson:"positions"`
+	ClosedReturns []float64         `json:"closed_returns,omitempty" bson:"closed_returns,omitempty"` // realized % per closed trade, for Kelly sizing
+	LastExit      map[string]string `json:"last_exit,omitempty" bson:"last_exit,omitempty"`           // ticker → bar date of the last exit
+	LastBarDate   string            `json:"last_bar_date,omitempty" bson:"last_bar_date,omitempty"`   // newest bar evaluated
+	EvaluatedAt   *time.Time        `json:"evaluated_at,omitempty" bson:"evaluated_at,omitempty"`
+}
+
+// Position returns the open position in ticker, or nil.
+func (s *LiveStateEntity) Position(ticker string) *LivePosition {
+	for i := range s.Positions {
+		if s.Positions[i].Ticker == ticker {
+			return &s.Positions[i]
+		}
+	}
+	return nil
+}
+
+// Close removes the position in ticker.
+func (s *LiveStateEntity) Close(ticker string) {
+	for i := range s.Positions {
+		if s.Positions[i].Ticker == ticker {
+			s.Positions = append(s.Positions[:i], s.Positions[i+1:]...)
+			return
+		}
+	}
+}
+
+// LiveAction is what a live evaluation decided for one ticker.
+type LiveAction string
+
+const (
+	LiveBuy      LiveAction = "BUY"
+	LiveSell     LiveAction = "SELL"
+	LiveHold     LiveAction = "HOLD"      // held, no exit
+	LiveNoSignal LiveAction = "NO_SIGNAL" // not held, entry rules not met
+	LiveSkip     LiveAction = "SKIP"      // a signal that could not be acted on
+	LiveError    LiveAction = "ERROR"     // the order or data fetch failed
+)
+
+// LiveDecisionEntity logs one decision of a live strategy for review.
+type LiveDecisionEntity struct {
+	UUID         string     `json:"uuid" bson:"uuid"`
+	StrategyUUID string     `json:"strategy_uuid" bson:"strategy_uuid"`
+	AccountID    string     `json:"account_id" bson:"account_id"`
+	Ticker       string     `json:"ticker" bson:"ticker"`
+	BarDate      string     `json:"bar_date" bson:"bar_date"`
+	Action       LiveAction `json:"action" bson:"action"`
+	Reason       string     `json:"reason,omitempty" bson:"reason,omitempty"`
+	Price        float64    `json:"price,omitempty" bson:"price,omitempty"`
+	Quantity     int64      `json:"quantity,omitempty" bson:"quantity,omitempty"`
+	OrderUUID    string     `json:"order_uuid,omitempty" bson:"order_uuid,omitempty"`
+	CreatedAt    time.Time  `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,17 @@
+package entities
+
+import (
+	"time"
+
+	"code.cacheflow.internal/strategy/montecarlo"
+)
+
+// MonteCarloEntity is a saved Monte Carlo run over a backtest's returns.
+type MonteCarloEntity struct {
+	UUID              string `json:"uuid" bson:"uuid"`
+	BacktestUUID      string `json:"backtest_uuid" bson:"backtest_uuid"`
+	StrategyUUID      string `json:"strategy_uuid" bson:"strategy_uuid"`
+	AccountID         string `json:"account_id" bson:"account_id"`
+	montecarlo.Result `bson:",inline"`
+	CreatedAt         time.Time `json:"created_at" bson:"created_at"`
+}
@@ -0,0 +1,110 @@
+package entities
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// StrategyRevisionEntity is an immutable snapshot of how a strategy trades.
+// A strategy's Revision names the snapshot its current definition matches.
+type StrategyRevisionEntity struct {
+	StrategyUUID string         `json:"strategy_uuid" bson:"strategy_uuid"`
+	AccountID    string         `json:"account_id" bson:"account_id"`
+	Revision     int            `json:"revision" bson:"revision"`
+	Strategy     StrategyEntity `json:"strategy" bson:"strategy"`
+	CreatedAt    time.Time      `json:"created_at" bson:"created_at"`
+}
+
+// NewRevision snapshots s as its current revision.
+func NewRevision(s *StrategyEntity, now time.Time) StrategyRevisionEntity {
+	snapshot := *s
+	snapshot.Live = nil // paper trading is not part of the definition
+	return StrategyRevisionEntity{
+		StrategyUUID: s.UUID,
+		AccountID:    s.AccountID,
+		Revision:     s.Revision,
+		Strategy:     snapshot,
+		CreatedAt:    now,
+	}
+}
+
+// StrategyChange is one definition field that differs between two revisions.
+type StrategyChange struct {
+	Field string `json:"field"` // JSON name of the strategy field
+	From  any    `json:"from"`
+	To    any    `json:"to"`
+}
+
+// revisionExempt lists fields that can change without a new revision because
+// they do not affect how the strategy trades.
+var revisionExempt = map[string]bool{
+	"uuid":           true,
+	"name":           true,
+	"description":    true,
+	"portfolio_uuid": true,
+	"account_id":     true,
+	"revision":       true,
+	"live":           true,
+	"created_at":     true,
+	"updated_at":     true,
+}
+
+// DiffStrategies lists the definition fields that differ from a to b, sorted
+// by field name. No changes means both trade identically.
+func DiffStrategies(a, b *StrategyEntity) []StrategyChange {
+	from, to := definitionFields(a), definitionFields(b)
+
+	fields := make([]string, 0, len(from)+len(to))
+	for f := range from {
+		fields = append(fields, f)
+	}
+	for f := range to {
+		if _, ok := from[f]; !ok {
+			fields = append(fields, f)
+		}
+	}
+	sort.Strings(fields)
+
+	var changes []StrategyChange
+	for _, f := range fields {
+		if !reflect.DeepEqual(from[f], to[f]) {
+			changes = append(changes, StrategyChange{Field: f, From: from[f], To: to[f]})
+		}
+	}
+	return changes
+}
+
+// definitionFields returns s's definition as generic JSON values. Empty
+// values are dropped so a missing list and an empty one compare equal.
+func definitionFields(s *StrategyEntity) map[string]any {
+	fields := make(map[string]any)
+	raw, err := json.Marshal(s)
+	if err != nil {
+		return fields
+	}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return fields
+	}
+	for f, v := range fields {
+		if revisionExempt[f] || isEmptyJSON(v) {
+			delete(fields, f)
+		}
+	}
+	return fields
+}
+
+func isEmptyJSON(v any) bool {
+	switch v := v.(type) {
+	case nil:
+		return true
+	case []any:
+		return len(v) == 0
+	case string:
+		return v == ""
+	case float64:
+		return v == 0
+	}
+	return false
+}
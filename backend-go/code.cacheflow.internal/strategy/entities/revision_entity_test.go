@@ -0,0 +1,73 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffStrategies(t *testing.T) {
+	base := StrategyEntity{
+		UUID:           "s-1",
+		Name:           "Momentum",
+		Ticker:         "AAA",
+		BuyRules:       []Rule{{Type: RulePriceAboveSMA, Window: 20}},
+		SellConditions: []SellCondition{{Type: SellTakeProfit, Percent: 5}},
+		Revision:       1,
+	}
+
+	tests := []struct {
+		Name   string
+		Edit   func(s *StrategyEntity)
+		Fields []string
+	}{
+		{
+			Name: "Renames and live settings are not definition changes",
+			Edit: func(s *StrategyEntity) {
+				s.Name, s.Description, s.Live = "Renamed", "notes", &LiveSettings{Enabled: true}
+			},
+			Fields: nil,
+		},
+		{
+			Name:   "A missing list equals an empty one",
+			Edit:   func(s *StrategyEntity) { s.Tickers = []string{} },
+			Fields: nil,
+		},
+		{
+			Name:   "Rule parameter",
+			Edit:   func(s *StrategyEntity) { s.BuyRules = []Rule{{Type: RulePriceAboveSMA, Window: 50}} },
+			Fields: []string{"buy_rules"},
+		},
+		{
+			Name: "Several fields, sorted",
+			Edit: func(s *StrategyEntity) {
+				s.SellConditions = nil
+				s.MaxPositions = 3
+			},
+			Fields: []string{"max_positions", "sell_conditions"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			next := base
+			tt.Edit(&next)
+
+			var fields []string
+			for _, c := range DiffStrategies(&base, &next) {
+				fields = append(fields, c.Field)
+			}
+			assert.Equal(t, tt.Fields, fields)
+		})
+	}
+}
+
+func TestNewRevisionDropsLiveSettings(t *testing.T) {
+	s := &StrategyEntity{UUID: "s-1", AccountID: "a-1", Revision: 3, Live: &LiveSettings{Enabled: true}}
+
+	rev := NewRevision(s, s.CreatedAt)
+	assert.Equal(t, 3, rev.Revision)
+	assert.Equal(t, "s-1", rev.StrategyUUID)
+	assert.Nil(t, rev.Strategy.Live)
+	assert.NotNil(t, s.Live)
+}
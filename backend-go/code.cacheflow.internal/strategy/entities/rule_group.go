@@ -0,0 +1,124 @@
+package entities
+
+import (
+	"errors"
+	"fmt"
+)
+
+// RuleOperator combines the children of a RuleGroup.
+type RuleOperator string
+
+const (
+	RuleAnd RuleOperator = "AND" // every child must hold
+	RuleOr  RuleOperator = "OR"  // at least one child must hold
+	RuleNot RuleOperator = "NOT" // negates its single child
+)
+
+// maxRuleDepth bounds how deeply groups may nest.
+const maxRuleDepth = 8
+
+// RuleGroup is a node in a boolean rule tree. A leaf carries a single Rule and
+// no operator; a branch combines its Children with Op.
+type RuleGroup struct {
+	Op       RuleOperator `json:"op,omitempty" bson:"op,omitempty"`
+	Rule     *Rule        `json:"rule,omitempty" bson:"rule,omitempty"`
+	Children []RuleGroup  `json:"children,omitempty" bson:"children,omitempty"`
+}
+
+// AllOf builds the AND group equivalent to a legacy flat rule list.
+func AllOf(rules []Rule) *RuleGroup {
+	if len(rules) == 0 {
+		return nil
+	}
+	g := &RuleGroup{Op: RuleAnd, Children: make([]RuleGroup, len(rules))}
+	for i := range rules {
+		rule := rules[i]
+		g.Children[i] = RuleGroup{Rule: &rule}
+	}
+	return g
+}
+
+// Eval evaluates the tree, delegating leaves to eval. An empty AND / OR is false.
+func (g *RuleGroup) Eval(eval func(Rule) bool) bool {
+	if g == nil {
+		return false
+	}
+	if g.Rule != nil {
+		return eval(*g.Rule)
+	}
+	switch g.Op {
+	case RuleAnd:
+		if len(g.Children) == 0 {
+			return false
+		}
+		for i := range g.Children {
+			if !g.Children[i].Eval(eval) {
+				return false
+			}
+		}
+		return true
+	case RuleOr:
+		for i := range g.Children {
+			if g.Children[i].Eval(eval) {
+				return true
+			}
+		}
+		return false
+	case RuleNot:
+		return len(g.Children) == 1 && !g.Children[0].Eval(eval)
+	}
+	return false
+}
+
+// Rules returns every leaf rule in the tree, depth first.
+func (g *RuleGroup) Rules() []Rule {
+	if g == nil {
+		return nil
+	}
+	if g.Rule != nil {
+		return []Rule{*g.Rule}
+	}
+	var out []Rule
+	for i := range g.Children {
+		out = append(out, g.Children[i].Rules()...)
+	}
+	return out
+}
+
+// Validate checks the shape of the tree.
+func (g *RuleGroup) Validate() error {
+	return g.validate(1)
+}
+
+func (g *RuleGroup) validate(depth int) error {
+	if depth > maxRuleDepth {
+		return fmt.Errorf("rule groups may nest at most %d levels", maxRuleDepth)
+	}
+	if g.Rule != nil {
+		if g.Op != "" || len(g.Children) > 0 {
+			return errors.New("a rule leaf cannot have an operator or children")
+		}
+		if g.Rule.Type == "" {
+			return errors.New("rule type is required")
+		}
+		return nil
+	}
+	switch g.Op {
+	case RuleAnd, RuleOr:
+		if len(g.Children) == 0 {
+			return fmt.Errorf("%s group needs at least one child", g.Op)
+		}
+	case RuleNot:
+		if len(g.Children) != 1 {
+			return errors.New("NOT group needs exactly one child")
+		}
+	default:
+		return fmt.Errorf("unknown rule operator %q", g.Op)
+	}
+	for i := range g.Children {
+		if err := g.Children[i].validate(depth + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func leaf(t RuleType) RuleGroup {
+	return RuleGroup{Rule: &Rule{Type: t}}
+}
+
+func TestRuleGroupEval(t *testing.T) {
+	// RSI_BELOW holds, RSI_ABOVE does not
+	eval := func(r Rule) bool { return r.Type == RuleRSIBelow }
+
+	tests := []struct {
+		Name   string
+		Group  *RuleGroup
+		Expect bool
+	}{
+		{
+			Name:   "Nil tree",
+			Group:  nil,
+			Expect: false,
+		},
+		{
+			Name:   "AND needs every child",
+			Group:  &RuleGroup{Op: RuleAnd, Children: []RuleGroup{leaf(RuleRSIBelow), leaf(RuleRSIAbove)}},
+			Expect: false,
+		},
+		{
+			Name:   "OR needs one child",
+			Group:  &RuleGroup{Op: RuleOr, Children: []RuleGroup{leaf(RuleRSIBelow), leaf(RuleRSIAbove)}},
+			Expect: true,
+		},
+		{
+			Name: "Nested NOT",
+			Group: &RuleGroup{Op: RuleAnd, Children: []RuleGroup{
+				leaf(RuleRSIBelow),
+				{Op: RuleNot, Children: []RuleGroup{leaf(RuleRSIAbove)}},
+			}},
+			Expect: true,
+		},
+		{
+			Name:   "Empty AND",
+			Group:  &RuleGroup{Op: RuleAnd},
+			Expect: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Expect, tt.Group.Eval(eval))
+		})
+	}
+}
+
+func TestRuleGroupValidate(t *testing.T) {
+	assert.NoError(t, AllOf([]Rule{{Type: RuleRSIBelow}}).Validate())
+	assert.Error(t, (&RuleGroup{Op: RuleNot, Children: []RuleGroup{leaf(RuleRSIBelow), leaf(RuleRSIAbove)}}).Validate())
+	assert.Error(t, (&RuleGroup{Op: "XOR", Children: []RuleGroup{leaf(RuleRSIBelow)}}).Validate())
+	assert.Error(t, (&RuleGroup{Op: RuleAnd}).Validate())
+}
+
+func TestStrategyEntryFallsBackToBuyRules(t *testing.T) {
+	s := StrategyEntity{BuyRules: []Rule{{Type: RuleRSIBelow}, {Type: RuleMACDAboveZero}}}
+
+	entry := s.Entry()
+	assert.Equal(t, RuleAnd, entry.Op)
+	assert.Equal(t, s.BuyRules, entry.Rules())
+}
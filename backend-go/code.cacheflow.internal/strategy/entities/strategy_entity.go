@@ -1,6 +1,10 @@
 package entities
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"time"
+)
 
 // RuleType is the kind of indicator condition to evaluate.
 type RuleType string
@@ -24,9 +28,25 @@ const (
 	RuleMACDBelowZero        RuleType = "MACD_BELOW_ZERO"
 	RulePriceAboveVWAP       RuleType = "PRICE_ABOVE_VWAP_PCT"
 	RulePriceBelowVWAP       RuleType = "PRICE_BELOW_VWAP_PCT"
+	RuleBBTouchUpper         RuleType = "BB_TOUCH_UPPER"          // high reaches the upper band
+	RuleBBTouchLower         RuleType = "BB_TOUCH_LOWER"          // low reaches the lower band
+	RuleBBBreakoutAbove      RuleType = "BB_BREAKOUT_ABOVE"       // close crosses above the upper band
+	RuleBBBreakoutBelow      RuleType = "BB_BREAKOUT_BELOW"       // close crosses below the lower band
+	RuleATRAbovePct          RuleType = "ATR_ABOVE_PCT"           // ATR as % of close above Value
+	RuleATRBelowPct          RuleType = "ATR_BELOW_PCT"           // ATR as % of close below Value
+	RuleStochCrossAbove      RuleType = "STOCH_CROSS_ABOVE"       // %K crosses above %D
+	RuleStochCrossBelow      RuleType = "STOCH_CROSS_BELOW"       // %K crosses below %D
+	RuleADXAbove             RuleType = "ADX_ABOVE"               // trend strength above Value
+	RuleADXBelow             RuleType = "ADX_BELOW"               // trend strength below Value
+	RuleOBVBullishDivergence RuleType = "OBV_BULLISH_DIVERGENCE"  // price down, OBV up over Window bars
+	RuleOBVBearishDivergence RuleType = "OBV_BEARISH_DIVERGENCE"  // price up, OBV down over Window bars
+	RuleDonchianBreakAbove   RuleType = "DONCHIAN_BREAKOUT_ABOVE" // close above the prior Window-bar high
+	RuleDonchianBreakBelow   RuleType = "DONCHIAN_BREAKOUT_BELOW" // close below the prior Window-bar low
 )
 
-// Rule is a single indicator-based buy/sell condition.
+// Rule is a single indicator-based buy/sell condition. A rule with its own
+// Timeframe reads the bars of that timeframe instead of the strategy's, seeing
+// only the ones completed by the close of the bar being evaluated.
 type Rule struct {
 	Type          RuleType `json:"type" bson:"type"`
 	Value         float64  `json:"value" bson:"value"`                   // threshold (RSI level, etc.)
@@ -37,6 +57,13 @@ type Rule struct {
 	SlowPeriod    int      `json:"slow_period" bson:"slow_period"`        // MACD slow EMA
 	SignalPeriod  int      `json:"signal_period" bson:"signal_period"`    // MACD signal line
 	VWAPDeviation float64  `json:"vwap_deviation" bson:"vwap_deviation"` // % above/below VWAP
+	StdDev        float64  `json:"std_dev" bson:"std_dev"`               // Bollinger band width in standard deviations
+	KPeriod       int      `json:"k_period" bson:"k_period"`             // Stochastic %K lookback
+	KSmoothing    int      `json:"k_smoothing" bson:"k_smoothing"`       // Stochastic %K smoothing
+	DPeriod       int      `json:"d_period" bson:"d_period"`             // Stochastic %D period
+
+	// Bars the rule reads, no shorter than the strategy's; empty means the strategy's
+	Timeframe Timeframe `json:"timeframe,omitempty" bson:"timeframe,omitempty"`
 }
 
 // SellConditionType defines how a position exit is triggered.
@@ -47,25 +74,258 @@ const (
 	SellStopLoss     SellConditionType = "STOP_LOSS"
 	SellTrailingStop SellConditionType = "TRAILING_STOP"
 	SellIndicator    SellConditionType = "INDICATOR"
+
+	SellMaxHolding SellConditionType = "MAX_HOLDING"  // after Bars bars or Days calendar days
+	SellExitOnDate SellConditionType = "EXIT_ON_DATE" // on the first bar on or after Date
+	SellATRStop    SellConditionType = "ATR_STOP"     // ATRMultiple × ATR against the entry
+	SellChandelier SellConditionType = "CHANDELIER"   // ATRMultiple × ATR back from the peak
+	SellBreakEven  SellConditionType = "BREAK_EVEN"   // at the entry price once Percent in profit
 )
 
-// SellCondition defines when to exit a position.
+// SellCondition defines when to exit a position. A price exit (any type but
+// INDICATOR, MAX_HOLDING and EXIT_ON_DATE) with a ClosePercent below 100 is a
+// partial exit: it closes that share of the position once, the first time it
+// fires, and leaves the rest to the other conditions.
+//
+// ATR_STOP measures its distance in the ATR of the bar that raised the entry,
+// so the level stays put; CHANDELIER trails the position's peak by the latest
+// ATR. BREAK_EVEN arms once the peak has been Percent in profit and then stops
+// out at the entry price.
 type SellCondition struct {
 	Type    SellConditionType `json:"type" bson:"type"`
 	Percent float64           `json:"percent" bson:"percent"`             // for TP / SL / trailing stop (%)
 	Rule    *Rule             `json:"rule,omitempty" bson:"rule,omitempty"` // for indicator-based exits
+
+	// Share of the position to close, in %; 0 closes all of it
+	ClosePercent float64 `json:"close_percent,omitempty" bson:"close_percent,omitempty"`
+
+	// Time and volatility exits
+	Bars        int     `json:"bars,omitempty" bson:"bars,omitempty"`                 // MAX_HOLDING: bars since the first entry
+	Days        int     `json:"days,omitempty" bson:"days,omitempty"`                 // MAX_HOLDING: calendar days since the first entry
+	Date        string  `json:"date,omitempty" bson:"date,omitempty"`                 // EXIT_ON_DATE: YYYY-MM-DD
+	ATRWindow   int     `json:"atr_window,omitempty" bson:"atr_window,omitempty"`     // ATR_STOP / CHANDELIER period (default 14)
+	ATRMultiple float64 `json:"atr_multiple,omitempty" bson:"atr_multiple,omitempty"` // ATR_STOP / CHANDELIER distance in ATRs
+}
+
+// Partial reports whether c closes only part of the position.
+func (c SellCondition) Partial() bool {
+	return c.ClosePercent > 0 && c.ClosePercent < 100
+}
+
+// SizingModel decides how many shares a new position buys.
+type SizingModel string
+
+const (
+	SizingAllCash          SizingModel = "ALL_CASH"          // equal share of cash per free position slot (default)
+	SizingFixedDollar      SizingModel = "FIXED_DOLLAR"      // Amount dollars per trade
+	SizingFixedFraction    SizingModel = "FIXED_FRACTION"    // Percent of equity per trade
+	SizingVolatilityTarget SizingModel = "VOLATILITY_TARGET" // risk Percent of equity per ATRMultiple × ATR
+	SizingKelly            SizingModel = "KELLY"             // KellyFraction × Kelly criterion from closed trades
+	SizingFixedShares      SizingModel = "FIXED_SHARES"      // Amount shares per trade
+)
+
+// PositionSizing configures the sizing model. Positions are always capped at
+// the cash available.
+type PositionSizing struct {
+	Model         SizingModel `json:"model" bson:"model"`
+	Amount        float64     `json:"amount" bson:"amount"`                 // FIXED_DOLLAR dollars / FIXED_SHARES shares
+	Percent       float64     `json:"percent" bson:"percent"`               // % of equity; also KELLY's size until MinTrades
+	ATRWindow     int         `json:"atr_window" bson:"atr_window"`         // VOLATILITY_TARGET ATR period (default 14)
+	ATRMultiple   float64     `json:"atr_multiple" bson:"atr_multiple"`     // VOLATILITY_TARGET stop distance in ATRs (default 1)
+	KellyFraction float64     `json:"kelly_fraction" bson:"kelly_fraction"` // KELLY scale, 0–1 (default 0.5)
+	MinTrades     int         `json:"min_trades" bson:"min_trades"`         // KELLY closed trades needed first (default 10)
+}
+
+// Validate checks that the model has the parameters it needs.
+func (p *PositionSizing) Validate() error {
+	switch p.Model {
+	case "", SizingAllCash:
+		return nil
+	case SizingFixedDollar, SizingFixedShares:
+		if p.Amount <= 0 {
+			return fmt.Errorf("%s sizing needs a positive amount", p.Model)
+		}
+	case SizingFixedFraction, SizingVolatilityTarget:
+		if p.Percent <= 0 || p.Percent > 100 {
+			return fmt.Errorf("%s sizing needs a percent between 0 and 100", p.Model)
+		}
+	case SizingKelly:
+		if p.KellyFraction < 0 || p.KellyFraction > 1 {
+			return errors.New("kelly_fraction must be between 0 and 1")
+		}
+		if p.Percent < 0 || p.Percent > 100 {
+			return errors.New("percent must be between 0 and 100")
+		}
+	default:
+		return fmt.Errorf("unknown sizing model %q", p.Model)
+	}
+	if p.ATRWindow < 0 || p.ATRMultiple < 0 || p.MinTrades < 0 {
+		return errors.New("sizing parameters cannot be negative")
+	}
+	return nil
+}
+
+// ExecutionModel decides when signals fill.
+type ExecutionModel string
+
+const (
+	ExecutionSameClose ExecutionModel = "SAME_CLOSE" // fill at the close that raised the signal (default)
+	ExecutionNextOpen  ExecutionModel = "NEXT_OPEN"  // fill at the next open; stops and targets trade intrabar
+)
+
+// Valid reports whether e is a known model; empty means SAME_CLOSE.
+func (e ExecutionModel) Valid() bool {
+	return e == "" || e == ExecutionSameClose || e == ExecutionNextOpen
+}
+
+// TradeDirection is the side of the market a strategy trades.
+type TradeDirection string
+
+const (
+	DirectionLong      TradeDirection = "LONG"       // buy on the entry rules (default)
+	DirectionShort     TradeDirection = "SHORT"      // sell short on the short entry rules
+	DirectionLongShort TradeDirection = "LONG_SHORT" // either side, long checked first
+)
+
+// Valid reports whether d is a known direction; empty means LONG.
+func (d TradeDirection) Valid() bool {
+	return d == "" || d == DirectionLong || d == DirectionShort || d == DirectionLongShort
+}
+
+// Long reports whether d opens long positions.
+func (d TradeDirection) Long() bool {
+	return d != DirectionShort
+}
+
+// Short reports whether d opens short positions.
+func (d TradeDirection) Short() bool {
+	return d == DirectionShort || d == DirectionLongShort
+}
+
+// Timeframe is the bar size a strategy or rule is evaluated on.
+type Timeframe string
+
+const (
+	Timeframe1Min   Timeframe = "1m"
+	Timeframe5Min   Timeframe = "5m"
+	Timeframe15Min  Timeframe = "15m"
+	Timeframe1Hour  Timeframe = "1h"
+	Timeframe4Hour  Timeframe = "4h"
+	TimeframeDaily  Timeframe = "1d" // default
+	TimeframeWeekly Timeframe = "1w" // rules only
+)
+
+// Valid reports whether t is a known timeframe; empty means 1d.
+func (t Timeframe) Valid() bool {
+	_, timespan := t.Bars()
+	return timespan != ""
+}
+
+// Intraday reports whether t's bars are shorter than a trading session.
+func (t Timeframe) Intraday() bool {
+	return t.Valid() && t.Duration() < 24*time.Hour
+}
+
+// Bars returns the aggregate size of one bar, e.g. 5 "minute", or "" for an
+// unknown timeframe.
+func (t Timeframe) Bars() (multiplier int, timespan string) {
+	switch t {
+	case Timeframe1Min:
+		return 1, "minute"
+	case Timeframe5Min:
+		return 5, "minute"
+	case Timeframe15Min:
+		return 15, "minute"
+	case Timeframe1Hour:
+		return 1, "hour"
+	case Timeframe4Hour:
+		return 4, "hour"
+	case "", TimeframeDaily:
+		return 1, "day"
+	case TimeframeWeekly:
+		return 1, "week"
+	}
+	return 0, ""
+}
+
+// Duration is the length of one bar.
+func (t Timeframe) Duration() time.Duration {
+	multiplier, timespan := t.Bars()
+	switch timespan {
+	case "minute":
+		return time.Duration(multiplier) * time.Minute
+	case "hour":
+		return time.Duration(multiplier) * time.Hour
+	case "week":
+		return time.Duration(multiplier) * 7 * 24 * time.Hour
+	}
+	return time.Duration(multiplier) * 24 * time.Hour
 }
 
 // StrategyEntity is the persisted strategy document.
+//
+// EntryRules supersedes the flat BuyRules list (implicitly ANDed), which is kept
+// for documents written before rule trees existed. ExitRules is checked
+// alongside SellConditions; either one firing closes the position.
+//
+// The traded universe is Tickers, else the tickers of WatchlistUUID in the
+// strategy's portfolio, else the single Ticker. MaxPositions caps how many
+// symbols may be held at once (0 means one slot per symbol).
+//
+// MaxEntries lets a position be added to on later entry signals, up to that
+// many entries in total; 0 or 1 enters once.
+//
+// Timeframe sets the bar size rules, indicators and exits work in, daily by
+// default. Intraday timeframes only trade bars of the regular session, and
+// FlattenAtClose closes every position on each session's last bar and opens
+// none there, so nothing is held overnight.
+//
+// Direction selects the sides traded. Short positions open on ShortEntryRules
+// and close on ShortExitRules; price and time sell conditions apply to both
+// sides, measured in the position's favour, while indicator sell conditions
+// and ExitRules only close longs.
+//
+// Revision numbers the strategy's trading definition. Each change to it saves
+// a new immutable revision; strategies saved before versioning have none until
+// they are first updated or backtested.
 type StrategyEntity struct {
 	UUID           string          `json:"uuid" bson:"uuid"`
 	Name           string          `json:"name" bson:"name"`
 	Description    string          `json:"description" bson:"description"`
 	Ticker         string          `json:"ticker" bson:"ticker"`
+	Tickers        []string        `json:"tickers,omitempty" bson:"tickers,omitempty"`
+	WatchlistUUID  string          `json:"watchlist_uuid,omitempty" bson:"watchlist_uuid,omitempty"`
+	MaxPositions   int             `json:"max_positions,omitempty" bson:"max_positions,omitempty"`
+	PositionSizing *PositionSizing `json:"position_sizing,omitempty" bson:"position_sizing,omitempty"`
+	Execution      ExecutionModel  `json:"execution,omitempty" bson:"execution,omitempty"`
 	BuyRules       []Rule          `json:"buy_rules" bson:"buy_rules"`
+	EntryRules     *RuleGroup      `json:"entry_rules,omitempty" bson:"entry_rules,omitempty"`
+	ExitRules      *RuleGroup      `json:"exit_rules,omitempty" bson:"exit_rules,omitempty"`
 	SellConditions []SellCondition `json:"sell_conditions" bson:"sell_conditions"`
+	Live           *LiveSettings   `json:"live,omitempty" bson:"live,omitempty"`
+	Revision       int             `json:"revision" bson:"revision,omitempty"`
 	PortfolioUUID  string          `json:"portfolio_uuid" bson:"portfolio_uuid"`
 	AccountID      string          `json:"account_id" bson:"account_id"`
 	CreatedAt      time.Time       `json:"created_at" bson:"created_at"`
 	UpdatedAt      time.Time       `json:"updated_at" bson:"updated_at"`
+
+	// Short side; empty on strategies saved before short selling
+	Direction       TradeDirection `json:"direction,omitempty" bson:"direction,omitempty"`
+	ShortEntryRules *RuleGroup     `json:"short_entry_rules,omitempty" bson:"short_entry_rules,omitempty"`
+	ShortExitRules  *RuleGroup     `json:"short_exit_rules,omitempty" bson:"short_exit_rules,omitempty"`
+
+	// Pyramiding; see MaxEntries
+	MaxEntries int `json:"max_entries,omitempty" bson:"max_entries,omitempty"`
+
+	// Bar size; see Timeframe
+	Timeframe      Timeframe `json:"timeframe,omitempty" bson:"timeframe,omitempty"`
+	FlattenAtClose bool      `json:"flatten_at_close,omitempty" bson:"flatten_at_close,omitempty"`
+}
+
+// Entry returns the entry rule tree, falling back to the legacy BuyRules.
+func (s *StrategyEntity) Entry() *RuleGroup {
+	if s.EntryRules != nil {
+		return s.EntryRules
+	}
+	return AllOf(s.BuyRules)
 }
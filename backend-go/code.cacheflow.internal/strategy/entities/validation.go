@@ -0,0 +1,243 @@
+package entities
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MaxRulePeriod = 10000 // bounds every window and period a rule may use
+	MaxEntries    = 100   // bounds StrategyEntity.MaxEntries
+)
+
+// FieldErrors maps a dotted field path, such as
+// "entry_rules.children.0.rule.fast_window", to what is wrong with it. Paths
+// use the same form as optimizer params.
+type FieldErrors map[string]string
+
+// Error lists the problems in field order.
+func (e FieldErrors) Error() string {
+	fields := make([]string, 0, len(e))
+	for field := range e {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for i, field := range fields {
+		fields[i] = field + ": " + e[field]
+	}
+	return strings.Join(fields, "; ")
+}
+
+func (e FieldErrors) add(path, field, format string, args ...any) {
+	if path != "" {
+		field = path + "." + field
+	}
+	if _, ok := e[field]; !ok {
+		e[field] = fmt.Sprintf(format, args...)
+	}
+}
+
+// ValidateRules checks the direction, timeframe, every buy rule, rule tree leaf
+// and sell condition of s and returns the problems by field, or nil when there
+// are none. A rule that passes can fire: its periods are in range, crossovers
+// compare two distinct lines and thresholds are reachable.
+func (s *StrategyEntity) ValidateRules() FieldErrors {
+	errs := FieldErrors{}
+	if !s.Direction.Valid() {
+		errs["direction"] = "must be LONG, SHORT or LONG_SHORT"
+	}
+	if !s.Timeframe.Valid() || s.Timeframe == TimeframeWeekly {
+		errs["timeframe"] = "must be 1m, 5m, 15m, 1h, 4h or 1d"
+	} else if s.FlattenAtClose && !s.Timeframe.Intraday() {
+		errs["flatten_at_close"] = "needs an intraday timeframe"
+	}
+	if s.MaxEntries < 0 || s.MaxEntries > MaxEntries {
+		errs["max_entries"] = fmt.Sprintf("must be between 0 and %d", MaxEntries)
+	}
+	for i := range s.BuyRules {
+		s.BuyRules[i].validate("buy_rules."+strconv.Itoa(i), s.Timeframe, errs)
+	}
+	s.EntryRules.validateRules("entry_rules", s.Timeframe, errs)
+	s.ExitRules.validateRules("exit_rules", s.Timeframe, errs)
+	s.ShortEntryRules.validateRules("short_entry_rules", s.Timeframe, errs)
+	s.ShortExitRules.validateRules("short_exit_rules", s.Timeframe, errs)
+	for i := range s.SellConditions {
+		s.SellConditions[i].validate("sell_conditions."+strconv.Itoa(i), s.Timeframe, errs)
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// validateRules reports the tree's shape at path, or else checks its leaves
+// for a strategy trading timeframe tf.
+func (g *RuleGroup) validateRules(path string, tf Timeframe, errs FieldErrors) {
+	if g == nil {
+		return
+	}
+	if err := g.Validate(); err != nil {
+		errs[path] = err.Error()
+		return
+	}
+	g.validateLeaves(path, tf, errs)
+}
+
+func (g *RuleGroup) validateLeaves(path string, tf Timeframe, errs FieldErrors) {
+	if g.Rule != nil {
+		g.Rule.validate(path+".rule", tf, errs)
+		return
+	}
+	for i := range g.Children {
+		g.Children[i].validateLeaves(path+".children."+strconv.Itoa(i), tf, errs)
+	}
+}
+
+func (c *SellCondition) validate(path string, tf Timeframe, errs FieldErrors) {
+	if c.ClosePercent < 0 || c.ClosePercent > 100 {
+		errs.add(path, "close_percent", "must be between 0 and 100")
+	}
+	if c.Partial() && (c.Type == SellIndicator || c.Type == SellMaxHolding || c.Type == SellExitOnDate) {
+		errs.add(path, "close_percent", "partial exits need a price condition")
+	}
+
+	switch c.Type {
+	case SellTakeProfit:
+		if c.Percent <= 0 {
+			errs.add(path, "percent", "must be positive")
+		}
+	case SellStopLoss, SellTrailingStop:
+		if c.Percent <= 0 || c.Percent >= 100 {
+			errs.add(path, "percent", "must be between 0 and 100")
+		}
+	case SellIndicator:
+		if c.Rule == nil {
+			errs.add(path, "rule", "is required for indicator exits")
+			return
+		}
+		c.Rule.validate(path+".rule", tf, errs)
+	case SellMaxHolding:
+		if c.Bars < 0 || c.Bars > MaxRulePeriod {
+			errs.add(path, "bars", "must be between 0 and %d", MaxRulePeriod)
+		}
+		if c.Days < 0 {
+			errs.add(path, "days", "cannot be negative")
+		}
+		if c.Bars == 0 && c.Days == 0 {
+			errs.add(path, "bars", "bars or days is required")
+		}
+	case SellExitOnDate:
+		if _, err := time.Parse("2006-01-02", c.Date); err != nil {
+			errs.add(path, "date", "must be a YYYY-MM-DD date")
+		}
+	case SellATRStop, SellChandelier:
+		if c.ATRWindow < 0 || c.ATRWindow > MaxRulePeriod {
+			errs.add(path, "atr_window", "must be between 0 and %d", MaxRulePeriod)
+		}
+		if c.ATRMultiple <= 0 {
+			errs.add(path, "atr_multiple", "must be positive")
+		}
+	case SellBreakEven:
+		if c.Percent <= 0 {
+			errs.add(path, "percent", "must be positive")
+		}
+	case "":
+		errs.add(path, "type", "sell condition type is required")
+	default:
+		errs.add(path, "type", "unknown sell condition type %q", c.Type)
+	}
+}
+
+// validate checks r as a rule of a strategy trading timeframe tf.
+func (r *Rule) validate(path string, tf Timeframe, errs FieldErrors) {
+	switch {
+	case !r.Timeframe.Valid():
+		errs.add(path, "timeframe", "must be 1m, 5m, 15m, 1h, 4h, 1d or 1w")
+	case r.Timeframe != "" && tf.Valid() && r.Timeframe.Duration() < tf.Duration():
+		errs.add(path, "timeframe", "cannot be shorter than the strategy's timeframe")
+	}
+
+	period := func(field string, v int) {
+		if v < 0 || v > MaxRulePeriod {
+			errs.add(path, field, "must be between 0 and %d", MaxRulePeriod)
+		}
+	}
+	// Zero periods fall back to the indicator's default
+	period("window", r.Window)
+	period("fast_window", r.FastWindow)
+	period("slow_window", r.SlowWindow)
+	period("fast_period", r.FastPeriod)
+	period("slow_period", r.SlowPeriod)
+	period("signal_period", r.SignalPeriod)
+	period("k_period", r.KPeriod)
+	period("k_smoothing", r.KSmoothing)
+	period("d_period", r.DPeriod)
+
+	switch r.Type {
+	case RuleRSICrossAbove, RuleRSICrossBelow, RuleRSIAbove, RuleRSIBelow,
+		RuleADXAbove, RuleADXBelow:
+		if r.Value <= 0 || r.Value >= 100 {
+			errs.add(path, "value", "must be between 0 and 100")
+		}
+
+	case RuleEMACrossAbove, RuleEMACrossBelow, RuleSMACrossAbove, RuleSMACrossBelow:
+		// Crossovers have no default periods
+		if r.FastWindow <= 0 {
+			errs.add(path, "fast_window", "is required")
+		}
+		if r.SlowWindow <= 0 {
+			errs.add(path, "slow_window", "is required")
+		}
+		if r.FastWindow > 0 && r.FastWindow == r.SlowWindow {
+			errs.add(path, "slow_window", "must differ from fast_window")
+		}
+
+	case RuleMACDCrossSignalAbove, RuleMACDCrossSignalBelow, RuleMACDAboveZero, RuleMACDBelowZero:
+		fast, slow := r.FastPeriod, r.SlowPeriod
+		if fast == 0 {
+			fast = 12
+		}
+		if slow == 0 {
+			slow = 26
+		}
+		if fast >= slow {
+			errs.add(path, "slow_period", "must be longer than fast_period (defaults 12 and 26)")
+		}
+
+	case RulePriceAboveVWAP, RulePriceBelowVWAP:
+		if r.VWAPDeviation < 0 || r.VWAPDeviation >= 100 {
+			errs.add(path, "vwap_deviation", "must be between 0 and 100")
+		}
+
+	case RuleBBTouchUpper, RuleBBTouchLower, RuleBBBreakoutAbove, RuleBBBreakoutBelow:
+		if r.Window == 1 {
+			errs.add(path, "window", "must be at least 2")
+		}
+		if r.StdDev < 0 {
+			errs.add(path, "std_dev", "cannot be negative")
+		}
+
+	case RuleATRAbovePct, RuleATRBelowPct:
+		if r.Value <= 0 {
+			errs.add(path, "value", "must be a positive percent")
+		}
+
+	case RuleOBVBullishDivergence, RuleOBVBearishDivergence:
+		if r.Window == 1 {
+			errs.add(path, "window", "must be at least 2")
+		}
+
+	case RulePriceAboveEMA, RulePriceBelowEMA, RulePriceAboveSMA, RulePriceBelowSMA,
+		RuleStochCrossAbove, RuleStochCrossBelow,
+		RuleDonchianBreakAbove, RuleDonchianBreakBelow:
+		// Periods only, checked above
+
+	case "":
+		errs.add(path, "type", "rule type is required")
+	default:
+		errs.add(path, "type", "unknown rule type %q", r.Type)
+	}
+}
@@ -0,0 +1,186 @@
+package entities
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRules(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Strategy StrategyEntity
+		Expect   FieldErrors
+	}{
+		{
+			Name: "Valid with defaults",
+			Strategy: StrategyEntity{
+				BuyRules: []Rule{
+					{Type: RuleRSIBelow, Value: 30},
+					{Type: RuleEMACrossAbove, FastWindow: 12, SlowWindow: 26},
+					{Type: RuleMACDCrossSignalAbove},
+					{Type: RuleBBTouchLower},
+					{Type: RulePriceAboveVWAP},
+				},
+				SellConditions: []SellCondition{
+					{Type: SellTakeProfit, Percent: 150},
+					{Type: SellTrailingStop, Percent: 8},
+					{Type: SellIndicator, Rule: &Rule{Type: RuleADXBelow, Value: 20}},
+				},
+			},
+			Expect: nil,
+		},
+		{
+			Name:     "Crossover without windows",
+			Strategy: StrategyEntity{BuyRules: []Rule{{Type: RuleEMACrossAbove}}},
+			Expect: FieldErrors{
+				"buy_rules.0.fast_window": "is required",
+				"buy_rules.0.slow_window": "is required",
+			},
+		},
+		{
+			Name:     "Crossover of a line with itself",
+			Strategy: StrategyEntity{BuyRules: []Rule{{Type: RuleSMACrossBelow, FastWindow: 50, SlowWindow: 50}}},
+			Expect:   FieldErrors{"buy_rules.0.slow_window": "must differ from fast_window"},
+		},
+		{
+			Name: "Unreachable thresholds",
+			Strategy: StrategyEntity{BuyRules: []Rule{
+				{Type: RuleRSIAbove, Value: 100},
+				{Type: RuleATRBelowPct},
+				{Type: RuleMACDAboveZero, FastPeriod: 30},
+			}},
+			Expect: FieldErrors{
+				"buy_rules.0.value":       "must be between 0 and 100",
+				"buy_rules.1.value":       "must be a positive percent",
+				"buy_rules.2.slow_period": "must be longer than fast_period (defaults 12 and 26)",
+			},
+		},
+		{
+			Name: "Periods out of range",
+			Strategy: StrategyEntity{BuyRules: []Rule{
+				{Type: RulePriceAboveSMA, Window: -5},
+				{Type: RuleStochCrossAbove, KPeriod: 20000},
+			}},
+			Expect: FieldErrors{
+				"buy_rules.0.window":   "must be between 0 and 10000",
+				"buy_rules.1.k_period": "must be between 0 and 10000",
+			},
+		},
+		{
+			Name: "Paths into rule trees",
+			Strategy: StrategyEntity{
+				EntryRules: &RuleGroup{Op: RuleAnd, Children: []RuleGroup{
+					{Rule: &Rule{Type: RuleRSIBelow, Value: 30}},
+					{Op: RuleNot, Children: []RuleGroup{{Rule: &Rule{Type: "RSI_SIDEWAYS"}}}},
+				}},
+				ExitRules: &RuleGroup{Op: RuleOr},
+			},
+			Expect: FieldErrors{
+				"entry_rules.children.1.children.0.rule.type": `unknown rule type "RSI_SIDEWAYS"`,
+				"exit_rules": "OR group needs at least one child",
+			},
+		},
+		{
+			Name: "Sell conditions",
+			Strategy: StrategyEntity{
+				BuyRules: []Rule{{Type: RuleADXAbove, Value: 25}},
+				SellConditions: []SellCondition{
+					{Type: SellStopLoss},
+					{Type: SellTrailingStop, Percent: 100},
+					{Type: SellIndicator},
+					{Type: SellIndicator, Rule: &Rule{Type: RuleBBBreakoutAbove, Window: 1, StdDev: -2}},
+					{Type: "TIME_STOP"},
+				},
+			},
+			Expect: FieldErrors{
+				"sell_conditions.0.percent":      "must be between 0 and 100",
+				"sell_conditions.1.percent":      "must be between 0 and 100",
+				"sell_conditions.2.rule":         "is required for indicator exits",
+				"sell_conditions.3.rule.window":  "must be at least 2",
+				"sell_conditions.3.rule.std_dev": "cannot be negative",
+				"sell_conditions.4.type":         `unknown sell condition type "TIME_STOP"`,
+			},
+		},
+		{
+			Name: "Pyramiding and partial exits",
+			Strategy: StrategyEntity{
+				BuyRules:   []Rule{{Type: RuleRSIBelow, Value: 30}},
+				MaxEntries: 500,
+				SellConditions: []SellCondition{
+					{Type: SellTakeProfit, Percent: 10, ClosePercent: 50},
+					{Type: SellStopLoss, Percent: 5, ClosePercent: 120},
+					{Type: SellIndicator, Rule: &Rule{Type: RuleRSIAbove, Value: 70}, ClosePercent: 25},
+				},
+			},
+			Expect: FieldErrors{
+				"max_entries":                     "must be between 0 and 100",
+				"sell_conditions.1.close_percent": "must be between 0 and 100",
+				"sell_conditions.2.close_percent": "partial exits need a price condition",
+			},
+		},
+		{
+			Name: "Time and volatility exits",
+			Strategy: StrategyEntity{
+				BuyRules: []Rule{{Type: RuleRSIBelow, Value: 30}},
+				SellConditions: []SellCondition{
+					{Type: SellMaxHolding},
+					{Type: SellExitOnDate, Date: "01/31/2024"},
+					{Type: SellATRStop, ATRWindow: -1, ATRMultiple: 2},
+					{Type: SellChandelier},
+					{Type: SellBreakEven},
+					{Type: SellMaxHolding, Days: 10, ClosePercent: 50},
+				},
+			},
+			Expect: FieldErrors{
+				"sell_conditions.0.bars":          "bars or days is required",
+				"sell_conditions.1.date":          "must be a YYYY-MM-DD date",
+				"sell_conditions.2.atr_window":    "must be between 0 and 10000",
+				"sell_conditions.3.atr_multiple":  "must be positive",
+				"sell_conditions.4.percent":       "must be positive",
+				"sell_conditions.5.close_percent": "partial exits need a price condition",
+			},
+		},
+		{
+			Name:     "Unknown timeframe",
+			Strategy: StrategyEntity{BuyRules: []Rule{{Type: RuleRSIBelow, Value: 30}}, Timeframe: "2h"},
+			Expect:   FieldErrors{"timeframe": "must be 1m, 5m, 15m, 1h, 4h or 1d"},
+		},
+		{
+			Name:     "Flattening daily bars",
+			Strategy: StrategyEntity{BuyRules: []Rule{{Type: RuleRSIBelow, Value: 30}}, FlattenAtClose: true},
+			Expect:   FieldErrors{"flatten_at_close": "needs an intraday timeframe"},
+		},
+		{
+			Name: "Rule timeframes",
+			Strategy: StrategyEntity{
+				Timeframe: Timeframe1Hour,
+				BuyRules: []Rule{
+					{Type: RulePriceAboveSMA, Window: 20, Timeframe: TimeframeWeekly},
+					{Type: RuleRSIBelow, Value: 30, Timeframe: Timeframe5Min},
+					{Type: RuleRSIAbove, Value: 70, Timeframe: "1y"},
+				},
+			},
+			Expect: FieldErrors{
+				"buy_rules.1.timeframe": "cannot be shorter than the strategy's timeframe",
+				"buy_rules.2.timeframe": "must be 1m, 5m, 15m, 1h, 4h, 1d or 1w",
+			},
+		},
+		{
+			Name:     "Weekly strategy",
+			Strategy: StrategyEntity{BuyRules: []Rule{{Type: RuleRSIBelow, Value: 30}}, Timeframe: TimeframeWeekly},
+			Expect:   FieldErrors{"timeframe": "must be 1m, 5m, 15m, 1h, 4h or 1d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Expect, tt.Strategy.ValidateRules())
+		})
+	}
+}
+
+func TestFieldErrorsError(t *testing.T) {
+	errs := FieldErrors{"buy_rules.1.value": "must be positive", "buy_rules.0.window": "is required"}
+	assert.Equal(t, "buy_rules.0.window: is required; buy_rules.1.value: must be positive", errs.Error())
+}
@@ -0,0 +1,256 @@
+// Package metrics computes performance statistics from an equity curve and
+// its closed trades. It is shared by backtests, optimization and walk-forward
+// analysis, and has no dependencies on storage or market data.
+package metrics
+
+import (
+	"math"
+	"time"
+)
+
+// TradingDaysPerYear annualizes daily statistics.
+const TradingDaysPerYear = 252
+
+// Point is the account value at one bar's close.
+type Point struct {
+	Date     string  `json:"date" bson:"date"` // YYYY-MM-DD
+	Equity   float64 `json:"equity" bson:"equity"`
+	Cash     float64 `json:"cash" bson:"cash"`
+	Invested bool    `json:"invested" bson:"invested"` // any position open at the close
+}
+
+// Trade is a closed round trip.
+type Trade struct {
+	EntryDate  string  // YYYY-MM-DD
+	ExitDate   string  // YYYY-MM-DD
+	PnL        float64 // net of costs
+	PnLPercent float64
+}
+
+// Stats are the headline performance figures. Percentages are in percent
+// (12.5 means 12.5%); ratios that are undefined, such as a profit factor with
+// no losing trades, are 0.
+type Stats struct {
+	CAGR                float64 `json:"cagr" bson:"cagr"`                                   // compound annual growth, %
+	Volatility          float64 `json:"volatility" bson:"volatility"`                       // annualized stddev of daily returns, %
+	Sharpe              float64 `json:"sharpe" bson:"sharpe"`                               // annualized, zero risk-free rate
+	Sortino             float64 `json:"sortino" bson:"sortino"`                             // annualized, zero target return
+	Calmar              float64 `json:"calmar" bson:"calmar"`                               // CAGR / max drawdown
+	MaxDrawdown         float64 `json:"max_drawdown" bson:"max_drawdown"`                   // peak-to-trough on closes, %
+	LongestDrawdownDays int     `json:"longest_drawdown_days" bson:"longest_drawdown_days"` // calendar days below a prior peak
+	ProfitFactor        float64 `json:"profit_factor" bson:"profit_factor"`                 // gross profit / gross loss
+	Expectancy          float64 `json:"expectancy" bson:"expectancy"`                       // mean P&L per trade, $
+	AvgWin              float64 `json:"avg_win" bson:"avg_win"`                             // mean winning trade, $
+	AvgLoss             float64 `json:"avg_loss" bson:"avg_loss"`                           // mean losing trade, $ (negative)
+	AvgHoldingDays      float64 `json:"avg_holding_days" bson:"avg_holding_days"`           // calendar days per trade
+	Exposure            float64 `json:"exposure" bson:"exposure"`                           // % of bars with a position open
+}
+
+// Compute derives Stats from an equity curve that started at initial and the
+// trades closed along it.
+func Compute(initial float64, curve []Point, trades []Trade) Stats {
+	var s Stats
+	if len(curve) == 0 || initial <= 0 {
+		return s
+	}
+
+	returns := DailyReturns(initial, curve)
+	s.Volatility = StdDev(returns) * math.Sqrt(TradingDaysPerYear) * 100
+	s.Sharpe = sharpe(returns)
+	s.Sortino = sortino(returns)
+	s.MaxDrawdown, s.LongestDrawdownDays = drawdown(initial, curve)
+
+	final := curve[len(curve)-1].Equity
+	if years := yearsBetween(curve[0].Date, curve[len(curve)-1].Date); years > 0 && final > 0 {
+		s.CAGR = (math.Pow(final/initial, 1/years) - 1) * 100
+	}
+	if s.MaxDrawdown > 0 {
+		s.Calmar = s.CAGR / s.MaxDrawdown
+	}
+
+	invested := 0
+	for _, p := range curve {
+		if p.Invested {
+			invested++
+		}
+	}
+	s.Exposure = float64(invested) / float64(len(curve)) * 100
+
+	tradeStats(&s, trades)
+	return s
+}
+
+// DailyReturns is the fractional return of each point over the one before it,
+// the first measured from initial.
+func DailyReturns(initial float64, curve []Point) []float64 {
+	returns := make([]float64, 0, len(curve))
+	prev := initial
+	for _, p := range curve {
+		if prev > 0 {
+			returns = append(returns, p.Equity/prev-1)
+		}
+		prev = p.Equity
+	}
+	return returns
+}
+
+// StdDev is the sample standard deviation of values.
+func StdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	mean := Mean(values)
+	var sum float64
+	for _, v := range values {
+		sum += (v - mean) * (v - mean)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
+
+// Mean is the arithmetic mean of values.
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+func sharpe(returns []float64) float64 {
+	sd := StdDev(returns)
+	if sd == 0 {
+		return 0
+	}
+	return Mean(returns) / sd * math.Sqrt(TradingDaysPerYear)
+}
+
+func sortino(returns []float64) float64 {
+	if len(returns) == 0 {
+		return 0
+	}
+	var downside float64
+	for _, r := range returns {
+		if r < 0 {
+			downside += r * r
+		}
+	}
+	dd := math.Sqrt(downside / float64(len(returns)))
+	if dd == 0 {
+		return 0
+	}
+	return Mean(returns) / dd * math.Sqrt(TradingDaysPerYear)
+}
+
+// drawdown returns the deepest peak-to-trough decline in percent and the
+// longest stretch, in calendar days, spent below a prior peak.
+func drawdown(initial float64, curve []Point) (float64, int) {
+	peak, peakDate := initial, curve[0].Date
+	maxDD, longest := 0.0, 0
+	underwater := false
+	for _, p := range curve {
+		if p.Equity >= peak {
+			if underwater {
+				longest = max(longest, daysBetween(peakDate, p.Date))
+			}
+			peak, peakDate, underwater = p.Equity, p.Date, false
+			continue
+		}
+		underwater = true
+		maxDD = math.Max(maxDD, (peak-p.Equity)/peak*100)
+	}
+	if underwater {
+		longest = max(longest, daysBetween(peakDate, curve[len(curve)-1].Date))
+	}
+	return maxDD, longest
+}
+
+func tradeStats(s *Stats, trades []Trade) {
+	if len(trades) == 0 {
+		return
+	}
+
+	var grossWin, grossLoss, total float64
+	var wins, losses, held int
+	for _, t := range trades {
+		total += t.PnL
+		held += daysBetween(t.EntryDate, t.ExitDate)
+		if t.PnL >= 0 {
+			wins++
+			grossWin += t.PnL
+		} else {
+			losses++
+			grossLoss -= t.PnL
+		}
+	}
+
+	s.Expectancy = total / float64(len(trades))
+	s.AvgHoldingDays = float64(held) / float64(len(trades))
+	if wins > 0 {
+		s.AvgWin = grossWin / float64(wins)
+	}
+	if losses > 0 {
+		s.AvgLoss = -grossLoss / float64(losses)
+	}
+	if grossLoss > 0 {
+		s.ProfitFactor = grossWin / grossLoss
+	}
+}
+
+func daysBetween(from, to string) int {
+	a, errA := time.Parse("2006-01-02", from)
+	b, errB := time.Parse("2006-01-02", to)
+	if errA != nil || errB != nil {
+		return 0
+	}
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// yearsBetween counts both end dates, so a single bar spans one day.
+func yearsBetween(from, to string) float64 {
+	return float64(daysBetween(from, to)+1) / 365.25
+}
+
+// Relative measures a return series against a benchmark's.
+type Relative struct {
+	Alpha            float64 `json:"alpha" bson:"alpha"` // annualized excess return over beta × benchmark, %
+	Beta             float64 `json:"beta" bson:"beta"`
+	Correlation      float64 `json:"correlation" bson:"correlation"`
+	InformationRatio float64 `json:"information_ratio" bson:"information_ratio"` // annualized active return / tracking error
+}
+
+// Compare relates daily returns to benchmark returns over the same days.
+// Extra values on either side are ignored.
+func Compare(returns, benchmark []float64) Relative {
+	n := min(len(returns), len(benchmark))
+	var r Relative
+	if n < 2 {
+		return r
+	}
+	returns, benchmark = returns[:n], benchmark[:n]
+
+	meanR, meanB := Mean(returns), Mean(benchmark)
+	active := make([]float64, n)
+	var cov, varR, varB float64
+	for i := range n {
+		dr, db := returns[i]-meanR, benchmark[i]-meanB
+		cov += dr * db
+		varR += dr * dr
+		varB += db * db
+		active[i] = returns[i] - benchmark[i]
+	}
+
+	if varB > 0 {
+		r.Beta = cov / varB
+	}
+	if varR > 0 && varB > 0 {
+		r.Correlation = cov / math.Sqrt(varR*varB)
+	}
+	r.Alpha = (meanR - r.Beta*meanB) * TradingDaysPerYear * 100
+	if te := StdDev(active); te > 0 {
+		r.InformationRatio = Mean(active) / te * math.Sqrt(TradingDaysPerYear)
+	}
+	return r
+}
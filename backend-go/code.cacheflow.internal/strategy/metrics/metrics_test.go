@@ -0,0 +1,81 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompute(t *testing.T) {
+	curve := []Point{
+		{Date: "2024-01-01", Equity: 110, Invested: true},
+		{Date: "2024-01-02", Equity: 99, Invested: true},
+		{Date: "2024-01-03", Equity: 105, Invested: false},
+		{Date: "2024-01-04", Equity: 121, Invested: false},
+	}
+	trades := []Trade{
+		{EntryDate: "2024-01-01", ExitDate: "2024-01-03", PnL: 30},
+		{EntryDate: "2024-01-03", ExitDate: "2024-01-04", PnL: -10},
+	}
+
+	s := Compute(100, curve, trades)
+
+	assert.InDelta(t, 10, s.MaxDrawdown, 1e-9) // 110 → 99
+	assert.Equal(t, 3, s.LongestDrawdownDays)  // under the 01-01 peak until 01-04
+	assert.InDelta(t, 50, s.Exposure, 1e-9)    // 2 of 4 bars
+	assert.InDelta(t, 3, s.ProfitFactor, 1e-9) // 30 / 10
+	assert.InDelta(t, 10, s.Expectancy, 1e-9)  // (30 − 10) / 2
+	assert.InDelta(t, 30, s.AvgWin, 1e-9)
+	assert.InDelta(t, -10, s.AvgLoss, 1e-9)
+	assert.InDelta(t, 1.5, s.AvgHoldingDays, 1e-9)
+	assert.Greater(t, s.CAGR, 0.0)
+	assert.Greater(t, s.Sharpe, 0.0)
+	assert.InDelta(t, s.CAGR/s.MaxDrawdown, s.Calmar, 1e-9)
+}
+
+func TestComputeEmpty(t *testing.T) {
+	assert.Equal(t, Stats{}, Compute(100, nil, nil))
+}
+
+func TestSortinoIgnoresUpsideVolatility(t *testing.T) {
+	flatDown := []float64{0.01, -0.01, 0.01, -0.01}
+	bigUp := []float64{0.05, -0.01, 0.05, -0.01}
+
+	assert.Greater(t, sortino(bigUp), sortino(flatDown))
+	assert.InDelta(t, StdDev([]float64{1, 2, 3, 4}), 1.2909944487, 1e-9)
+}
+
+func TestCompare(t *testing.T) {
+	benchmark := []float64{0.01, -0.02, 0.015, 0.005, -0.01}
+
+	tests := []struct {
+		Name        string
+		Returns     []float64
+		Beta        float64
+		Correlation float64
+		Alpha       float64
+	}{
+		{Name: "Identical series", Returns: benchmark, Beta: 1, Correlation: 1, Alpha: 0},
+		{Name: "Twice the benchmark", Returns: scale(benchmark, 2), Beta: 2, Correlation: 1, Alpha: 0},
+		{Name: "Inverse", Returns: scale(benchmark, -1), Beta: -1, Correlation: -1, Alpha: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			r := Compare(tt.Returns, benchmark)
+			assert.InDelta(t, tt.Beta, r.Beta, 1e-9)
+			assert.InDelta(t, tt.Correlation, r.Correlation, 1e-9)
+			assert.InDelta(t, tt.Alpha, r.Alpha, 1e-9)
+		})
+	}
+
+	assert.Equal(t, Relative{}, Compare([]float64{0.01}, benchmark))
+}
+
+func scale(values []float64, k float64) []float64 {
+	out := make([]float64, len(values))
+	for i, v := range values {
+		out[i] = v * k
+	}
+	return out
+}
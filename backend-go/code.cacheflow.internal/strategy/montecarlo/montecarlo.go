@@ -0,0 +1,260 @@
+// Package montecarlo stress-tests a backtest by replaying resampled versions
+// of its returns. Trade methods resample closed-trade returns; the block
+// bootstrap resamples runs of daily equity returns, keeping their
+// autocorrelation.
+package montecarlo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Method selects how paths are resampled.
+type Method string
+
+const (
+	MethodTradeShuffle   Method = "TRADE_SHUFFLE"   // every trade once, in random order (default)
+	MethodBootstrap      Method = "BOOTSTRAP"       // trades drawn with replacement
+	MethodBlockBootstrap Method = "BLOCK_BOOTSTRAP" // blocks of consecutive daily returns drawn with replacement
+)
+
+const (
+	MaxSimulations       = 10000
+	defaultSimulations   = 1000
+	defaultBlockSize     = 5
+	defaultRuinThreshold = 50
+	maxBands             = 100
+)
+
+// Config configures a run. Zero values take the defaults noted.
+type Config struct {
+	Method           Method  `json:"method" bson:"method"`                       // default TRADE_SHUFFLE
+	Simulations      int     `json:"num_simulations" bson:"num_simulations"`     // default 1000
+	PositionFraction float64 `json:"position_fraction" bson:"position_fraction"` // share of equity exposed to each return, 0–1 (default 1)
+	BlockSize        int     `json:"block_size" bson:"block_size"`               // BLOCK_BOOTSTRAP days per block (default 5)
+	RuinThreshold    float64 `json:"ruin_threshold" bson:"ruin_threshold"`       // % loss of initial balance that counts as ruin (default 50)
+	Seed             int64   `json:"seed" bson:"seed"`                           // 0 picks one; echoed back for replays
+}
+
+// Daily reports whether the method resamples daily returns rather than trades.
+func (c *Config) Daily() bool {
+	return c.Method == MethodBlockBootstrap
+}
+
+// Normalize validates c and fills in defaults.
+func (c *Config) Normalize() error {
+	switch c.Method {
+	case "":
+		c.Method = MethodTradeShuffle
+	case MethodTradeShuffle, MethodBootstrap, MethodBlockBootstrap:
+	default:
+		return fmt.Errorf("unknown method %q", c.Method)
+	}
+	if c.Simulations <= 0 || c.Simulations > MaxSimulations {
+		c.Simulations = defaultSimulations
+	}
+	if c.PositionFraction == 0 {
+		c.PositionFraction = 1
+	}
+	if c.PositionFraction < 0 || c.PositionFraction > 1 {
+		return errors.New("position_fraction must be between 0 and 1")
+	}
+	if c.BlockSize <= 0 {
+		c.BlockSize = defaultBlockSize
+	}
+	if c.RuinThreshold == 0 {
+		c.RuinThreshold = defaultRuinThreshold
+	}
+	if c.RuinThreshold < 0 || c.RuinThreshold > 100 {
+		return errors.New("ruin_threshold must be between 0 and 100")
+	}
+	if c.Seed == 0 {
+		c.Seed = time.Now().UnixNano()
+	}
+	return nil
+}
+
+// Band holds equity percentiles across paths after Step returns.
+type Band struct {
+	Step int     `json:"step" bson:"step"`
+	P5   float64 `json:"p5" bson:"p5"`
+	P25  float64 `json:"p25" bson:"p25"`
+	P50  float64 `json:"p50" bson:"p50"`
+	P75  float64 `json:"p75" bson:"p75"`
+	P95  float64 `json:"p95" bson:"p95"`
+}
+
+// Distribution summarizes one statistic across paths.
+type Distribution struct {
+	P50   float64 `json:"p50" bson:"p50"`
+	P90   float64 `json:"p90" bson:"p90"`
+	P95   float64 `json:"p95" bson:"p95"`
+	Worst float64 `json:"worst" bson:"worst"`
+}
+
+// Result is the outcome of a run. The flat final-balance fields predate the
+// configurable methods and keep their meaning.
+type Result struct {
+	NumSimulations int       `json:"num_simulations" bson:"num_simulations"`
+	InitialBalance float64   `json:"initial_balance" bson:"initial_balance"`
+	Median         float64   `json:"median" bson:"median"`
+	P10            float64   `json:"p10" bson:"p10"`
+	P25            float64   `json:"p25" bson:"p25"`
+	P75            float64   `json:"p75" bson:"p75"`
+	P90            float64   `json:"p90" bson:"p90"`
+	Worst          float64   `json:"worst" bson:"worst"`
+	Best           float64   `json:"best" bson:"best"`
+	ProbProfit     float64   `json:"prob_profit" bson:"prob_profit"`   // % of paths that ended above the initial balance
+	FinalValues    []float64 `json:"final_values" bson:"final_values"` // every path's final balance, sorted (for distribution charts)
+
+	Config      Config       `json:"config" bson:"config"`
+	Bands       []Band       `json:"bands" bson:"bands"`               // equity percentiles over time
+	MaxDrawdown Distribution `json:"max_drawdown" bson:"max_drawdown"` // per-path max drawdown, %; higher percentiles are worse
+	RiskOfRuin  float64      `json:"risk_of_ruin" bson:"risk_of_ruin"` // % of paths that lost RuinThreshold % of the initial balance
+}
+
+// Simulate runs cfg over samples, which are fractional returns: per closed
+// trade for trade methods, per day for BLOCK_BOOTSTRAP. cfg must be
+// normalized.
+func Simulate(cfg Config, initial float64, samples []float64) (*Result, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("no returns to resample")
+	}
+	if initial <= 0 {
+		return nil, errors.New("initial balance must be positive")
+	}
+
+	rng := rand.New(rand.NewSource(cfg.Seed))
+	steps := len(samples)
+	bandSteps := bandIndices(steps)
+	atStep := make([][]float64, len(bandSteps)) // [band][path]
+	for i := range atStep {
+		atStep[i] = make([]float64, cfg.Simulations)
+	}
+
+	finals := make([]float64, cfg.Simulations)
+	drawdowns := make([]float64, cfg.Simulations)
+	ruinLevel := initial * (1 - cfg.RuinThreshold/100)
+	ruined, profitable := 0, 0
+
+	path := make([]float64, steps)
+	for sim := range cfg.Simulations {
+		resample(cfg, rng, samples, path)
+
+		equity, peak, maxDD := initial, initial, 0.0
+		hitRuin := false
+		band := 0
+		for step, r := range path {
+			equity = math.Max(0, equity*(1+cfg.PositionFraction*r))
+			peak = math.Max(peak, equity)
+			if peak > 0 {
+				maxDD = math.Max(maxDD, (peak-equity)/peak*100)
+			}
+			if equity <= ruinLevel {
+				hitRuin = true
+			}
+			if band < len(bandSteps) && bandSteps[band] == step+1 {
+				atStep[band][sim] = equity
+				band++
+			}
+		}
+
+		finals[sim] = equity
+		drawdowns[sim] = maxDD
+		if hitRuin {
+			ruined++
+		}
+		if equity > initial {
+			profitable++
+		}
+	}
+
+	sort.Float64s(finals)
+	sort.Float64s(drawdowns)
+
+	bands := make([]Band, len(bandSteps))
+	for i, step := range bandSteps {
+		values := atStep[i]
+		sort.Float64s(values)
+		bands[i] = Band{
+			Step: step,
+			P5:   Percentile(values, 0.05),
+			P25:  Percentile(values, 0.25),
+			P50:  Percentile(values, 0.50),
+			P75:  Percentile(values, 0.75),
+			P95:  Percentile(values, 0.95),
+		}
+	}
+
+	n := float64(cfg.Simulations)
+	return &Result{
+		NumSimulations: cfg.Simulations,
+		InitialBalance: initial,
+		Median:         Percentile(finals, 0.50),
+		P10:            Percentile(finals, 0.10),
+		P25:            Percentile(finals, 0.25),
+		P75:            Percentile(finals, 0.75),
+		P90:            Percentile(finals, 0.90),
+		Worst:          finals[0],
+		Best:           finals[len(finals)-1],
+		ProbProfit:     float64(profitable) / n * 100,
+		FinalValues:    finals,
+		Config:         cfg,
+		Bands:          bands,
+		MaxDrawdown: Distribution{
+			P50:   Percentile(drawdowns, 0.50),
+			P90:   Percentile(drawdowns, 0.90),
+			P95:   Percentile(drawdowns, 0.95),
+			Worst: drawdowns[len(drawdowns)-1],
+		},
+		RiskOfRuin: float64(ruined) / n * 100,
+	}, nil
+}
+
+// resample fills path with one resampled sequence of samples.
+func resample(cfg Config, rng *rand.Rand, samples, path []float64) {
+	switch cfg.Method {
+	case MethodBootstrap:
+		for i := range path {
+			path[i] = samples[rng.Intn(len(samples))]
+		}
+	case MethodBlockBootstrap:
+		block := min(cfg.BlockSize, len(samples))
+		for i := 0; i < len(path); {
+			start := rng.Intn(len(samples) - block + 1)
+			i += copy(path[i:], samples[start:start+block])
+		}
+	default:
+		copy(path, samples)
+		rng.Shuffle(len(path), func(a, b int) { path[a], path[b] = path[b], path[a] })
+	}
+}
+
+// bandIndices picks up to maxBands evenly spaced step counts ending at steps.
+func bandIndices(steps int) []int {
+	n := min(steps, maxBands)
+	out := make([]int, n)
+	for i := range out {
+		out[i] = (i + 1) * steps / n
+	}
+	return out
+}
+
+// Percentile interpolates the p-th quantile (0–1) of sorted values.
+func Percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	idx := p * float64(len(sorted)-1)
+	lo := int(math.Floor(idx))
+	hi := int(math.Ceil(idx))
+	if lo == hi {
+		return sorted[lo]
+	}
+	frac := idx - float64(lo)
+	return sorted[lo]*(1-frac) + sorted[hi]*frac
+}
@@ -0,0 +1,98 @@
+package montecarlo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimulate(t *testing.T) {
+	trades := []float64{0.10, -0.05, 0.20, -0.10, 0.05}
+
+	tests := []struct {
+		Name   string
+		Config Config
+		Check  func(t *testing.T, r *Result)
+	}{
+		{
+			Name:   "Shuffle ends every path at the same balance",
+			Config: Config{Method: MethodTradeShuffle, Simulations: 200},
+			Check: func(t *testing.T, r *Result) {
+				assert.InDelta(t, r.Worst, r.Best, 1e-6) // compounding commutes
+				assert.Len(t, r.Bands, len(trades))
+				assert.InDelta(t, r.Median, r.Bands[len(r.Bands)-1].P50, 1e-6)
+			},
+		},
+		{
+			Name:   "Bootstrap spreads outcomes",
+			Config: Config{Method: MethodBootstrap, Simulations: 500},
+			Check: func(t *testing.T, r *Result) {
+				assert.Less(t, r.Worst, r.Best)
+				assert.LessOrEqual(t, r.MaxDrawdown.P50, r.MaxDrawdown.Worst)
+			},
+		},
+		{
+			Name:   "Block bootstrap keeps path length",
+			Config: Config{Method: MethodBlockBootstrap, Simulations: 100, BlockSize: 2},
+			Check: func(t *testing.T, r *Result) {
+				assert.Equal(t, len(trades), r.Bands[len(r.Bands)-1].Step)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			cfg := tt.Config
+			cfg.Seed = 7
+			assert.NoError(t, cfg.Normalize())
+			r, err := Simulate(cfg, 10000, trades)
+			assert.NoError(t, err)
+			tt.Check(t, r)
+		})
+	}
+}
+
+func TestFractionalSizingDampsSpread(t *testing.T) {
+	trades := []float64{0.10, -0.05, 0.20, -0.10, 0.05}
+	full := Config{Method: MethodBootstrap, Simulations: 500, Seed: 3}
+	tenth := full
+	tenth.PositionFraction = 0.1
+	assert.NoError(t, full.Normalize())
+	assert.NoError(t, tenth.Normalize())
+
+	a, _ := Simulate(full, 10000, trades)
+	b, _ := Simulate(tenth, 10000, trades)
+	assert.Less(t, b.Best-b.Worst, (a.Best-a.Worst)/5)
+	assert.Less(t, b.MaxDrawdown.Worst, a.MaxDrawdown.Worst)
+}
+
+func TestSimulateIsReproducible(t *testing.T) {
+	cfg := Config{Method: MethodBootstrap, Seed: 42}
+	assert.NoError(t, cfg.Normalize())
+
+	a, _ := Simulate(cfg, 1000, []float64{0.1, -0.2, 0.05})
+	b, _ := Simulate(cfg, 1000, []float64{0.1, -0.2, 0.05})
+	assert.Equal(t, a.FinalValues, b.FinalValues)
+}
+
+func TestRiskOfRuin(t *testing.T) {
+	cfg := Config{Method: MethodTradeShuffle, Simulations: 10, RuinThreshold: 40, Seed: 1}
+	assert.NoError(t, cfg.Normalize())
+
+	r, err := Simulate(cfg, 1000, []float64{-0.7, 0.5})
+	assert.NoError(t, err)
+	assert.Equal(t, 100.0, r.RiskOfRuin) // both orders fall below 600
+	assert.InDelta(t, 70, r.MaxDrawdown.Worst, 1e-9)
+}
+
+func TestConfigNormalize(t *testing.T) {
+	cfg := Config{}
+	assert.NoError(t, cfg.Normalize())
+	assert.Equal(t, MethodTradeShuffle, cfg.Method)
+	assert.Equal(t, 1000, cfg.Simulations)
+	assert.Equal(t, 1.0, cfg.PositionFraction)
+	assert.NotZero(t, cfg.Seed)
+
+	assert.Error(t, (&Config{Method: "NOPE"}).Normalize())
+	assert.Error(t, (&Config{PositionFraction: 1.5}).Normalize())
+}
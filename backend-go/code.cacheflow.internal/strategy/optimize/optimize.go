@@ -0,0 +1,352 @@
+// Package optimize expands parameter ranges into candidate strategies and
+// ranks backtest results by an objective. It knows nothing about how a
+// backtest runs; callers apply each candidate and score the outcome.
+package optimize
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"time"
+
+	"code.cacheflow.internal/strategy/metrics"
+)
+
+// MaxCandidates caps how many parameter sets one optimization may evaluate.
+const MaxCandidates = 500
+
+// Param is one tunable value of a strategy, addressed by its JSON path, e.g.
+// "entry_rules.children.0.rule.value" or "sell_conditions.1.percent". It
+// takes either explicit Values or the range Min..Max in Step increments.
+type Param struct {
+	Path   string    `json:"path"`
+	Values []float64 `json:"values,omitempty"`
+	Min    float64   `json:"min"`
+	Max    float64   `json:"max"`
+	Step   float64   `json:"step"`
+}
+
+// Grid lists the values the parameter takes.
+func (p Param) Grid() ([]float64, error) {
+	if strings.TrimSpace(p.Path) == "" {
+		return nil, errors.New("param path is required")
+	}
+	if len(p.Values) > 0 {
+		return p.Values, nil
+	}
+	if p.Step <= 0 || p.Max < p.Min {
+		return nil, fmt.Errorf("%s: needs values or min <= max with a positive step", p.Path)
+	}
+	n := int(math.Floor((p.Max-p.Min)/p.Step+1e-9)) + 1
+	if n > MaxCandidates {
+		return nil, fmt.Errorf("%s: range has more than %d values", p.Path, MaxCandidates)
+	}
+	values := make([]float64, n)
+	for i := range values {
+		// Round away float drift so 0.1 steps land on 0.3, not 0.30000000000000004
+		values[i] = math.Round((p.Min+float64(i)*p.Step)*1e9) / 1e9
+	}
+	return values, nil
+}
+
+// Candidate is one assignment of values to params, in param order.
+type Candidate []float64
+
+// Grid expands params into their cartesian product.
+func Grid(params []Param) ([]Candidate, error) {
+	grids, err := grids(params)
+	if err != nil {
+		return nil, err
+	}
+
+	total := 1
+	for _, g := range grids {
+		total *= len(g)
+		if total > MaxCandidates {
+			return nil, fmt.Errorf("the grid has more than %d combinations; narrow the ranges or use random search", MaxCandidates)
+		}
+	}
+
+	out := make([]Candidate, total)
+	for i := range out {
+		c := make(Candidate, len(grids))
+		rest := i
+		for j := len(grids) - 1; j >= 0; j-- {
+			c[j] = grids[j][rest%len(grids[j])]
+			rest /= len(grids[j])
+		}
+		out[i] = c
+	}
+	return out, nil
+}
+
+// Random draws up to n distinct candidates from the grid of params.
+func Random(params []Param, n int, rng *rand.Rand) ([]Candidate, error) {
+	if n <= 0 || n > MaxCandidates {
+		return nil, fmt.Errorf("samples must be between 1 and %d", MaxCandidates)
+	}
+	grids, err := grids(params)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var out []Candidate
+	// Give up after enough repeats that the grid is likely exhausted
+	for tries := 0; len(out) < n && tries < n*20; tries++ {
+		c := make(Candidate, len(grids))
+		for j, g := range grids {
+			c[j] = g[rng.Intn(len(g))]
+		}
+		key := fmt.Sprint(c)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func grids(params []Param) ([][]float64, error) {
+	if len(params) == 0 {
+		return nil, errors.New("at least one param is required")
+	}
+	seen := make(map[string]bool, len(params))
+	out := make([][]float64, len(params))
+	for i, p := range params {
+		if seen[p.Path] {
+			return nil, fmt.Errorf("%s: param listed twice", p.Path)
+		}
+		seen[p.Path] = true
+		g, err := p.Grid()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = g
+	}
+	return out, nil
+}
+
+// Apply decodes base with each param's path set to the candidate's value into
+// dst. A path must name a field that survives the round trip, so typos and
+// paths into missing list items are rejected.
+func Apply[T any](base *T, params []Param, c Candidate, dst *T) error {
+	raw, err := json.Marshal(base)
+	if err != nil {
+		return err
+	}
+	var doc any
+	if err := json.Unmarshal(raw, &doc); err != nil {
+		return err
+	}
+	for i, p := range params {
+		if err := setPath(doc, strings.Split(p.Path, "."), c[i]); err != nil {
+			return fmt.Errorf("%s: %w", p.Path, err)
+		}
+	}
+
+	raw, err = json.Marshal(doc)
+	if err != nil {
+		return err
+	}
+	var out T
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return fmt.Errorf("invalid value: %w", err)
+	}
+
+	// Check every value landed
+	raw, err = json.Marshal(&out)
+	if err != nil {
+		return err
+	}
+	var check any
+	if err := json.Unmarshal(raw, &check); err != nil {
+		return err
+	}
+	for i, p := range params {
+		if v, ok := getPath(check, strings.Split(p.Path, ".")).(float64); !ok || v != c[i] {
+			return fmt.Errorf("%s: not a numeric strategy field", p.Path)
+		}
+	}
+
+	*dst = out
+	return nil
+}
+
+func setPath(node any, path []string, value float64) error {
+	for i, key := range path {
+		last := i == len(path)-1
+		switch n := node.(type) {
+		case map[string]any:
+			if last {
+				n[key] = value
+				return nil
+			}
+			node = n[key]
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(n) {
+				return fmt.Errorf("index %q out of range", key)
+			}
+			if last {
+				n[idx] = value
+				return nil
+			}
+			node = n[idx]
+		default:
+			return fmt.Errorf("no field at %q", key)
+		}
+	}
+	return errors.New("empty path")
+}
+
+func getPath(node any, path []string) any {
+	for _, key := range path {
+		switch n := node.(type) {
+		case map[string]any:
+			node = n[key]
+		case []any:
+			idx, err := strconv.Atoi(key)
+			if err != nil || idx < 0 || idx >= len(n) {
+				return nil
+			}
+			node = n[idx]
+		default:
+			return nil
+		}
+	}
+	return node
+}
+
+// ── Objectives ────────────────────────────────────────────────────────────────
+
+// Objective is the statistic an optimization maximizes.
+type Objective string
+
+const (
+	ObjectiveROI          Objective = "ROI"
+	ObjectiveSharpe       Objective = "SHARPE"
+	ObjectiveSortino      Objective = "SORTINO"
+	ObjectiveCalmar       Objective = "CALMAR"
+	ObjectiveCAGR         Objective = "CAGR"
+	ObjectiveProfitFactor Objective = "PROFIT_FACTOR"
+	ObjectiveMaxDrawdown  Objective = "MAX_DRAWDOWN" // smallest drawdown wins
+)
+
+// Valid reports whether o is a known objective. Empty means ObjectiveSharpe.
+func (o Objective) Valid() bool {
+	switch o {
+	case "", ObjectiveROI, ObjectiveSharpe, ObjectiveSortino, ObjectiveCalmar,
+		ObjectiveCAGR, ObjectiveProfitFactor, ObjectiveMaxDrawdown:
+		return true
+	}
+	return false
+}
+
+// Score is the value to maximize for a run with the given ROI and stats.
+func (o Objective) Score(roi float64, s metrics.Stats) float64 {
+	switch o {
+	case ObjectiveROI:
+		return roi
+	case ObjectiveSortino:
+		return s.Sortino
+	case ObjectiveCalmar:
+		return s.Calmar
+	case ObjectiveCAGR:
+		return s.CAGR
+	case ObjectiveProfitFactor:
+		return s.ProfitFactor
+	case ObjectiveMaxDrawdown:
+		return -s.MaxDrawdown
+	}
+	return s.Sharpe
+}
+
+// ── Walk-Forward ──────────────────────────────────────────────────────────────
+
+// Window is one walk-forward step: parameters are fit on the in-sample range
+// and tested on the out-of-sample range that follows it. Dates are
+// YYYY-MM-DD and inclusive.
+type Window struct {
+	InFrom  string `json:"in_from"`
+	InTo    string `json:"in_to"`
+	OutFrom string `json:"out_from"`
+	OutTo   string `json:"out_to"`
+}
+
+// Windows splits from..to into rolling windows of inDays in-sample and
+// outDays out-of-sample calendar days, stepping by outDays so out-of-sample
+// ranges tile the period without overlap. Anchored windows keep every
+// in-sample range starting at from. The last out-of-sample range is cut at to.
+func Windows(from, to time.Time, inDays, outDays int, anchored bool) ([]Window, error) {
+	if inDays <= 0 || outDays <= 0 {
+		return nil, errors.New("in-sample and out-of-sample lengths must be positive")
+	}
+
+	const day = "2006-01-02"
+	var out []Window
+	for start := from; ; start = start.AddDate(0, 0, outDays) {
+		outFrom := start.AddDate(0, 0, inDays)
+		if outFrom.After(to) {
+			break
+		}
+		outTo := outFrom.AddDate(0, 0, outDays-1)
+		if outTo.After(to) {
+			outTo = to
+		}
+		inFrom := start
+		if anchored {
+			inFrom = from
+		}
+		out = append(out, Window{
+			InFrom:  inFrom.Format(day),
+			InTo:    outFrom.AddDate(0, 0, -1).Format(day),
+			OutFrom: outFrom.Format(day),
+			OutTo:   outTo.Format(day),
+		})
+	}
+	if len(out) == 0 {
+		return nil, errors.New("the date range is shorter than one in-sample window")
+	}
+	return out, nil
+}
+
+// Stability summarizes the values one parameter took across windows.
+type Stability struct {
+	Path    string  `json:"path"`
+	Mean    float64 `json:"mean"`
+	StdDev  float64 `json:"std_dev"`
+	Min     float64 `json:"min"`
+	Max     float64 `json:"max"`
+	Changes int     `json:"changes"` // windows whose value differs from the window before
+}
+
+// ParamStability reports how much each parameter moved across the chosen
+// candidates, one per window. Stable parameters suggest a robust fit.
+func ParamStability(params []Param, chosen []Candidate) []Stability {
+	out := make([]Stability, len(params))
+	for i, p := range params {
+		s := Stability{Path: p.Path}
+		values := make([]float64, len(chosen))
+		for w, c := range chosen {
+			values[w] = c[i]
+			if w == 0 {
+				s.Min, s.Max = c[i], c[i]
+				continue
+			}
+			s.Min, s.Max = math.Min(s.Min, c[i]), math.Max(s.Max, c[i])
+			if c[i] != chosen[w-1][i] {
+				s.Changes++
+			}
+		}
+		s.Mean = metrics.Mean(values)
+		s.StdDev = metrics.StdDev(values)
+		out[i] = s
+	}
+	return out
+}
@@ -0,0 +1,156 @@
+package optimize
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParamGrid(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Param  Param
+		Values []float64
+		Err    bool
+	}{
+		{Name: "Range", Param: Param{Path: "a", Min: 10, Max: 20, Step: 5}, Values: []float64{10, 15, 20}},
+		{Name: "Fractional step", Param: Param{Path: "a", Min: 0.1, Max: 0.3, Step: 0.1}, Values: []float64{0.1, 0.2, 0.3}},
+		{Name: "Explicit values", Param: Param{Path: "a", Values: []float64{7, 3}}, Values: []float64{7, 3}},
+		{Name: "Missing step", Param: Param{Path: "a", Min: 1, Max: 2}, Err: true},
+		{Name: "Missing path", Param: Param{Values: []float64{1}}, Err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			values, err := tt.Param.Grid()
+			if tt.Err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.Values, values)
+		})
+	}
+}
+
+func TestGridAndRandom(t *testing.T) {
+	params := []Param{
+		{Path: "a", Values: []float64{1, 2}},
+		{Path: "b", Values: []float64{10, 20, 30}},
+	}
+
+	grid, err := Grid(params)
+	assert.NoError(t, err)
+	assert.Len(t, grid, 6)
+	assert.Equal(t, Candidate{1, 10}, grid[0])
+	assert.Equal(t, Candidate{2, 30}, grid[5])
+
+	sampled, err := Random(params, 100, rand.New(rand.NewSource(1)))
+	assert.NoError(t, err)
+	assert.Len(t, sampled, 6) // grid exhausted
+
+	_, err = Grid([]Param{{Path: "a", Min: 0, Max: 1000, Step: 1}, {Path: "b", Min: 0, Max: 1000, Step: 1}})
+	assert.Error(t, err)
+}
+
+type doc struct {
+	Window int     `json:"window"`
+	Rules  []rule  `json:"rules"`
+	Skip   float64 `json:"skip,omitempty"`
+}
+
+type rule struct {
+	Value float64 `json:"value"`
+}
+
+func TestApply(t *testing.T) {
+	base := &doc{Window: 5, Rules: []rule{{Value: 30}}}
+
+	tests := []struct {
+		Name   string
+		Params []Param
+		Values Candidate
+		Want   doc
+		Err    bool
+	}{
+		{
+			Name:   "Sets nested and top-level fields",
+			Params: []Param{{Path: "window"}, {Path: "rules.0.value"}},
+			Values: Candidate{14, 25},
+			Want:   doc{Window: 14, Rules: []rule{{Value: 25}}},
+		},
+		{Name: "Unknown field", Params: []Param{{Path: "windw"}}, Values: Candidate{14}, Err: true},
+		{Name: "Index out of range", Params: []Param{{Path: "rules.3.value"}}, Values: Candidate{1}, Err: true},
+		{Name: "Fraction into an int", Params: []Param{{Path: "window"}}, Values: Candidate{2.5}, Err: true},
+		{Name: "Omitted field can be set", Params: []Param{{Path: "skip"}}, Values: Candidate{3}, Want: doc{Window: 5, Rules: []rule{{Value: 30}}, Skip: 3}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			var got doc
+			err := Apply(base, tt.Params, tt.Values, &got)
+			if tt.Err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.Want, got)
+		})
+	}
+	assert.Equal(t, 30.0, base.Rules[0].Value) // base untouched
+}
+
+func TestWindows(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 1, 25, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		Name     string
+		Anchored bool
+		Want     []Window
+	}{
+		{
+			Name: "Rolling",
+			Want: []Window{
+				{InFrom: "2024-01-01", InTo: "2024-01-10", OutFrom: "2024-01-11", OutTo: "2024-01-15"},
+				{InFrom: "2024-01-06", InTo: "2024-01-15", OutFrom: "2024-01-16", OutTo: "2024-01-20"},
+				{InFrom: "2024-01-11", InTo: "2024-01-20", OutFrom: "2024-01-21", OutTo: "2024-01-25"},
+			},
+		},
+		{
+			Name:     "Anchored",
+			Anchored: true,
+			Want: []Window{
+				{InFrom: "2024-01-01", InTo: "2024-01-10", OutFrom: "2024-01-11", OutTo: "2024-01-15"},
+				{InFrom: "2024-01-01", InTo: "2024-01-15", OutFrom: "2024-01-16", OutTo: "2024-01-20"},
+				{InFrom: "2024-01-01", InTo: "2024-01-20", OutFrom: "2024-01-21", OutTo: "2024-01-25"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			windows, err := Windows(from, to, 10, 5, tt.Anchored)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.Want, windows)
+		})
+	}
+
+	_, err := Windows(from, to, 30, 5, false)
+	assert.Error(t, err)
+}
+
+func TestParamStability(t *testing.T) {
+	params := []Param{{Path: "a"}, {Path: "b"}}
+	chosen := []Candidate{{14, 5}, {14, 10}, {14, 5}}
+
+	s := ParamStability(params, chosen)
+
+	assert.Equal(t, Stability{Path: "a", Mean: 14, Min: 14, Max: 14}, s[0])
+	assert.Equal(t, 2, s[1].Changes)
+	assert.Equal(t, 5.0, s[1].Min)
+	assert.Equal(t, 10.0, s[1].Max)
+	assert.Greater(t, s[1].StdDev, 0.0)
+}
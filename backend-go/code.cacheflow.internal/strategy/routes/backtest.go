@@ -1,28 +1,31 @@
 package routes
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"io"
-	"math"
 	"net/http"
 	"strings"
 	"time"
 
 	accountEntities "code.cacheflow.internal/account/entities"
+	"code.cacheflow.internal/datafeed"
+	"code.cacheflow.internal/datafeed/indicators"
 	datastores "code.cacheflow.internal/datastores/mongo"
 	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/strategy/sizing"
 	"code.cacheflow.internal/util/httpx"
-	"code.cacheflow.internal/util/secrets"
 
 	"github.com/pborman/uuid"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
-const massiveBase = "https://api.massive.com"
-
-// ── Helpers: Massive API calls ────────────────────────────────────────────────
+// ── Helpers: market data ──────────────────────────────────────────────────────
 
+// dailyBar is one bar of a strategy's timeframe. Date keys it: YYYY-MM-DD for
+// daily bars, "YYYY-MM-DD HH:MM" in exchange time for intraday ones, so keys
+// sort in time order either way.
 type dailyBar struct {
 	Date  string
 	Open  float64
@@ -30,96 +33,84 @@ type dailyBar struct {
 	Low   float64
 	Close float64
 	Vol   float64
-	VWAP  float64 // vw field from Massive
+	VWAP  float64 // vw field from the provider
 	TsMs  int64
 }
 
+// intradayKeyLayout formats the Date of intraday bars.
+const intradayKeyLayout = "2006-01-02 15:04"
+
 func msToDate(ms int64) string {
 	return time.UnixMilli(ms).UTC().Format("2006-01-02")
 }
 
-func fetchDailyBars(ctx *http.Request, ticker, from, to string) ([]dailyBar, error) {
-	url := fmt.Sprintf("%s/v2/aggs/ticker/%s/range/1/day/%s/%s?apiKey=%s&sort=asc&limit=5000&adjusted=true",
-		massiveBase, ticker, from, to, secrets.MassiveMainApiKeyValue)
-
-	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-
-	var raw struct {
-		Results []struct {
-			T  int64   `json:"t"`
-			O  float64 `json:"o"`
-			H  float64 `json:"h"`
-			L  float64 `json:"l"`
-			C  float64 `json:"c"`
-			V  float64 `json:"v"`
-			VW float64 `json:"vw"`
-		} `json:"results"`
-	}
-	if err := json.Unmarshal(body, &raw); err != nil {
-		return nil, err
+// barKey is the Date of a bar of timeframe tf opening at ms.
+func barKey(ms int64, tf strategyEntities.Timeframe) string {
+	if tf.Intraday() {
+		return time.UnixMilli(ms).In(marketZone).Format(intradayKeyLayout)
 	}
+	return msToDate(ms)
+}
 
-	bars := make([]dailyBar, 0, len(raw.Results))
-	for _, r := range raw.Results {
-		bars = append(bars, dailyBar{
-			Date:  msToDate(r.T),
-			Open:  r.O,
-			High:  r.H,
-			Low:   r.L,
-			Close: r.C,
-			Vol:   r.V,
-			VWAP:  r.VW,
-			TsMs:  r.T,
-		})
+// sessionDate is the trading day a bar key falls on.
+func sessionDate(key string) string {
+	if len(key) > len("2006-01-02") {
+		return key[:len("2006-01-02")]
 	}
-	return bars, nil
+	return key
+}
+
+// inSession reports whether an intraday bar of length d opening at ms
+// overlaps the regular session, 09:30 to 16:00 exchange time.
+func inSession(ms int64, d time.Duration) bool {
+	t := time.UnixMilli(ms).In(marketZone)
+	open := time.Date(t.Year(), t.Month(), t.Day(), 9, 30, 0, 0, marketZone)
+	end := time.Date(t.Year(), t.Month(), t.Day(), 16, 0, 0, 0, marketZone)
+	return t.Before(end) && t.Add(d).After(open)
 }
 
-// fetchSimpleIndicator fetches RSI / EMA / SMA values and returns date→value map.
-func fetchSimpleIndicator(ctx *http.Request, kind, ticker, from, to string, window int) (map[string]float64, error) {
-	url := fmt.Sprintf(
-		"%s/v1/indicators/%s/%s?timespan=day&window=%d&order=asc&limit=5000&adjusted=true&timestamp.gte=%s&timestamp.lte=%s&apiKey=%s",
-		massiveBase, kind, ticker, window, from, to, secrets.MassiveMainApiKeyValue)
+func fetchDailyBars(ctx context.Context, provider datafeed.MarketDataProvider, ticker, from, to string) ([]dailyBar, error) {
+	return fetchBars(ctx, provider, ticker, strategyEntities.TimeframeDaily, from, to)
+}
 
-	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+// fetchBars loads ticker's bars of timeframe tf from one date to another.
+// Intraday timeframes keep only the bars that overlap the regular session.
+func fetchBars(ctx context.Context, provider datafeed.MarketDataProvider, ticker string, tf strategyEntities.Timeframe, from, to string) ([]dailyBar, error) {
+	multiplier, timespan := tf.Bars()
+	raw, err := provider.Bars(ctx, datafeed.BarsRequest{
+		Ticker:     ticker,
+		Multiplier: multiplier,
+		Timespan:   timespan,
+		From:       from,
+		To:         to,
+		Adjusted:   true,
+	})
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
 
-	var raw struct {
-		Results struct {
-			Values []struct {
-				Timestamp int64   `json:"timestamp"`
-				Value     float64 `json:"value"`
-			} `json:"values"`
-		} `json:"results"`
-	}
-	if err := json.Unmarshal(body, &raw); err != nil {
-		return nil, err
-	}
-
-	m := make(map[string]float64, len(raw.Results.Values))
-	for _, v := range raw.Results.Values {
-		m[msToDate(v.Timestamp)] = v.Value
+	bars := make([]dailyBar, 0, len(raw))
+	for _, r := range raw {
+		if tf.Intraday() && !inSession(r.Timestamp, tf.Duration()) {
+			continue
+		}
+		vwap := r.VWAP
+		if vwap == 0 {
+			// Fall back to the bar's typical price when the provider has no VWAP
+			vwap = (r.High + r.Low + r.Close) / 3
+		}
+		bars = append(bars, dailyBar{
+			Date:  barKey(r.Timestamp, tf),
+			Open:  r.Open,
+			High:  r.High,
+			Low:   r.Low,
+			Close: r.Close,
+			Vol:   r.Volume,
+			VWAP:  vwap,
+			TsMs:  r.Timestamp,
+		})
 	}
-	return m, nil
+	return bars, nil
 }
 
 type macdPoint struct {
@@ -128,44 +119,6 @@ type macdPoint struct {
 	Histogram float64
 }
 
-func fetchMACDIndicator(ctx *http.Request, ticker, from, to string, fast, slow, signal int) (map[string]macdPoint, error) {
-	url := fmt.Sprintf(
-		"%s/v1/indicators/macd/%s?timespan=day&fast_period=%d&slow_period=%d&signal_period=%d&order=asc&limit=5000&adjusted=true&timestamp.gte=%s&timestamp.lte=%s&apiKey=%s",
-		massiveBase, ticker, fast, slow, signal, from, to, secrets.MassiveMainApiKeyValue)
-
-	req, err := http.NewRequestWithContext(ctx.Context(), http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
-	}
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
-	body, _ := io.ReadAll(resp.Body)
-
-	var raw struct {
-		Results struct {
-			Values []struct {
-				Timestamp int64   `json:"timestamp"`
-				Value     float64 `json:"value"`
-				Signal    float64 `json:"signal"`
-				Histogram float64 `json:"histogram"`
-			} `json:"values"`
-		} `json:"results"`
-	}
-	if err := json.Unmarshal(body, &raw); err != nil {
-		return nil, err
-	}
-
-	m := make(map[string]macdPoint, len(raw.Results.Values))
-	for _, v := range raw.Results.Values {
-		m[msToDate(v.Timestamp)] = macdPoint{Value: v.Value, Signal: v.Signal, Histogram: v.Histogram}
-	}
-	return m, nil
-}
-
 // ── Backtest Engine ───────────────────────────────────────────────────────────
 
 type indicatorStore struct {
@@ -191,120 +144,317 @@ func (s *indicatorStore) getMACD(key, date string) (macdPoint, bool) {
 	return macdPoint{}, false
 }
 
-func loadIndicators(req *http.Request, strategy *strategyEntities.StrategyEntity, from, to string) (*indicatorStore, error) {
+// strategyRules gathers entry and exit tree leaves of both sides plus
+// indicator-based sell conditions.
+func strategyRules(strategy *strategyEntities.StrategyEntity) []strategyEntities.Rule {
+	allRules := strategy.Entry().Rules()
+	allRules = append(allRules, strategy.ExitRules.Rules()...)
+	allRules = append(allRules, strategy.ShortEntryRules.Rules()...)
+	allRules = append(allRules, strategy.ShortExitRules.Rules()...)
+	for _, sc := range strategy.SellConditions {
+		if sc.Type == strategyEntities.SellIndicator && sc.Rule != nil {
+			allRules = append(allRules, *sc.Rule)
+		}
+	}
+	return allRules
+}
+
+// strategyTimeframe is the timeframe the strategy trades, daily by default.
+func strategyTimeframe(strategy *strategyEntities.StrategyEntity) strategyEntities.Timeframe {
+	if strategy.Timeframe == "" {
+		return strategyEntities.TimeframeDaily
+	}
+	return strategy.Timeframe
+}
+
+// ruleTimeframe is the timeframe rule reads.
+func ruleTimeframe(strategy *strategyEntities.StrategyEntity, rule strategyEntities.Rule) strategyEntities.Timeframe {
+	if rule.Timeframe == "" {
+		return strategyTimeframe(strategy)
+	}
+	return rule.Timeframe
+}
+
+// rulesOn returns the strategy's rules that read timeframe tf.
+func rulesOn(strategy *strategyEntities.StrategyEntity, tf strategyEntities.Timeframe) []strategyEntities.Rule {
+	var rules []strategyEntities.Rule
+	for _, rule := range strategyRules(strategy) {
+		if ruleTimeframe(strategy, rule) == tf {
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
+// ruleTimeframes lists the timeframes other than its own that the strategy's
+// rules read, in a stable order.
+func ruleTimeframes(strategy *strategyEntities.StrategyEntity) []strategyEntities.Timeframe {
+	seen := map[strategyEntities.Timeframe]bool{strategyTimeframe(strategy): true}
+	var out []strategyEntities.Timeframe
+	for _, rule := range strategyRules(strategy) {
+		if tf := ruleTimeframe(strategy, rule); !seen[tf] {
+			seen[tf] = true
+			out = append(out, tf)
+		}
+	}
+	return out
+}
+
+func macdParams(rule strategyEntities.Rule) (fast, slow, sig int) {
+	fast, slow, sig = rule.FastPeriod, rule.SlowPeriod, rule.SignalPeriod
+	if fast == 0 {
+		fast = 12
+	}
+	if slow == 0 {
+		slow = 26
+	}
+	if sig == 0 {
+		sig = 9
+	}
+	return fast, slow, sig
+}
+
+func bbParams(rule strategyEntities.Rule) (window int, k float64) {
+	window, k = windowOr(rule.Window, 20), rule.StdDev
+	if k == 0 {
+		k = 2
+	}
+	return window, k
+}
+
+func stochParams(rule strategyEntities.Rule) (kPeriod, smoothing, dPeriod int) {
+	return windowOr(rule.KPeriod, 14), windowOr(rule.KSmoothing, 3), windowOr(rule.DPeriod, 3)
+}
+
+// ruleLookback is the number of warm-up bars a rule needs before its first
+// evaluation, plus one so that crossovers can compare against the prior bar.
+func ruleLookback(rule strategyEntities.Rule) int {
+	switch rule.Type {
+	case strategyEntities.RuleRSICrossAbove, strategyEntities.RuleRSICrossBelow,
+		strategyEntities.RuleRSIAbove, strategyEntities.RuleRSIBelow:
+		return indicators.RSILookback(windowOr(rule.Window, 14)) + 1
+	case strategyEntities.RuleEMACrossAbove, strategyEntities.RuleEMACrossBelow:
+		return indicators.EMALookback(max(rule.FastWindow, rule.SlowWindow)) + 1
+	case strategyEntities.RuleSMACrossAbove, strategyEntities.RuleSMACrossBelow:
+		return indicators.SMALookback(max(rule.FastWindow, rule.SlowWindow)) + 1
+	case strategyEntities.RulePriceAboveEMA, strategyEntities.RulePriceBelowEMA:
+		return indicators.EMALookback(windowOr(rule.Window, 9))
+	case strategyEntities.RulePriceAboveSMA, strategyEntities.RulePriceBelowSMA:
+		return indicators.SMALookback(windowOr(rule.Window, 20))
+	case strategyEntities.RuleMACDCrossSignalAbove, strategyEntities.RuleMACDCrossSignalBelow,
+		strategyEntities.RuleMACDAboveZero, strategyEntities.RuleMACDBelowZero:
+		return indicators.MACDLookback(macdParams(rule)) + 1
+	case strategyEntities.RuleBBTouchUpper, strategyEntities.RuleBBTouchLower,
+		strategyEntities.RuleBBBreakoutAbove, strategyEntities.RuleBBBreakoutBelow:
+		w, _ := bbParams(rule)
+		return indicators.BollingerLookback(w) + 1
+	case strategyEntities.RuleATRAbovePct, strategyEntities.RuleATRBelowPct:
+		return indicators.ATRLookback(windowOr(rule.Window, 14))
+	case strategyEntities.RuleStochCrossAbove, strategyEntities.RuleStochCrossBelow:
+		return indicators.StochasticLookback(stochParams(rule)) + 1
+	case strategyEntities.RuleADXAbove, strategyEntities.RuleADXBelow:
+		return indicators.ADXLookback(windowOr(rule.Window, 14))
+	case strategyEntities.RuleOBVBullishDivergence, strategyEntities.RuleOBVBearishDivergence:
+		return indicators.OBVLookback(windowOr(rule.Window, 20))
+	case strategyEntities.RuleDonchianBreakAbove, strategyEntities.RuleDonchianBreakBelow:
+		return indicators.DonchianLookback(windowOr(rule.Window, 20))
+	}
+	return 1
+}
+
+func windowOr(w, def int) int {
+	if w == 0 {
+		return def
+	}
+	return w
+}
+
+// frameLookback is the warm-up, in bars of tf, needed by the most demanding
+// rule reading that timeframe.
+func frameLookback(strategy *strategyEntities.StrategyEntity, tf strategyEntities.Timeframe) int {
+	lookback := 1
+	for _, rule := range rulesOn(strategy, tf) {
+		lookback = max(lookback, ruleLookback(rule))
+	}
+	return lookback
+}
+
+// strategyLookback is the warm-up needed on the strategy's own timeframe by
+// the most demanding rule, sell condition or the sizing model. Holding-period
+// exits count the bars back to the entry, so live evaluation needs that many
+// on hand.
+func strategyLookback(strategy *strategyEntities.StrategyEntity) int {
+	lookback := frameLookback(strategy, strategyTimeframe(strategy))
+	for _, sc := range strategy.SellConditions {
+		switch sc.Type {
+		case strategyEntities.SellATRStop, strategyEntities.SellChandelier:
+			lookback = max(lookback, indicators.ATRLookback(windowOr(sc.ATRWindow, 14)))
+		case strategyEntities.SellMaxHolding:
+			lookback = max(lookback, sc.Bars+1)
+		}
+	}
+	if w := sizing.ATRWindow(strategy.PositionSizing); w > 0 {
+		lookback = max(lookback, indicators.ATRLookback(w))
+	}
+	return lookback
+}
+
+// putSeries stores the computed values of a series by bar date.
+func (s *indicatorStore) putSeries(key string, bars []dailyBar, series []float64) {
+	m := make(map[string]float64, len(bars))
+	for i, b := range bars {
+		if indicators.Valid(series[i]) {
+			m[b.Date] = series[i]
+		}
+	}
+	s.simple[key] = m
+}
+
+// loadIndicators computes every indicator the strategy's rules on timeframe tf
+// reference from bars of that timeframe, which should include the warm-up
+// history. On the strategy's own timeframe it adds what sizing and sell
+// conditions read.
+func loadIndicators(strategy *strategyEntities.StrategyEntity, tf strategyEntities.Timeframe, bars []dailyBar) *indicatorStore {
 	store := &indicatorStore{
 		simple: make(map[string]map[string]float64),
 		macd:   make(map[string]map[string]macdPoint),
 	}
 
-	// Gather all rules including indicator-based sell conditions
-	allRules := make([]strategyEntities.Rule, len(strategy.BuyRules))
-	copy(allRules, strategy.BuyRules)
-	for _, sc := range strategy.SellConditions {
-		if sc.Type == strategyEntities.SellIndicator && sc.Rule != nil {
-			allRules = append(allRules, *sc.Rule)
+	closes := make([]float64, len(bars))
+	highs := make([]float64, len(bars))
+	lows := make([]float64, len(bars))
+	volumes := make([]float64, len(bars))
+	for i, b := range bars {
+		closes[i], highs[i], lows[i], volumes[i] = b.Close, b.High, b.Low, b.Vol
+	}
+	store.putSeries("CLOSE", bars, closes)
+
+	addSimple := func(kind string, w int, compute func([]float64, int) []float64) {
+		key := fmt.Sprintf("%s_%d", kind, w)
+		if _, ok := store.simple[key]; !ok {
+			store.putSeries(key, bars, compute(closes, w))
 		}
 	}
 
-	for _, rule := range allRules {
+	for _, rule := range rulesOn(strategy, tf) {
 		switch rule.Type {
 		case strategyEntities.RuleRSICrossAbove, strategyEntities.RuleRSICrossBelow,
 			strategyEntities.RuleRSIAbove, strategyEntities.RuleRSIBelow:
-			w := rule.Window
-			if w == 0 {
-				w = 14
-			}
-			key := fmt.Sprintf("RSI_%d", w)
-			if _, ok := store.simple[key]; !ok {
-				vals, err := fetchSimpleIndicator(req, "rsi", strategy.Ticker, from, to, w)
-				if err != nil {
-					return nil, fmt.Errorf("fetch RSI_%d: %w", w, err)
-				}
-				store.simple[key] = vals
-			}
+			addSimple("RSI", windowOr(rule.Window, 14), indicators.RSI)
 
 		case strategyEntities.RuleEMACrossAbove, strategyEntities.RuleEMACrossBelow:
 			for _, w := range []int{rule.FastWindow, rule.SlowWindow} {
-				if w == 0 {
-					continue
-				}
-				key := fmt.Sprintf("EMA_%d", w)
-				if _, ok := store.simple[key]; !ok {
-					vals, err := fetchSimpleIndicator(req, "ema", strategy.Ticker, from, to, w)
-					if err != nil {
-						return nil, fmt.Errorf("fetch EMA_%d: %w", w, err)
-					}
-					store.simple[key] = vals
+				if w != 0 {
+					addSimple("EMA", w, indicators.EMA)
 				}
 			}
 
 		case strategyEntities.RuleSMACrossAbove, strategyEntities.RuleSMACrossBelow:
 			for _, w := range []int{rule.FastWindow, rule.SlowWindow} {
-				if w == 0 {
-					continue
-				}
-				key := fmt.Sprintf("SMA_%d", w)
-				if _, ok := store.simple[key]; !ok {
-					vals, err := fetchSimpleIndicator(req, "sma", strategy.Ticker, from, to, w)
-					if err != nil {
-						return nil, fmt.Errorf("fetch SMA_%d: %w", w, err)
-					}
-					store.simple[key] = vals
+				if w != 0 {
+					addSimple("SMA", w, indicators.SMA)
 				}
 			}
 
 		case strategyEntities.RulePriceAboveEMA, strategyEntities.RulePriceBelowEMA:
-			w := rule.Window
-			if w == 0 {
-				w = 9
+			addSimple("EMA", windowOr(rule.Window, 9), indicators.EMA)
+
+		case strategyEntities.RulePriceAboveSMA, strategyEntities.RulePriceBelowSMA:
+			addSimple("SMA", windowOr(rule.Window, 20), indicators.SMA)
+
+		case strategyEntities.RuleMACDCrossSignalAbove, strategyEntities.RuleMACDCrossSignalBelow,
+			strategyEntities.RuleMACDAboveZero, strategyEntities.RuleMACDBelowZero:
+			fast, slow, sig := macdParams(rule)
+			key := fmt.Sprintf("MACD_%d_%d_%d", fast, slow, sig)
+			if _, ok := store.macd[key]; ok {
+				continue
 			}
-			key := fmt.Sprintf("EMA_%d", w)
-			if _, ok := store.simple[key]; !ok {
-				vals, err := fetchSimpleIndicator(req, "ema", strategy.Ticker, from, to, w)
-				if err != nil {
-					return nil, fmt.Errorf("fetch EMA_%d: %w", w, err)
+			line, signal, hist := indicators.MACD(closes, fast, slow, sig)
+			m := make(map[string]macdPoint, len(bars))
+			for i, b := range bars {
+				if indicators.Valid(hist[i]) {
+					m[b.Date] = macdPoint{Value: line[i], Signal: signal[i], Histogram: hist[i]}
 				}
-				store.simple[key] = vals
 			}
-
-		case strategyEntities.RulePriceAboveSMA, strategyEntities.RulePriceBelowSMA:
-			w := rule.Window
-			if w == 0 {
-				w = 20
+			store.macd[key] = m
+
+		case strategyEntities.RuleBBTouchUpper, strategyEntities.RuleBBTouchLower,
+			strategyEntities.RuleBBBreakoutAbove, strategyEntities.RuleBBBreakoutBelow:
+			w, k := bbParams(rule)
+			key := fmt.Sprintf("%d_%g", w, k)
+			if _, ok := store.simple["BBU_"+key]; !ok {
+				_, upper, lower := indicators.Bollinger(closes, w, k)
+				store.putSeries("BBU_"+key, bars, upper)
+				store.putSeries("BBL_"+key, bars, lower)
 			}
-			key := fmt.Sprintf("SMA_%d", w)
+
+		case strategyEntities.RuleATRAbovePct, strategyEntities.RuleATRBelowPct:
+			w := windowOr(rule.Window, 14)
+			key := fmt.Sprintf("ATR_%d", w)
 			if _, ok := store.simple[key]; !ok {
-				vals, err := fetchSimpleIndicator(req, "sma", strategy.Ticker, from, to, w)
-				if err != nil {
-					return nil, fmt.Errorf("fetch SMA_%d: %w", w, err)
-				}
-				store.simple[key] = vals
+				store.putSeries(key, bars, indicators.ATR(highs, lows, closes, w))
 			}
 
-		case strategyEntities.RuleMACDCrossSignalAbove, strategyEntities.RuleMACDCrossSignalBelow,
-			strategyEntities.RuleMACDAboveZero, strategyEntities.RuleMACDBelowZero:
-			fast, slow, sig := rule.FastPeriod, rule.SlowPeriod, rule.SignalPeriod
-			if fast == 0 {
-				fast = 12
+		case strategyEntities.RuleStochCrossAbove, strategyEntities.RuleStochCrossBelow:
+			kp, sm, dp := stochParams(rule)
+			key := fmt.Sprintf("%d_%d_%d", kp, sm, dp)
+			if _, ok := store.simple["STOCHK_"+key]; !ok {
+				k, d := indicators.Stochastic(highs, lows, closes, kp, sm, dp)
+				store.putSeries("STOCHK_"+key, bars, k)
+				store.putSeries("STOCHD_"+key, bars, d)
 			}
-			if slow == 0 {
-				slow = 26
+
+		case strategyEntities.RuleADXAbove, strategyEntities.RuleADXBelow:
+			w := windowOr(rule.Window, 14)
+			key := fmt.Sprintf("ADX_%d", w)
+			if _, ok := store.simple[key]; !ok {
+				adx, _, _ := indicators.ADX(highs, lows, closes, w)
+				store.putSeries(key, bars, adx)
 			}
-			if sig == 0 {
-				sig = 9
+
+		case strategyEntities.RuleOBVBullishDivergence, strategyEntities.RuleOBVBearishDivergence:
+			w := windowOr(rule.Window, 20)
+			key := fmt.Sprintf("OBVDIV_%d", w)
+			if _, ok := store.simple[key]; !ok {
+				store.putSeries(key, bars, indicators.OBVDivergence(closes, volumes, w))
 			}
-			key := fmt.Sprintf("MACD_%d_%d_%d", fast, slow, sig)
-			if _, ok := store.macd[key]; !ok {
-				vals, err := fetchMACDIndicator(req, strategy.Ticker, from, to, fast, slow, sig)
-				if err != nil {
-					return nil, fmt.Errorf("fetch MACD: %w", err)
-				}
-				store.macd[key] = vals
+
+		case strategyEntities.RuleDonchianBreakAbove, strategyEntities.RuleDonchianBreakBelow:
+			w := windowOr(rule.Window, 20)
+			key := fmt.Sprintf("%d", w)
+			if _, ok := store.simple["DCU_"+key]; !ok {
+				upper, lower := indicators.Donchian(highs, lows, w)
+				store.putSeries("DCU_"+key, bars, upper)
+				store.putSeries("DCL_"+key, bars, lower)
 			}
 		}
 	}
 
-	return store, nil
+	if tf != strategyTimeframe(strategy) {
+		return store
+	}
+
+	// Volatility-targeted sizing reads ATR at entry
+	if w := sizing.ATRWindow(strategy.PositionSizing); w > 0 {
+		key := fmt.Sprintf("ATR_%d", w)
+		if _, ok := store.simple[key]; !ok {
+			store.putSeries(key, bars, indicators.ATR(highs, lows, closes, w))
+		}
+	}
+
+	// So do ATR stops and chandelier exits
+	for _, sc := range strategy.SellConditions {
+		if sc.Type != strategyEntities.SellATRStop && sc.Type != strategyEntities.SellChandelier {
+			continue
+		}
+		w := windowOr(sc.ATRWindow, 14)
+		key := fmt.Sprintf("ATR_%d", w)
+		if _, ok := store.simple[key]; !ok {
+			store.putSeries(key, bars, indicators.ATR(highs, lows, closes, w))
+		}
+	}
+
+	return store
 }
 
 // evaluateRule checks a single rule against the current and previous bar.
@@ -444,10 +594,7 @@ func evaluateRule(rule strategyEntities.Rule, bar *dailyBar, prevDate string, st
 		if !hasPrev {
 			return false
 		}
-		fast, slow, sig := rule.FastPeriod, rule.SlowPeriod, rule.SignalPeriod
-		if fast == 0 {
-			fast, slow, sig = 12, 26, 9
-		}
+		fast, slow, sig := macdParams(rule)
 		key := fmt.Sprintf("MACD_%d_%d_%d", fast, slow, sig)
 		curr, ok1 := store.getMACD(key, bar.Date)
 		prev, ok2 := store.getMACD(key, prevDate)
@@ -457,29 +604,20 @@ func evaluateRule(rule strategyEntities.Rule, bar *dailyBar, prevDate string, st
 		if !hasPrev {
 			return false
 		}
-		fast, slow, sig := rule.FastPeriod, rule.SlowPeriod, rule.SignalPeriod
-		if fast == 0 {
-			fast, slow, sig = 12, 26, 9
-		}
+		fast, slow, sig := macdParams(rule)
 		key := fmt.Sprintf("MACD_%d_%d_%d", fast, slow, sig)
 		curr, ok1 := store.getMACD(key, bar.Date)
 		prev, ok2 := store.getMACD(key, prevDate)
 		return ok1 && ok2 && prev.Value >= prev.Signal && curr.Value < curr.Signal
 
 	case strategyEntities.RuleMACDAboveZero:
-		fast, slow, sig := rule.FastPeriod, rule.SlowPeriod, rule.SignalPeriod
-		if fast == 0 {
-			fast, slow, sig = 12, 26, 9
-		}
+		fast, slow, sig := macdParams(rule)
 		key := fmt.Sprintf("MACD_%d_%d_%d", fast, slow, sig)
 		p, ok := store.getMACD(key, bar.Date)
 		return ok && p.Histogram > 0
 
 	case strategyEntities.RuleMACDBelowZero:
-		fast, slow, sig := rule.FastPeriod, rule.SlowPeriod, rule.SignalPeriod
-		if fast == 0 {
-			fast, slow, sig = 12, 26, 9
-		}
+		fast, slow, sig := macdParams(rule)
 		key := fmt.Sprintf("MACD_%d_%d_%d", fast, slow, sig)
 		p, ok := store.getMACD(key, bar.Date)
 		return ok && p.Histogram < 0
@@ -500,199 +638,139 @@ func evaluateRule(rule strategyEntities.Rule, bar *dailyBar, prevDate string, st
 		dev := rule.VWAPDeviation
 		threshold := bar.VWAP * (1 - dev/100)
 		return bar.Close < threshold
-	}
 
-	return false
-}
+	// ── Bollinger Bands ──────────────────────────────────────────────────────
+	case strategyEntities.RuleBBTouchUpper:
+		w, k := bbParams(rule)
+		upper, ok := store.get(fmt.Sprintf("BBU_%d_%g", w, k), bar.Date)
+		return ok && bar.High >= upper
 
-func allBuyRulesMet(rules []strategyEntities.Rule, bar *dailyBar, prevDate string, store *indicatorStore) bool {
-	if len(rules) == 0 {
-		return false
-	}
-	for _, rule := range rules {
-		if !evaluateRule(rule, bar, prevDate, store) {
+	case strategyEntities.RuleBBTouchLower:
+		w, k := bbParams(rule)
+		lower, ok := store.get(fmt.Sprintf("BBL_%d_%g", w, k), bar.Date)
+		return ok && bar.Low <= lower
+
+	case strategyEntities.RuleBBBreakoutAbove:
+		if !hasPrev {
 			return false
 		}
-	}
-	return true
-}
+		w, k := bbParams(rule)
+		key := fmt.Sprintf("BBU_%d_%g", w, k)
+		curr, ok1 := store.get(key, bar.Date)
+		prev, ok2 := store.get(key, prevDate)
+		prevClose, ok3 := store.get("CLOSE", prevDate)
+		return ok1 && ok2 && ok3 && prevClose <= prev && bar.Close > curr
 
-type backtestResult struct {
-	FinalBalance  float64
-	ROI           float64
-	TotalTrades   int
-	WinningTrades int
-	LosingTrades  int
-	MaxDrawdown   float64
-	Trades        []strategyEntities.BacktestTrade
-}
-
-func runBacktestEngine(req *http.Request, strategy *strategyEntities.StrategyEntity, ticker, from, to string, initialBalance float64) (*backtestResult, error) {
-	bars, err := fetchDailyBars(req, ticker, from, to)
-	if err != nil {
-		return nil, fmt.Errorf("failed to fetch bars: %w", err)
-	}
-	if len(bars) == 0 {
-		return nil, fmt.Errorf("no price data for %s between %s and %s", ticker, from, to)
-	}
-
-	store, err := loadIndicators(req, strategy, from, to)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load indicators: %w", err)
-	}
-
-	cash := initialBalance
-	var shares float64
-	var buyPrice float64
-	var peakPrice float64
-	var trades []strategyEntities.BacktestTrade
+	case strategyEntities.RuleBBBreakoutBelow:
+		if !hasPrev {
+			return false
+		}
+		w, k := bbParams(rule)
+		key := fmt.Sprintf("BBL_%d_%g", w, k)
+		curr, ok1 := store.get(key, bar.Date)
+		prev, ok2 := store.get(key, prevDate)
+		prevClose, ok3 := store.get("CLOSE", prevDate)
+		return ok1 && ok2 && ok3 && prevClose >= prev && bar.Close < curr
 
-	peakEquity := initialBalance
-	maxDrawdown := 0.0
+	// ── ATR volatility filter ────────────────────────────────────────────────
+	case strategyEntities.RuleATRAbovePct:
+		atr, ok := store.get(fmt.Sprintf("ATR_%d", windowOr(rule.Window, 14)), bar.Date)
+		return ok && bar.Close > 0 && atr/bar.Close*100 > rule.Value
 
-	for i, bar := range bars {
-		bar := bar // copy
+	case strategyEntities.RuleATRBelowPct:
+		atr, ok := store.get(fmt.Sprintf("ATR_%d", windowOr(rule.Window, 14)), bar.Date)
+		return ok && bar.Close > 0 && atr/bar.Close*100 < rule.Value
 
-		prevDate := ""
-		if i > 0 {
-			prevDate = bars[i-1].Date
+	// ── Stochastic ───────────────────────────────────────────────────────────
+	case strategyEntities.RuleStochCrossAbove:
+		if !hasPrev {
+			return false
 		}
+		kp, sm, dp := stochParams(rule)
+		key := fmt.Sprintf("%d_%d_%d", kp, sm, dp)
+		kCurr, ok1 := store.get("STOCHK_"+key, bar.Date)
+		dCurr, ok2 := store.get("STOCHD_"+key, bar.Date)
+		kPrev, ok3 := store.get("STOCHK_"+key, prevDate)
+		dPrev, ok4 := store.get("STOCHD_"+key, prevDate)
+		return ok1 && ok2 && ok3 && ok4 && kPrev <= dPrev && kCurr > dCurr
 
-		equity := cash + shares*bar.Close
-		if equity > peakEquity {
-			peakEquity = equity
+	case strategyEntities.RuleStochCrossBelow:
+		if !hasPrev {
+			return false
 		}
-		if peakEquity > 0 {
-			dd := (peakEquity - equity) / peakEquity * 100
-			if dd > maxDrawdown {
-				maxDrawdown = dd
-			}
+		kp, sm, dp := stochParams(rule)
+		key := fmt.Sprintf("%d_%d_%d", kp, sm, dp)
+		kCurr, ok1 := store.get("STOCHK_"+key, bar.Date)
+		dCurr, ok2 := store.get("STOCHD_"+key, bar.Date)
+		kPrev, ok3 := store.get("STOCHK_"+key, prevDate)
+		dPrev, ok4 := store.get("STOCHD_"+key, prevDate)
+		return ok1 && ok2 && ok3 && ok4 && kPrev >= dPrev && kCurr < dCurr
+
+	// ── ADX trend strength ───────────────────────────────────────────────────
+	case strategyEntities.RuleADXAbove:
+		adx, ok := store.get(fmt.Sprintf("ADX_%d", windowOr(rule.Window, 14)), bar.Date)
+		return ok && adx > rule.Value
+
+	case strategyEntities.RuleADXBelow:
+		adx, ok := store.get(fmt.Sprintf("ADX_%d", windowOr(rule.Window, 14)), bar.Date)
+		return ok && adx < rule.Value
+
+	// ── OBV divergence ───────────────────────────────────────────────────────
+	case strategyEntities.RuleOBVBullishDivergence:
+		div, ok := store.get(fmt.Sprintf("OBVDIV_%d", windowOr(rule.Window, 20)), bar.Date)
+		return ok && div > 0
+
+	case strategyEntities.RuleOBVBearishDivergence:
+		div, ok := store.get(fmt.Sprintf("OBVDIV_%d", windowOr(rule.Window, 20)), bar.Date)
+		return ok && div < 0
+
+	// ── Donchian channel breakout (against the channel as of the prior bar) ──
+	case strategyEntities.RuleDonchianBreakAbove:
+		if !hasPrev {
+			return false
 		}
+		upper, ok := store.get(fmt.Sprintf("DCU_%d", windowOr(rule.Window, 20)), prevDate)
+		return ok && bar.Close > upper
 
-		if shares == 0 {
-			// Check buy conditions (all must be met)
-			if allBuyRulesMet(strategy.BuyRules, &bar, prevDate, store) {
-				qty := math.Floor(cash / bar.Close)
-				if qty >= 1 {
-					cost := qty * bar.Close
-					cash -= cost
-					shares = qty
-					buyPrice = bar.Close
-					peakPrice = bar.Close
-					trades = append(trades, strategyEntities.BacktestTrade{
-						Type:      "BUY",
-						Date:      bar.Date,
-						Price:     bar.Close,
-						Shares:    qty,
-						Value:     cost,
-						CashAfter: cash,
-					})
-				}
-			}
-		} else {
-			// Update trailing stop peak
-			if bar.Close > peakPrice {
-				peakPrice = bar.Close
-			}
-
-			shouldSell := false
-
-			// No sell conditions → hold to end
-			if len(strategy.SellConditions) > 0 {
-				for _, sc := range strategy.SellConditions {
-					switch sc.Type {
-					case strategyEntities.SellTakeProfit:
-						pnlPct := (bar.Close - buyPrice) / buyPrice * 100
-						if pnlPct >= sc.Percent {
-							shouldSell = true
-						}
-					case strategyEntities.SellStopLoss:
-						pnlPct := (bar.Close - buyPrice) / buyPrice * 100
-						if pnlPct <= -sc.Percent {
-							shouldSell = true
-						}
-					case strategyEntities.SellTrailingStop:
-						if peakPrice > 0 {
-							drawdown := (peakPrice - bar.Close) / peakPrice * 100
-							if drawdown >= sc.Percent {
-								shouldSell = true
-							}
-						}
-					case strategyEntities.SellIndicator:
-						if sc.Rule != nil && evaluateRule(*sc.Rule, &bar, prevDate, store) {
-							shouldSell = true
-						}
-					}
-					if shouldSell {
-						break
-					}
-				}
-
-				// Force close on last bar
-				if i == len(bars)-1 {
-					shouldSell = true
-				}
-			}
-
-			if shouldSell {
-				proceeds := shares * bar.Close
-				pnl := (bar.Close - buyPrice) * shares
-				pnlPct := (bar.Close - buyPrice) / buyPrice * 100
-				cash += proceeds
-				trades = append(trades, strategyEntities.BacktestTrade{
-					Type:       "SELL",
-					Date:       bar.Date,
-					Price:      bar.Close,
-					Shares:     shares,
-					Value:      proceeds,
-					PnL:        pnl,
-					PnLPercent: pnlPct,
-					CashAfter:  cash,
-				})
-				shares = 0
-				buyPrice = 0
-				peakPrice = 0
-			}
+	case strategyEntities.RuleDonchianBreakBelow:
+		if !hasPrev {
+			return false
 		}
+		lower, ok := store.get(fmt.Sprintf("DCL_%d", windowOr(rule.Window, 20)), prevDate)
+		return ok && bar.Close < lower
 	}
 
-	// Mark-to-market any open position
-	lastPrice := bars[len(bars)-1].Close
-	finalBalance := cash + shares*lastPrice
-	roi := (finalBalance - initialBalance) / initialBalance * 100
+	return false
+}
 
-	winning, losing := 0, 0
-	for _, t := range trades {
-		if t.Type == "SELL" {
-			if t.PnL >= 0 {
-				winning++
-			} else {
-				losing++
-			}
-		}
-	}
+// met evaluates a rule tree on the close of bar i. A nil tree never fires.
+func (f *symbolFeed) met(group *strategyEntities.RuleGroup, i int) bool {
+	return group.Eval(func(rule strategyEntities.Rule) bool {
+		return f.ruleMet(rule, i)
+	})
+}
 
-	return &backtestResult{
-		FinalBalance:  finalBalance,
-		ROI:           roi,
-		TotalTrades:   len(trades),
-		WinningTrades: winning,
-		LosingTrades:  losing,
-		MaxDrawdown:   maxDrawdown,
-		Trades:        trades,
-	}, nil
+// ruleMet evaluates rule on the close of bar i. A rule on another timeframe
+// sees the last bar of it completed by then, and the one before that as its
+// previous bar.
+func (f *symbolFeed) ruleMet(rule strategyEntities.Rule, i int) bool {
+	frame := f.Frames[rule.Timeframe]
+	if frame == nil {
+		return evaluateRule(rule, &f.History[i], f.prevDate(i), f.Store)
+	}
+	j := frame.Aligned[i]
+	if j < 0 {
+		return false
+	}
+	prevDate := ""
+	if j > 0 {
+		prevDate = frame.History[j-1].Date
+	}
+	return evaluateRule(rule, &frame.History[j], prevDate, frame.Store)
 }
 
 // ── HTTP Handlers ─────────────────────────────────────────────────────────────
 
-type runBacktestBody struct {
-	StrategyUUID   string  `json:"strategy_uuid"`
-	Ticker         string  `json:"ticker"`
-	FromDate       string  `json:"from_date"`
-	ToDate         string  `json:"to_date"`
-	InitialBalance float64 `json:"initial_balance"`
-}
-
 func RunBacktest(res http.ResponseWriter, req *http.Request) {
 	email := req.Header.Get("x-cf-uid")
 	if email == "" {
@@ -708,42 +786,145 @@ func RunBacktest(res http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	var body runBacktestBody
+	var body strategyEntities.BacktestRequest
 	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
 		httpx.WriteError(res, req, httpx.BadRequest("invalid request body", nil))
 		return
 	}
 
-	body.Ticker = strings.ToUpper(strings.TrimSpace(body.Ticker))
-	if body.StrategyUUID == "" || body.Ticker == "" || body.FromDate == "" || body.ToDate == "" || body.InitialBalance <= 0 {
-		httpx.WriteError(res, req, httpx.BadRequest("strategy_uuid, ticker, from_date, to_date, initial_balance are required", nil))
+	record, err := runBacktestRequest(req.Context(), db, *account.AccountID, body)
+	if err != nil {
+		httpx.WriteError(res, req, err)
 		return
 	}
 
+	httpx.WriteJSON(res, http.StatusOK, record)
+}
+
+// runBacktestRequest runs body synchronously and saves the result. Errors are
+// *httpx.Error.
+func runBacktestRequest(ctx context.Context, db *mongo.Database, accountID string, body strategyEntities.BacktestRequest) (*strategyEntities.BacktestEntity, error) {
+	prepared, err := prepareBacktest(ctx, db, accountID, body)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := runBacktestEngine(ctx, datafeed.GetProvider(), &prepared.Strategy, prepared.Run)
+	if err != nil {
+		return nil, httpx.Internal(fmt.Sprintf("backtest failed: %s", err.Error()))
+	}
+
+	return saveBacktest(ctx, db, prepared, result)
+}
+
+// preparedBacktest is a validated backtest request with its strategy loaded
+// and defaults resolved.
+type preparedBacktest struct {
+	AccountID string
+	Body      strategyEntities.BacktestRequest
+	Strategy  strategyEntities.StrategyEntity
+	Run       backtestRun
+}
+
+// prepareBacktest validates body and resolves the universe, cost model and
+// execution model. Errors are *httpx.Error.
+func prepareBacktest(ctx context.Context, db *mongo.Database, accountID string, body strategyEntities.BacktestRequest) (*preparedBacktest, error) {
+	if body.StrategyUUID == "" || body.FromDate == "" || body.ToDate == "" || body.InitialBalance <= 0 {
+		return nil, httpx.BadRequest("strategy_uuid, from_date, to_date, initial_balance are required", nil)
+	}
+
 	// Load strategy
 	var strategy strategyEntities.StrategyEntity
-	if err := db.Collection(datastores.Strategies).FindOne(req.Context(),
-		bson.M{"uuid": body.StrategyUUID, "account_id": *account.AccountID}).Decode(&strategy); err != nil {
-		httpx.WriteError(res, req, httpx.NotFound("strategy not found"))
-		return
+	if err := db.Collection(datastores.Strategies).FindOne(ctx,
+		bson.M{"uuid": body.StrategyUUID, "account_id": accountID}).Decode(&strategy); err != nil {
+		return nil, httpx.NotFound("strategy not found")
 	}
 
-	// Use the strategy ticker unless the caller overrides
-	ticker := body.Ticker
-	if ticker == "" {
-		ticker = strategy.Ticker
+	// Run the requested revision, pinning the current one by default so
+	// queued jobs are unaffected by later edits
+	if body.Revision < 0 {
+		return nil, httpx.BadRequest("revision cannot be negative", nil)
 	}
-
-	result, err := runBacktestEngine(req, &strategy, ticker, body.FromDate, body.ToDate, body.InitialBalance)
+	current, err := ensureRevision(ctx, db, &strategy)
 	if err != nil {
-		httpx.WriteError(res, req, httpx.Internal(fmt.Sprintf("backtest failed: %s", err.Error())))
-		return
+		return nil, httpx.Internal("failed to record strategy revision")
+	}
+	if body.Revision > 0 && body.Revision != current {
+		past, err := loadRevision(ctx, db, accountID, body.StrategyUUID, body.Revision)
+		if err != nil {
+			return nil, err
+		}
+		strategy = past.Strategy
 	}
+	body.Revision = strategy.Revision
+
+	// Use the strategy universe unless the caller overrides
+	tickers := normalizeTickers(body.Tickers)
+	if len(tickers) == 0 {
+		tickers = normalizeTickers([]string{body.Ticker})
+	}
+	if len(tickers) == 0 {
+		universe, err := strategyUniverse(ctx, db, &strategy)
+		if err != nil {
+			return nil, httpx.BadRequest(err.Error(), nil)
+		}
+		tickers = universe
+	}
+	if len(tickers) > maxUniverseSize {
+		return nil, httpx.BadRequest(fmt.Sprintf("a backtest may trade at most %d tickers", maxUniverseSize), nil)
+	}
+
+	if err := body.Costs.Validate(); err != nil {
+		return nil, httpx.BadRequest(err.Error(), nil)
+	}
+	if body.Costs == nil {
+		body.Costs = portfolioCostModel(ctx, db, &strategy)
+	}
+	if !body.Execution.Valid() {
+		return nil, httpx.BadRequest("execution must be SAME_CLOSE or NEXT_OPEN", nil)
+	}
+	if body.Execution == "" {
+		body.Execution = strategy.Execution
+	}
+	body.Benchmark = strings.ToUpper(strings.TrimSpace(body.Benchmark))
+
+	return &preparedBacktest{
+		AccountID: accountID,
+		Body:      body,
+		Strategy:  strategy,
+		Run: backtestRun{
+			Tickers:        tickers,
+			From:           body.FromDate,
+			To:             body.ToDate,
+			InitialBalance: body.InitialBalance,
+			Costs:          body.Costs,
+			Execution:      body.Execution,
+			BuyAndHold:     body.BuyAndHold,
+			Benchmark:      body.Benchmark,
+		},
+	}, nil
+}
+
+// saveBacktest stores a finished backtest and its equity curve.
+func saveBacktest(ctx context.Context, db *mongo.Database, p *preparedBacktest, result *backtestResult) (*strategyEntities.BacktestEntity, error) {
+	body, tickers := p.Body, p.Run.Tickers
 
 	record := strategyEntities.BacktestEntity{
-		UUID:           uuid.New(),
-		StrategyUUID:   body.StrategyUUID,
-		Ticker:         ticker,
+		UUID:             uuid.New(),
+		StrategyUUID:     body.StrategyUUID,
+		StrategyRevision: body.Revision,
+		Ticker:           tickers[0],
+		Tickers:          tickers,
+		MaxPositions:     p.Strategy.MaxPositions,
+		Symbols:          result.Symbols,
+		CostModel:        body.Costs,
+		Execution:        body.Execution,
+		TotalCosts: strategyEntities.BacktestCosts{
+			Commission: result.TotalCommission,
+			Fees:       result.TotalFees,
+			Slippage:   result.TotalSlippage,
+			Borrow:     result.TotalBorrow,
+		},
 		FromDate:       body.FromDate,
 		ToDate:         body.ToDate,
 		InitialBalance: body.InitialBalance,
@@ -753,18 +934,37 @@ func RunBacktest(res http.ResponseWriter, req *http.Request) {
 		WinningTrades:  result.WinningTrades,
 		LosingTrades:   result.LosingTrades,
 		MaxDrawdown:    result.MaxDrawdown,
+		Metrics:        &result.Metrics,
+		BuyAndHold:     result.BuyAndHold,
+		Benchmark:      result.Benchmark,
 		Trades:         result.Trades,
-		AccountID:      *account.AccountID,
-		PortfolioUUID:  strategy.PortfolioUUID,
+		AccountID:      p.AccountID,
+		PortfolioUUID:  p.Strategy.PortfolioUUID,
 		CreatedAt:      time.Now().UTC(),
 	}
 
-	if _, err := db.Collection(datastores.Backtests).InsertOne(req.Context(), record); err != nil {
-		httpx.WriteError(res, req, httpx.Internal("failed to save backtest"))
-		return
+	if _, err := db.Collection(datastores.Backtests).InsertOne(ctx, record); err != nil {
+		return nil, httpx.Internal("failed to save backtest")
 	}
 
-	httpx.WriteJSON(res, http.StatusOK, record)
+	equity := strategyEntities.BacktestEquityEntity{
+		BacktestUUID: record.UUID,
+		StrategyUUID: record.StrategyUUID,
+		AccountID:    record.AccountID,
+		Points:       equitySeries(body.InitialBalance, result.Equity),
+		CreatedAt:    record.CreatedAt,
+	}
+	if result.BuyAndHold != nil {
+		equity.BuyAndHold = result.BuyAndHold.Equity
+	}
+	if result.Benchmark != nil {
+		equity.Benchmark = result.Benchmark.Equity
+	}
+	if _, err := db.Collection(datastores.BacktestEquity).InsertOne(ctx, equity); err != nil {
+		return nil, httpx.Internal("failed to save backtest equity curve")
+	}
+
+	return &record, nil
 }
 
 func GetBacktests(res http.ResponseWriter, req *http.Request) {
@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+
+	"code.cacheflow.internal/datafeed"
+	"code.cacheflow.internal/portfolio/costs"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/strategy/metrics"
+)
+
+// ── Benchmarks ────────────────────────────────────────────────────────────────
+
+// holdCurve invests an equal share of initial in each series at its first
+// close on the calendar and holds to the end, marking to market every day.
+// Each series maps date → close.
+func holdCurve(series []map[string]float64, calendar []string, initial float64, model *costs.Model) []metrics.Point {
+	budget := initial / float64(len(series))
+	cash := initial
+	shares := make([]float64, len(series))
+	last := make([]float64, len(series))
+
+	curve := make([]metrics.Point, 0, len(calendar))
+	for _, date := range calendar {
+		equity := 0.0
+		for i, closes := range series {
+			if c, ok := closes[date]; ok {
+				last[i] = c
+				if shares[i] == 0 && c > 0 {
+					qty := float64(int(budget / model.Apply(costs.Buy, c, 1).Price))
+					fill, qty := affordableFill(model, costs.Buy, c, qty, min(budget, cash))
+					if qty >= 1 {
+						shares[i] = qty
+						cash -= qty*fill.Price + fill.Costs()
+					}
+				}
+			}
+			equity += shares[i] * last[i]
+		}
+		curve = append(curve, metrics.Point{Date: date, Equity: cash + equity, Cash: cash, Invested: equity > 0})
+	}
+	return curve
+}
+
+// benchmarkCloses fetches ticker's closes over the simulated range.
+func benchmarkCloses(ctx context.Context, provider datafeed.MarketDataProvider, ticker, from, to string) (map[string]float64, error) {
+	bars, err := fetchDailyBars(ctx, provider, ticker, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch benchmark %s: %w", ticker, err)
+	}
+	if len(bars) == 0 {
+		return nil, fmt.Errorf("no price data for benchmark %s between %s and %s", ticker, from, to)
+	}
+	closes := make(map[string]float64, len(bars))
+	for _, b := range bars {
+		closes[b.Date] = b.Close
+	}
+	return closes, nil
+}
+
+// compareCurves summarizes a passive curve and relates the strategy's curve
+// to it.
+func compareCurves(tickers []string, initial float64, strategy, passive []metrics.Point) *strategyEntities.BacktestComparison {
+	final := initial
+	if len(passive) > 0 {
+		final = passive[len(passive)-1].Equity
+	}
+	return &strategyEntities.BacktestComparison{
+		Tickers:      tickers,
+		FinalBalance: final,
+		ROI:          (final - initial) / initial * 100,
+		Metrics:      metrics.Compute(initial, passive, nil),
+		Relative:     metrics.Compare(metrics.DailyReturns(initial, strategy), metrics.DailyReturns(initial, passive)),
+		Equity:       equitySeries(initial, passive),
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -21,9 +22,26 @@ type createStrategyBody struct {
 	Name           string                              `json:"name"`
 	Description    string                              `json:"description"`
 	Ticker         string                              `json:"ticker"`
+	Tickers        []string                            `json:"tickers"`
+	WatchlistUUID  string                              `json:"watchlist_uuid"`
+	MaxPositions   int                                 `json:"max_positions"`
+	PositionSizing *strategyEntities.PositionSizing    `json:"position_sizing"`
+	Execution      strategyEntities.ExecutionModel     `json:"execution"`
 	BuyRules       []strategyEntities.Rule             `json:"buy_rules"`
+	EntryRules     *strategyEntities.RuleGroup         `json:"entry_rules"`
+	ExitRules      *strategyEntities.RuleGroup         `json:"exit_rules"`
 	SellConditions []strategyEntities.SellCondition    `json:"sell_conditions"`
+	DSL            string                              `json:"dsl"` // rules as text; replaces the rule fields when set
 	PortfolioUUID  string                              `json:"portfolio_uuid"`
+
+	// Short side; see StrategyEntity
+	Direction       strategyEntities.TradeDirection `json:"direction"`
+	ShortEntryRules *strategyEntities.RuleGroup     `json:"short_entry_rules"`
+	ShortExitRules  *strategyEntities.RuleGroup     `json:"short_exit_rules"`
+
+	MaxEntries     int                        `json:"max_entries"` // pyramiding; see StrategyEntity
+	Timeframe      strategyEntities.Timeframe `json:"timeframe"`
+	FlattenAtClose bool                       `json:"flatten_at_close"`
 }
 
 func CreateStrategy(res http.ResponseWriter, req *http.Request) {
@@ -49,15 +67,54 @@ func CreateStrategy(res http.ResponseWriter, req *http.Request) {
 
 	body.Name = strings.TrimSpace(body.Name)
 	body.Ticker = strings.ToUpper(strings.TrimSpace(body.Ticker))
+	body.Tickers = normalizeTickers(body.Tickers)
+	body.WatchlistUUID = strings.TrimSpace(body.WatchlistUUID)
 
-	if body.Name == "" || body.Ticker == "" || body.PortfolioUUID == "" {
-		httpx.WriteError(res, req, httpx.BadRequest("name, ticker, and portfolio_uuid are required", nil))
+	if body.Name == "" || body.PortfolioUUID == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("name and portfolio_uuid are required", nil))
+		return
+	}
+	if err := validateUniverse(&body.Ticker, body.Tickers, body.WatchlistUUID, body.MaxPositions); err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+	if body.PositionSizing != nil {
+		if err := body.PositionSizing.Validate(); err != nil {
+			httpx.WriteError(res, req, httpx.BadRequest("invalid position_sizing: "+err.Error(), nil))
+			return
+		}
+	}
+	if !body.Execution.Valid() {
+		httpx.WriteError(res, req, httpx.BadRequest("execution must be SAME_CLOSE or NEXT_OPEN", nil))
 		return
 	}
-	if len(body.BuyRules) == 0 {
-		httpx.WriteError(res, req, httpx.BadRequest("at least one buy rule is required", nil))
+	if strings.TrimSpace(body.DSL) != "" {
+		rules, err := parseDSL(body.DSL)
+		if err != nil {
+			httpx.WriteError(res, req, err)
+			return
+		}
+		body.BuyRules, body.EntryRules, body.ExitRules, body.SellConditions = nil, rules.EntryRules, rules.ExitRules, rules.SellConditions
+	}
+	entry, err := entryRules(&strategyEntities.StrategyEntity{
+		Direction:       body.Direction,
+		BuyRules:        body.BuyRules,
+		EntryRules:      body.EntryRules,
+		ExitRules:       body.ExitRules,
+		SellConditions:  body.SellConditions,
+		ShortEntryRules: body.ShortEntryRules,
+		ShortExitRules:  body.ShortExitRules,
+		MaxEntries:      body.MaxEntries,
+		Timeframe:       body.Timeframe,
+		FlattenAtClose:  body.FlattenAtClose,
+	})
+	if err != nil {
+		httpx.WriteError(res, req, err)
 		return
 	}
+	if body.BuyRules == nil {
+		body.BuyRules = []strategyEntities.Rule{}
+	}
 
 	now := time.Now().UTC()
 	strategy := strategyEntities.StrategyEntity{
@@ -65,22 +122,89 @@ func CreateStrategy(res http.ResponseWriter, req *http.Request) {
 		Name:           body.Name,
 		Description:    body.Description,
 		Ticker:         body.Ticker,
+		Tickers:        body.Tickers,
+		WatchlistUUID:  body.WatchlistUUID,
+		MaxPositions:   body.MaxPositions,
+		PositionSizing: body.PositionSizing,
+		Execution:      body.Execution,
 		BuyRules:       body.BuyRules,
+		EntryRules:     entry,
+		ExitRules:      body.ExitRules,
 		SellConditions: body.SellConditions,
+		Revision:       1,
 		PortfolioUUID:  body.PortfolioUUID,
 		AccountID:      *account.AccountID,
 		CreatedAt:      now,
 		UpdatedAt:      now,
+
+		Direction:       body.Direction,
+		ShortEntryRules: body.ShortEntryRules,
+		ShortExitRules:  body.ShortExitRules,
+		MaxEntries:      body.MaxEntries,
+		Timeframe:       body.Timeframe,
+		FlattenAtClose:  body.FlattenAtClose,
 	}
 
 	if _, err := db.Collection(datastores.Strategies).InsertOne(req.Context(), strategy); err != nil {
 		httpx.WriteError(res, req, httpx.Internal("failed to save strategy"))
 		return
 	}
+	if err := saveRevision(req.Context(), db, &strategy); err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to save strategy revision"))
+		return
+	}
 
 	httpx.WriteJSON(res, http.StatusOK, strategy)
 }
 
+// entryRules validates the submitted rules and returns the entry tree to
+// persist. Clients that only send the flat buy_rules list get the equivalent
+// AND group.
+func entryRules(rules *strategyEntities.StrategyEntity) (*strategyEntities.RuleGroup, error) {
+	entry, errs := checkRules(rules)
+	if errs != nil {
+		return nil, httpx.BadRequest("invalid strategy rules", errs)
+	}
+	return entry, nil
+}
+
+// checkRules returns the entry tree of the rules in s and every problem with
+// them by field. Each side the strategy trades needs an entry tree.
+func checkRules(s *strategyEntities.StrategyEntity) (*strategyEntities.RuleGroup, strategyEntities.FieldErrors) {
+	errs := s.ValidateRules()
+	missing := func(field, msg string) {
+		if errs == nil {
+			errs = strategyEntities.FieldErrors{}
+		}
+		errs[field] = msg
+	}
+	if s.Direction.Long() && s.Entry() == nil {
+		missing("entry_rules", "at least one buy rule is required")
+	}
+	if s.Direction.Short() && s.ShortEntryRules == nil {
+		missing("short_entry_rules", "short strategies need short entry rules")
+	}
+	return s.Entry(), errs
+}
+
+// validateUniverse checks that a strategy targets at least one symbol. When
+// only a ticker list is given, the first entry doubles as the display ticker.
+func validateUniverse(ticker *string, tickers []string, watchlistUUID string, maxPositions int) error {
+	if *ticker == "" && len(tickers) > 0 {
+		*ticker = tickers[0]
+	}
+	if *ticker == "" && watchlistUUID == "" {
+		return httpx.BadRequest("ticker, tickers, or watchlist_uuid is required", nil)
+	}
+	if len(tickers) > maxUniverseSize {
+		return httpx.BadRequest(fmt.Sprintf("a strategy may trade at most %d tickers", maxUniverseSize), nil)
+	}
+	if maxPositions < 0 {
+		return httpx.BadRequest("max_positions cannot be negative", nil)
+	}
+	return nil
+}
+
 // ── List ──────────────────────────────────────────────────────────────────────
 
 func GetStrategies(res http.ResponseWriter, req *http.Request) {
@@ -131,8 +255,25 @@ type updateStrategyBody struct {
 	Name           string                              `json:"name"`
 	Description    string                              `json:"description"`
 	Ticker         string                              `json:"ticker"`
+	Tickers        []string                            `json:"tickers"`
+	WatchlistUUID  string                              `json:"watchlist_uuid"`
+	MaxPositions   int                                 `json:"max_positions"`
+	PositionSizing *strategyEntities.PositionSizing    `json:"position_sizing"`
+	Execution      strategyEntities.ExecutionModel     `json:"execution"`
 	BuyRules       []strategyEntities.Rule             `json:"buy_rules"`
+	EntryRules     *strategyEntities.RuleGroup         `json:"entry_rules"`
+	ExitRules      *strategyEntities.RuleGroup         `json:"exit_rules"`
 	SellConditions []strategyEntities.SellCondition    `json:"sell_conditions"`
+	DSL            string                              `json:"dsl"` // rules as text; replaces the rule fields when set
+
+	// Short side; see StrategyEntity
+	Direction       strategyEntities.TradeDirection `json:"direction"`
+	ShortEntryRules *strategyEntities.RuleGroup     `json:"short_entry_rules"`
+	ShortExitRules  *strategyEntities.RuleGroup     `json:"short_exit_rules"`
+
+	MaxEntries     int                        `json:"max_entries"` // pyramiding; see StrategyEntity
+	Timeframe      strategyEntities.Timeframe `json:"timeframe"`
+	FlattenAtClose bool                       `json:"flatten_at_close"`
 }
 
 func UpdateStrategy(res http.ResponseWriter, req *http.Request) {
@@ -158,33 +299,141 @@ func UpdateStrategy(res http.ResponseWriter, req *http.Request) {
 
 	body.Name = strings.TrimSpace(body.Name)
 	body.Ticker = strings.ToUpper(strings.TrimSpace(body.Ticker))
+	body.Tickers = normalizeTickers(body.Tickers)
+	body.WatchlistUUID = strings.TrimSpace(body.WatchlistUUID)
 
-	if body.UUID == "" || body.Name == "" || body.Ticker == "" {
-		httpx.WriteError(res, req, httpx.BadRequest("uuid, name, and ticker are required", nil))
+	if body.UUID == "" || body.Name == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("uuid and name are required", nil))
+		return
+	}
+	if err := validateUniverse(&body.Ticker, body.Tickers, body.WatchlistUUID, body.MaxPositions); err != nil {
+		httpx.WriteError(res, req, err)
 		return
 	}
-	if len(body.BuyRules) == 0 {
-		httpx.WriteError(res, req, httpx.BadRequest("at least one buy rule is required", nil))
+	if body.PositionSizing != nil {
+		if err := body.PositionSizing.Validate(); err != nil {
+			httpx.WriteError(res, req, httpx.BadRequest("invalid position_sizing: "+err.Error(), nil))
+			return
+		}
+	}
+	if !body.Execution.Valid() {
+		httpx.WriteError(res, req, httpx.BadRequest("execution must be SAME_CLOSE or NEXT_OPEN", nil))
+		return
+	}
+	if strings.TrimSpace(body.DSL) != "" {
+		rules, err := parseDSL(body.DSL)
+		if err != nil {
+			httpx.WriteError(res, req, err)
+			return
+		}
+		body.BuyRules, body.EntryRules, body.ExitRules, body.SellConditions = nil, rules.EntryRules, rules.ExitRules, rules.SellConditions
+	}
+	entry, err := entryRules(&strategyEntities.StrategyEntity{
+		Direction:       body.Direction,
+		BuyRules:        body.BuyRules,
+		EntryRules:      body.EntryRules,
+		ExitRules:       body.ExitRules,
+		SellConditions:  body.SellConditions,
+		ShortEntryRules: body.ShortEntryRules,
+		ShortExitRules:  body.ShortExitRules,
+		MaxEntries:      body.MaxEntries,
+		Timeframe:       body.Timeframe,
+		FlattenAtClose:  body.FlattenAtClose,
+	})
+	if err != nil {
+		httpx.WriteError(res, req, err)
 		return
 	}
+	if body.BuyRules == nil {
+		body.BuyRules = []strategyEntities.Rule{}
+	}
 
 	filter := bson.M{"uuid": body.UUID, "account_id": *account.AccountID}
+
+	var current strategyEntities.StrategyEntity
+	if err := db.Collection(datastores.Strategies).FindOne(req.Context(), filter).Decode(&current); err != nil {
+		httpx.WriteError(res, req, httpx.NotFound("strategy not found"))
+		return
+	}
+	revision, err := ensureRevision(req.Context(), db, &current)
+	if err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to record strategy revision"))
+		return
+	}
+
+	next := current
+	next.Name = body.Name
+	next.Description = body.Description
+	next.Ticker = body.Ticker
+	next.Tickers = body.Tickers
+	next.WatchlistUUID = body.WatchlistUUID
+	next.MaxPositions = body.MaxPositions
+	next.PositionSizing = body.PositionSizing
+	next.Execution = body.Execution
+	next.BuyRules = body.BuyRules
+	next.EntryRules = entry
+	next.ExitRules = body.ExitRules
+	next.SellConditions = body.SellConditions
+	next.Direction = body.Direction
+	next.ShortEntryRules = body.ShortEntryRules
+	next.ShortExitRules = body.ShortExitRules
+	next.MaxEntries = body.MaxEntries
+	next.Timeframe = body.Timeframe
+	next.FlattenAtClose = body.FlattenAtClose
+	next.UpdatedAt = time.Now().UTC()
+	if next.Live != nil && next.Live.Enabled {
+		if msg := liveUnsupported(&next); msg != "" {
+			httpx.WriteError(res, req, httpx.BadRequest(msg+", disable it before this change", nil))
+			return
+		}
+	}
+
+	// Definition changes get a new revision; renames do not
+	if len(strategyEntities.DiffStrategies(&current, &next)) > 0 {
+		next.Revision = revision + 1
+	}
+
 	update := bson.M{"$set": bson.M{
-		"name":            body.Name,
-		"description":     body.Description,
-		"ticker":          body.Ticker,
-		"buy_rules":       body.BuyRules,
-		"sell_conditions": body.SellConditions,
-		"updated_at":      time.Now().UTC(),
+		"name":            next.Name,
+		"description":     next.Description,
+		"ticker":          next.Ticker,
+		"tickers":         next.Tickers,
+		"watchlist_uuid":  next.WatchlistUUID,
+		"max_positions":   next.MaxPositions,
+		"position_sizing": next.PositionSizing,
+		"execution":       next.Execution,
+		"buy_rules":       next.BuyRules,
+		"entry_rules":     next.EntryRules,
+		"exit_rules":      next.ExitRules,
+		"sell_conditions": next.SellConditions,
+		"revision":        next.Revision,
+		"updated_at":      next.UpdatedAt,
+
+		"direction":         next.Direction,
+		"short_entry_rules": next.ShortEntryRules,
+		"short_exit_rules":  next.ShortExitRules,
+		"max_entries":       next.MaxEntries,
+		"timeframe":         next.Timeframe,
+		"flatten_at_close":  next.FlattenAtClose,
 	}}
 
+	// Only apply the update to the revision it was based on
+	filter["revision"] = revision
 	result, err := db.Collection(datastores.Strategies).UpdateOne(req.Context(), filter, update)
-	if err != nil || result.MatchedCount == 0 {
-		httpx.WriteError(res, req, httpx.NotFound("strategy not found"))
+	if err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to update strategy"))
+		return
+	}
+	if result.MatchedCount == 0 {
+		httpx.WriteError(res, req, httpx.Conflict("strategy was changed by another request, reload and retry", nil))
+		return
+	}
+	if err := saveRevision(req.Context(), db, &next); err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to save strategy revision"))
 		return
 	}
 
-	httpx.WriteJSON(res, http.StatusOK, map[string]any{"updated": true})
+	httpx.WriteJSON(res, http.StatusOK, map[string]any{"updated": true, "revision": next.Revision})
 }
 
 // ── Delete ────────────────────────────────────────────────────────────────────
@@ -228,6 +477,12 @@ func DeleteStrategy(res http.ResponseWriter, req *http.Request) {
 
 	// Also delete associated backtests
 	_, _ = db.Collection(datastores.Backtests).DeleteMany(req.Context(), bson.M{"strategy_uuid": body.UUID})
+	_, _ = db.Collection(datastores.BacktestEquity).DeleteMany(req.Context(), bson.M{"strategy_uuid": body.UUID})
+	_, _ = db.Collection(datastores.BacktestJobs).DeleteMany(req.Context(), bson.M{"strategy_uuid": body.UUID})
+	_, _ = db.Collection(datastores.MonteCarlo).DeleteMany(req.Context(), bson.M{"strategy_uuid": body.UUID})
+	_, _ = db.Collection(datastores.StrategyLive).DeleteMany(req.Context(), bson.M{"strategy_uuid": body.UUID})
+	_, _ = db.Collection(datastores.StrategyDecisions).DeleteMany(req.Context(), bson.M{"strategy_uuid": body.UUID})
+	_, _ = db.Collection(datastores.StrategyRevisions).DeleteMany(req.Context(), bson.M{"strategy_uuid": body.UUID})
 
 	httpx.WriteJSON(res, http.StatusOK, map[string]any{"deleted": true})
 }
@@ -0,0 +1,135 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	accountEntities "code.cacheflow.internal/account/entities"
+	datastores "code.cacheflow.internal/datastores/mongo"
+	"code.cacheflow.internal/strategy/dsl"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/util/httpx"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ── Strategy DSL ──────────────────────────────────────────────────────────────
+
+// maxDSLLength bounds the text accepted by the parser.
+const maxDSLLength = 16 << 10
+
+// parseDSL parses strategy rules written as text. Errors are *httpx.Error
+// with the position in the line and column fields.
+func parseDSL(src string) (*strategyEntities.StrategyEntity, error) {
+	if len(src) > maxDSLLength {
+		return nil, httpx.BadRequest("dsl is too long", nil)
+	}
+	strategy, err := dsl.Parse(src)
+	if err != nil {
+		var syntaxErr *dsl.Error
+		if !errors.As(err, &syntaxErr) {
+			return nil, httpx.BadRequest("invalid dsl", nil)
+		}
+		return nil, httpx.BadRequest("invalid dsl: "+syntaxErr.Error(), map[string]string{
+			"dsl":    syntaxErr.Message,
+			"line":   strconv.Itoa(syntaxErr.Line),
+			"column": strconv.Itoa(syntaxErr.Column),
+		})
+	}
+	return strategy, nil
+}
+
+type validateDSLBody struct {
+	Source string `json:"source"`
+}
+
+// ValidateStrategyDSL parses strategy text and checks its rules as
+// CreateStrategy would, without saving anything. Syntax errors and rule errors
+// by field are part of a 200 response so editors can mark them as the user
+// types.
+func ValidateStrategyDSL(res http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("x-cf-uid") == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	var body validateDSLBody
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("invalid request body", nil))
+		return
+	}
+	if len(body.Source) > maxDSLLength {
+		httpx.WriteError(res, req, httpx.BadRequest("source is too long", nil))
+		return
+	}
+
+	strategy, err := dsl.Parse(body.Source)
+	if err != nil {
+		var syntaxErr *dsl.Error
+		if !errors.As(err, &syntaxErr) {
+			httpx.WriteError(res, req, httpx.Internal("failed to parse source"))
+			return
+		}
+		httpx.WriteJSON(res, http.StatusOK, map[string]any{"valid": false, "error": syntaxErr})
+		return
+	}
+	if _, errs := checkRules(strategy); errs != nil {
+		httpx.WriteJSON(res, http.StatusOK, map[string]any{"valid": false, "errors": errs})
+		return
+	}
+
+	formatted, err := dsl.Format(strategy)
+	if err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to format source"))
+		return
+	}
+
+	httpx.WriteJSON(res, http.StatusOK, map[string]any{
+		"valid":           true,
+		"formatted":       formatted,
+		"entry_rules":     strategy.EntryRules,
+		"exit_rules":      strategy.ExitRules,
+		"sell_conditions": strategy.SellConditions,
+	})
+}
+
+// GetStrategyDSL returns a saved strategy's rules as text.
+func GetStrategyDSL(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	strategyUUID := strings.TrimSpace(req.URL.Query().Get("strategy_uuid"))
+	if strategyUUID == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("strategy_uuid is required", nil))
+		return
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	var strategy strategyEntities.StrategyEntity
+	if err := db.Collection(datastores.Strategies).FindOne(req.Context(),
+		bson.M{"uuid": strategyUUID, "account_id": *account.AccountID}).Decode(&strategy); err != nil {
+		httpx.WriteError(res, req, httpx.NotFound("strategy not found"))
+		return
+	}
+
+	source, err := dsl.Format(&strategy)
+	if err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("strategy cannot be written as text: "+err.Error(), nil))
+		return
+	}
+
+	httpx.WriteJSON(res, http.StatusOK, map[string]any{"source": source})
+}
@@ -0,0 +1,59 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateStrategyDSL(t *testing.T) {
+	// Expect lists the response fields checked
+	tests := []struct {
+		Name   string
+		Source string
+		Expect map[string]any
+	}{
+		{
+			Name:   "Valid",
+			Source: "BUY WHEN rsi(14) < 30",
+			Expect: map[string]any{"valid": true, "formatted": "BUY WHEN rsi(14) < 30"},
+		},
+		{
+			Name:   "Syntax error",
+			Source: "BUY rsi < 30",
+			Expect: map[string]any{"valid": false, "error": map[string]any{
+				"offset": 4.0, "line": 1.0, "column": 5.0, "message": `expected WHEN, found "rsi"`,
+			}},
+		},
+		{
+			Name:   "Parses but cannot fire",
+			Source: "BUY WHEN ema crosses_above ema",
+			Expect: map[string]any{"valid": false, "errors": map[string]any{
+				"entry_rules.rule.fast_window": "is required",
+				"entry_rules.rule.slow_window": "is required",
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			body, _ := json.Marshal(validateDSLBody{Source: tt.Source})
+			req := httptest.NewRequest(http.MethodPost, "/v1/strategy/dsl/validate", bytes.NewReader(body))
+			req.Header.Set("x-cf-uid", "trader@example.com")
+			res := httptest.NewRecorder()
+
+			ValidateStrategyDSL(res, req)
+
+			assert.Equal(t, http.StatusOK, res.Code)
+			var got map[string]any
+			assert.NoError(t, json.Unmarshal(res.Body.Bytes(), &got))
+			for field, want := range tt.Expect {
+				assert.Equal(t, want, got[field], field)
+			}
+		})
+	}
+}
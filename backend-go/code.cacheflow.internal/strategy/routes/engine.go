@@ -0,0 +1,1000 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"code.cacheflow.internal/datafeed"
+	"code.cacheflow.internal/portfolio/costs"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/strategy/metrics"
+	"code.cacheflow.internal/strategy/sizing"
+)
+
+// ── Portfolio Engine ──────────────────────────────────────────────────────────
+
+// symbolFeed is one symbol's bars and indicators for a simulation.
+type symbolFeed struct {
+	Ticker  string
+	History []dailyBar // warm-up bars followed by the simulated range
+	Start   int        // index of the first simulated bar in History
+	Store   *indicatorStore
+	Frames  map[strategyEntities.Timeframe]*frameFeed // rules on other timeframes
+
+	byDate map[string]int // date → index in History
+}
+
+// frameFeed is a symbol's bars on a timeframe some rules read instead of the
+// strategy's. Aligned maps each bar of the symbol's History to the last frame
+// bar completed by its close, or -1 before the first.
+type frameFeed struct {
+	History []dailyBar
+	Store   *indicatorStore
+	Aligned []int
+}
+
+// alignFrame maps each bar of history, lasting barLen, to the last bar of
+// frame, lasting frameLen, completed by its close. A frame bar counts as
+// complete once the next bar of history opens at or after its end, so a weekly
+// bar is visible from the close of the week's last trading day; the final bar
+// of history only sees frame bars that ended by its own end.
+func alignFrame(history []dailyBar, barLen time.Duration, frame []dailyBar, frameLen time.Duration) []int {
+	aligned := make([]int, len(history))
+	j := -1
+	for i := range history {
+		cutoff := history[i].TsMs + barLen.Milliseconds()
+		if i+1 < len(history) {
+			cutoff = history[i+1].TsMs
+		}
+		for j+1 < len(frame) && frame[j+1].TsMs+frameLen.Milliseconds() <= cutoff {
+			j++
+		}
+		aligned[i] = j
+	}
+	return aligned
+}
+
+func (f *symbolFeed) prevDate(i int) string {
+	if i == 0 {
+		return ""
+	}
+	return f.History[i-1].Date
+}
+
+// sessionEnd reports whether bar i is the symbol's last of its trading day.
+func (f *symbolFeed) sessionEnd(i int) bool {
+	return i == len(f.History)-1 || sessionDate(f.History[i+1].Date) != sessionDate(f.History[i].Date)
+}
+
+// lot is one entry into a position. EntryPrice is the fill price after
+// slippage; EntryCosts is the commission and fees paid to open it.
+type lot struct {
+	Number     int // entry number within the position, from 1
+	Shares     float64
+	EntryPrice float64
+	EntryCosts float64
+	EntryDate  string
+	Borrow     float64 // stock loan fees accrued so far (shorts only)
+}
+
+// position is an open holding in one symbol, made of one lot per entry and
+// closed first in, first out. Shares, EntryPrice (share-weighted), EntryCosts
+// and Borrow total the lots. PeakPrice is the most favourable price since the
+// first entry, the highest for a long and the lowest for a short, and anchors
+// trailing stops.
+//
+// Shorts are cash-secured: opening one sets aside its entry notional, and it is
+// worth that collateral plus the gain since entry, less accrued borrow fees.
+type position struct {
+	Shares     float64
+	EntryPrice float64
+	PeakPrice  float64
+	EntryCosts float64
+	EntryDate  string
+	SignalDate string // bar whose close raised the first entry; ATR stops use its ATR
+	Short      bool
+	Borrow     float64 // stock loan fees accrued so far (shorts only)
+	BorrowedTo string  // date borrow fees are accrued through
+
+	Lots    []lot
+	Entries int          // lots opened so far, including closed ones
+	Taken   map[int]bool // partial sell conditions already filled, by index
+}
+
+// sync recomputes the totals from the lots.
+func (p *position) sync() {
+	p.Shares, p.EntryCosts, p.Borrow = 0, 0, 0
+	var notional float64
+	for _, l := range p.Lots {
+		p.Shares += l.Shares
+		p.EntryCosts += l.EntryCosts
+		p.Borrow += l.Borrow
+		notional += l.Shares * l.EntryPrice
+	}
+	if p.Shares > 0 {
+		p.EntryPrice = notional / p.Shares
+	}
+}
+
+// value is what the position adds to equity at price.
+func (p *position) value(price float64) float64 {
+	if p.Short {
+		return p.Shares*(2*p.EntryPrice-price) - p.Borrow
+	}
+	return p.Shares * price
+}
+
+// gain is the % move from the entry price in the position's favour.
+func (p *position) gain(price float64) float64 {
+	if p.Short {
+		return (p.EntryPrice - price) / p.EntryPrice * 100
+	}
+	return (price - p.EntryPrice) / p.EntryPrice * 100
+}
+
+// retrace is the % move back against the position from PeakPrice.
+func (p *position) retrace(price float64) float64 {
+	if p.Short {
+		return (price - p.PeakPrice) / p.PeakPrice * 100
+	}
+	return (p.PeakPrice - price) / p.PeakPrice * 100
+}
+
+// track moves PeakPrice to price when price is more favourable.
+func (p *position) track(price float64) {
+	if p.Short {
+		p.PeakPrice = math.Min(p.PeakPrice, price)
+	} else {
+		p.PeakPrice = math.Max(p.PeakPrice, price)
+	}
+}
+
+// backtestRun holds the parameters of one simulation.
+type backtestRun struct {
+	Tickers        []string
+	From           string
+	To             string
+	InitialBalance float64
+	Costs          *costs.Model // nil = frictionless execution
+	Execution      strategyEntities.ExecutionModel
+	BuyAndHold     bool   // also hold the universe passively over the same dates
+	Benchmark      string // optional index ticker to compare against, e.g. SPY
+
+	// Progress, when set, receives the percent complete as the run advances
+	Progress func(percent float64)
+}
+
+// loadShare is the part of a run's progress spent fetching data.
+const loadShare = 50.0
+
+func (r *backtestRun) report(percent float64) {
+	if r.Progress != nil {
+		r.Progress(percent)
+	}
+}
+
+type backtestResult struct {
+	FinalBalance    float64
+	ROI             float64
+	TotalTrades     int
+	WinningTrades   int
+	LosingTrades    int
+	MaxDrawdown     float64
+	TotalCommission float64
+	TotalFees       float64
+	TotalSlippage   float64
+	TotalBorrow     float64
+	Trades          []strategyEntities.BacktestTrade
+	Symbols         []strategyEntities.BacktestSymbolResult
+	Equity          []metrics.Point // end-of-day equity on every calendar date
+	Metrics         metrics.Stats
+	RoundTrips      []metrics.Trade
+	BuyAndHold      *strategyEntities.BacktestComparison
+	Benchmark       *strategyEntities.BacktestComparison
+}
+
+func loadSymbolFeed(ctx context.Context, provider datafeed.MarketDataProvider, strategy *strategyEntities.StrategyEntity, ticker, warmFrom, from, to string) (*symbolFeed, error) {
+	history, err := fetchBars(ctx, provider, ticker, strategy.Timeframe, warmFrom, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch bars for %s: %w", ticker, err)
+	}
+
+	start := sort.Search(len(history), func(i int) bool { return history[i].Date >= from })
+	if start == len(history) {
+		return nil, nil
+	}
+
+	feed := &symbolFeed{
+		Ticker:  ticker,
+		History: history,
+		Start:   start,
+		Store:   loadIndicators(strategy, strategyTimeframe(strategy), history),
+		byDate:  make(map[string]int, len(history)-start),
+	}
+	for i := start; i < len(history); i++ {
+		feed.byDate[history[i].Date] = i
+	}
+
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date %q", from)
+	}
+	for _, tf := range ruleTimeframes(strategy) {
+		multiplier, timespan := tf.Bars()
+		frameFrom := datafeed.WarmupFrom(fromDate, timespan, frameLookback(strategy, tf)*multiplier).Format("2006-01-02")
+		bars, err := fetchBars(ctx, provider, ticker, tf, frameFrom, to)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch %s bars for %s: %w", tf, ticker, err)
+		}
+		if feed.Frames == nil {
+			feed.Frames = make(map[strategyEntities.Timeframe]*frameFeed)
+		}
+		feed.Frames[tf] = &frameFeed{
+			History: bars,
+			Store:   loadIndicators(strategy, tf, bars),
+			Aligned: alignFrame(history, strategyTimeframe(strategy).Duration(), bars, tf.Duration()),
+		}
+	}
+	return feed, nil
+}
+
+// closeExitTriggered reports whether a sell condition or the position's exit
+// rule tree fires on the close of bar i of f. Price exits compare against the
+// close only.
+func closeExitTriggered(strategy *strategyEntities.StrategyEntity, pos *position, f *symbolFeed, i int) bool {
+	bar := &f.History[i]
+	for _, sc := range strategy.SellConditions {
+		if !sc.Partial() && priceExitHit(sc, pos, bar.Close, f.Store, bar.Date) {
+			return true
+		}
+	}
+	return timeExitDue(strategy.SellConditions, pos, f, i) || signalExitTriggered(strategy, pos.Short, f, i)
+}
+
+// priceExitHit reports whether a price sell condition holds at price. asOf is
+// the latest completed bar, as for stopLevel.
+func priceExitHit(sc strategyEntities.SellCondition, pos *position, price float64, store *indicatorStore, asOf string) bool {
+	switch sc.Type {
+	case strategyEntities.SellTakeProfit:
+		return pos.gain(price) >= sc.Percent
+	case strategyEntities.SellStopLoss:
+		return pos.gain(price) <= -sc.Percent
+	case strategyEntities.SellTrailingStop:
+		return pos.PeakPrice > 0 && pos.retrace(price) >= sc.Percent
+	}
+	level, stop, ok := stopLevel(sc, pos, store, asOf)
+	if !ok || !stop {
+		return false
+	}
+	if pos.Short {
+		return price >= level
+	}
+	return price <= level
+}
+
+// partialExitsTriggered returns the indices of the partial sell conditions not
+// yet taken that hold at the close of bar i of f.
+func partialExitsTriggered(strategy *strategyEntities.StrategyEntity, pos *position, f *symbolFeed, i int) []int {
+	bar := &f.History[i]
+	var hit []int
+	for j, sc := range strategy.SellConditions {
+		if sc.Partial() && !pos.Taken[j] && priceExitHit(sc, pos, bar.Close, f.Store, bar.Date) {
+			hit = append(hit, j)
+		}
+	}
+	return hit
+}
+
+// timeExitDue reports whether a holding-period or dated sell condition falls
+// due on bar i of f.
+func timeExitDue(conditions []strategyEntities.SellCondition, pos *position, f *symbolFeed, i int) bool {
+	date := f.History[i].Date
+	for _, sc := range conditions {
+		switch sc.Type {
+		case strategyEntities.SellMaxHolding:
+			if sc.Bars > 0 && barsHeld(f, pos, i) >= sc.Bars || sc.Days > 0 && daysBetween(pos.EntryDate, date) >= sc.Days {
+				return true
+			}
+		case strategyEntities.SellExitOnDate:
+			if date >= sc.Date {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// barsHeld counts the bars of f after the position's first entry, through i.
+func barsHeld(f *symbolFeed, pos *position, i int) int {
+	entry := sort.Search(len(f.History), func(k int) bool { return f.History[k].Date >= pos.EntryDate })
+	return i - entry
+}
+
+// signalExitTriggered reports whether an indicator sell condition or the exit
+// rule tree fires on bar i of f. Shorts only look at the short exit tree.
+func signalExitTriggered(strategy *strategyEntities.StrategyEntity, short bool, f *symbolFeed, i int) bool {
+	if short {
+		return f.met(strategy.ShortExitRules, i)
+	}
+	for _, sc := range strategy.SellConditions {
+		if sc.Type == strategyEntities.SellIndicator && sc.Rule != nil && f.ruleMet(*sc.Rule, i) {
+			return true
+		}
+	}
+	return f.met(strategy.ExitRules, i)
+}
+
+// exitsConfigured reports whether anything can close a position on the side.
+func exitsConfigured(strategy *strategyEntities.StrategyEntity, short bool) bool {
+	for _, sc := range strategy.SellConditions {
+		if !short || sc.Type != strategyEntities.SellIndicator {
+			return true
+		}
+	}
+	if short {
+		return strategy.ShortExitRules != nil
+	}
+	return strategy.ExitRules != nil
+}
+
+// stopLevel returns the price of the order a price sell condition rests
+// against the position and whether it is a stop rather than a target; ok is
+// false while the condition has no level, such as a break-even stop not yet
+// armed. ATR stops use the ATR of the entry signal's bar and chandelier exits
+// that of asOf, the latest completed bar.
+func stopLevel(sc strategyEntities.SellCondition, pos *position, store *indicatorStore, asOf string) (level float64, stop, ok bool) {
+	dir := 1.0 // the side of the entry a target lies on
+	if pos.Short {
+		dir = -1
+	}
+	switch sc.Type {
+	case strategyEntities.SellTakeProfit:
+		return pos.EntryPrice * (1 + dir*sc.Percent/100), false, true
+	case strategyEntities.SellStopLoss:
+		return pos.EntryPrice * (1 - dir*sc.Percent/100), true, true
+	case strategyEntities.SellTrailingStop:
+		return pos.PeakPrice * (1 - dir*sc.Percent/100), true, true
+	case strategyEntities.SellBreakEven:
+		return pos.EntryPrice, true, pos.PeakPrice > 0 && pos.gain(pos.PeakPrice) >= sc.Percent
+	case strategyEntities.SellATRStop:
+		atr, ok := store.get(stopATRKey(sc), pos.SignalDate)
+		return pos.EntryPrice - dir*sc.ATRMultiple*atr, true, ok
+	case strategyEntities.SellChandelier:
+		atr, ok := store.get(stopATRKey(sc), asOf)
+		return pos.PeakPrice - dir*sc.ATRMultiple*atr, true, ok && pos.PeakPrice > 0
+	}
+	return 0, false, false
+}
+
+// stopATRKey is the indicator store key of an ATR-based sell condition.
+func stopATRKey(sc strategyEntities.SellCondition) string {
+	return fmt.Sprintf("ATR_%d", windowOr(sc.ATRWindow, 14))
+}
+
+// stopFill returns the price at which the price exits among conditions fill
+// inside bar. Levels come from the entry price and the peak and ATR of earlier
+// bars, asOf being the last of them. A bar that opens through a level fills at
+// the open; when both a stop and a target lie inside the range the stop is
+// assumed to have traded first.
+func stopFill(conditions []strategyEntities.SellCondition, pos *position, bar *dailyBar, store *indicatorStore, asOf string) (float64, bool) {
+	if pos.Short {
+		return shortStopFill(conditions, pos, bar, store, asOf)
+	}
+	stop, target := 0.0, math.Inf(1)
+	for _, sc := range conditions {
+		level, isStop, ok := stopLevel(sc, pos, store, asOf)
+		switch {
+		case !ok:
+		case isStop:
+			stop = math.Max(stop, level)
+		default:
+			target = math.Min(target, level)
+		}
+	}
+
+	switch {
+	case stop > 0 && bar.Open <= stop, bar.Open >= target:
+		return bar.Open, true
+	case stop > 0 && bar.Low <= stop:
+		return stop, true
+	case bar.High >= target:
+		return target, true
+	}
+	return 0, false
+}
+
+// shortStopFill is stopFill for a short: stops rest above the price and
+// targets below it.
+func shortStopFill(conditions []strategyEntities.SellCondition, pos *position, bar *dailyBar, store *indicatorStore, asOf string) (float64, bool) {
+	stop, target := math.Inf(1), 0.0
+	for _, sc := range conditions {
+		level, isStop, ok := stopLevel(sc, pos, store, asOf)
+		switch {
+		case !ok:
+		case isStop:
+			stop = math.Min(stop, level)
+		default:
+			target = math.Max(target, level)
+		}
+	}
+
+	switch {
+	case bar.Open >= stop, target > 0 && bar.Open <= target:
+		return bar.Open, true
+	case bar.High >= stop:
+		return stop, true
+	case target > 0 && bar.Low <= target:
+		return target, true
+	}
+	return 0, false
+}
+
+// simulation is the mutable state of one backtest run.
+type simulation struct {
+	strategy     *strategyEntities.StrategyEntity
+	run          backtestRun
+	maxPositions int
+	atrKey       string
+
+	cash          float64
+	positions     map[string]*position
+	lastClose     map[string]float64
+	trades        []strategyEntities.BacktestTrade
+	closedReturns []float64
+	roundTrips    []metrics.Trade
+	curve         []metrics.Point
+}
+
+// equity is cash plus open positions marked at their last close.
+func (s *simulation) equity() float64 {
+	equity := s.cash
+	for ticker, pos := range s.positions {
+		equity += pos.value(s.lastClose[ticker])
+	}
+	return equity
+}
+
+// accrueBorrow charges the stock loan fee on every open short through date.
+func (s *simulation) accrueBorrow(date string) {
+	for _, pos := range s.positions {
+		if !pos.Short || date <= pos.BorrowedTo {
+			continue
+		}
+		days := daysBetween(pos.BorrowedTo, date)
+		for i := range pos.Lots {
+			l := &pos.Lots[i]
+			l.Borrow += s.run.Costs.Borrow(l.Shares*l.EntryPrice, days)
+		}
+		pos.BorrowedTo = date
+		pos.sync()
+	}
+}
+
+// daysBetween counts the calendar days from one bar key's date to another's.
+func daysBetween(from, to string) int {
+	a, errA := time.Parse("2006-01-02", sessionDate(from))
+	b, errB := time.Parse("2006-01-02", sessionDate(to))
+	if errA != nil || errB != nil {
+		return 0
+	}
+	return int(b.Sub(a).Hours() / 24)
+}
+
+// enter opens a position in f at price, or adds a lot to the open one, sized
+// by the strategy's sizing model: a long buys shares, a short sells borrowed
+// ones and sets the proceeds aside as collateral. signalDate is the bar that
+// raised the entry. It reports whether anything traded.
+func (s *simulation) enter(f *symbolFeed, bar *dailyBar, price, equity float64, short bool, signalDate string) bool {
+	side, tradeType := costs.Buy, "BUY"
+	if short {
+		side, tradeType = costs.Sell, "SHORT"
+	}
+
+	atr, _ := f.Store.get(s.atrKey, bar.Date)
+	qty := sizing.Shares(s.strategy.PositionSizing, sizing.Inputs{
+		Price:        s.run.Costs.Apply(side, price, 1).Price,
+		Cash:         s.cash,
+		Equity:       equity,
+		FreeSlots:    s.maxPositions - len(s.positions),
+		ATR:          atr,
+		ClosedReturn: s.closedReturns,
+	})
+	fill, qty := affordableFill(s.run.Costs, side, price, qty, s.cash)
+	if qty < 1 {
+		return false
+	}
+
+	cost := qty * fill.Price
+	s.cash -= cost + fill.Costs()
+	pos := s.positions[f.Ticker]
+	if pos == nil {
+		pos = &position{PeakPrice: price, EntryDate: bar.Date, SignalDate: signalDate, Short: short, BorrowedTo: bar.Date}
+		s.positions[f.Ticker] = pos
+	}
+	pos.Entries++
+	pos.Lots = append(pos.Lots, lot{
+		Number:     pos.Entries,
+		Shares:     qty,
+		EntryPrice: fill.Price,
+		EntryCosts: fill.Costs(),
+		EntryDate:  bar.Date,
+	})
+	pos.sync()
+	s.trades = append(s.trades, strategyEntities.BacktestTrade{
+		Ticker:     f.Ticker,
+		Type:       tradeType,
+		Date:       bar.Date,
+		Price:      fill.Price,
+		Shares:     qty,
+		Value:      cost,
+		CashAfter:  s.cash,
+		Commission: fill.Commission,
+		Fees:       fill.Fees,
+		Slippage:   fill.Slippage,
+		Lot:        pos.Entries,
+	})
+	return true
+}
+
+// exit closes the position in f at price: a long sells, a short buys back its
+// shares and pays the borrow fees accrued on them.
+func (s *simulation) exit(f *symbolFeed, bar *dailyBar, price float64) {
+	s.reduce(f, bar, price, s.positions[f.Ticker].Shares)
+}
+
+// reduce closes qty shares of the position in f at price, oldest lots first.
+// The order's costs are split across the lots it closes by shares, and each
+// lot closed records its own trade and round trip.
+func (s *simulation) reduce(f *symbolFeed, bar *dailyBar, price, qty float64) {
+	pos := s.positions[f.Ticker]
+	side, tradeType := costs.Sell, "SELL"
+	if pos.Short {
+		side, tradeType = costs.Buy, "COVER"
+	}
+
+	qty = math.Min(qty, pos.Shares)
+	fill := s.run.Costs.Apply(side, price, qty)
+	remaining := qty
+	for remaining > 0 && len(pos.Lots) > 0 {
+		l := &pos.Lots[0]
+		n := math.Min(l.Shares, remaining)
+		share, used := n/qty, n/l.Shares
+		entryCosts, borrow := l.EntryCosts*used, l.Borrow*used
+
+		value := n * fill.Price
+		if pos.Short {
+			value = n*(2*l.EntryPrice-fill.Price) - borrow
+		}
+		proceeds := value - fill.Costs()*share
+		s.cash += proceeds
+		basis := n*l.EntryPrice + entryCosts
+		pnl := proceeds - basis
+		s.trades = append(s.trades, strategyEntities.BacktestTrade{
+			Ticker:     f.Ticker,
+			Type:       tradeType,
+			Date:       bar.Date,
+			Price:      fill.Price,
+			Shares:     n,
+			Value:      n * fill.Price,
+			PnL:        pnl,
+			PnLPercent: pnl / basis * 100,
+			CashAfter:  s.cash,
+			Commission: fill.Commission * share,
+			Fees:       fill.Fees * share,
+			Slippage:   fill.Slippage * share,
+			Borrow:     borrow,
+			Lot:        l.Number,
+			EntryDate:  l.EntryDate,
+		})
+		s.closedReturns = append(s.closedReturns, pnl/basis*100)
+		s.roundTrips = append(s.roundTrips, metrics.Trade{
+			EntryDate:  sessionDate(l.EntryDate),
+			ExitDate:   sessionDate(bar.Date),
+			PnL:        pnl,
+			PnLPercent: pnl / basis * 100,
+		})
+
+		remaining -= n
+		l.Shares -= n
+		l.EntryCosts -= entryCosts
+		l.Borrow -= borrow
+		if l.Shares <= 0 {
+			pos.Lots = pos.Lots[1:]
+		}
+	}
+
+	pos.sync()
+	if len(pos.Lots) == 0 {
+		delete(s.positions, f.Ticker)
+	}
+}
+
+// partialExit closes the share of the position set by the partial sell
+// condition at index i, at least one share, and marks the condition taken.
+func (s *simulation) partialExit(f *symbolFeed, bar *dailyBar, price float64, i int) {
+	pos := s.positions[f.Ticker]
+	if pos.Taken == nil {
+		pos.Taken = make(map[int]bool)
+	}
+	pos.Taken[i] = true
+	qty := math.Max(1, math.Floor(pos.Shares*s.strategy.SellConditions[i].ClosePercent/100))
+	s.reduce(f, bar, price, qty)
+}
+
+// runBacktestEngine simulates the strategy over a universe of tickers sharing
+// one cash balance, sizing entries with the strategy's PositionSizing model.
+// The default model splits the remaining cash evenly across free slots, so a
+// single-ticker run invests all available cash.
+//
+// Under SAME_CLOSE execution signals fill at the close of the bar that raised
+// them and price exits look at the close only. Under NEXT_OPEN, signals raised
+// at a close fill at the symbol's next open, and price exits work intrabar
+// against the high and low. Holding-period and dated exits fill at the close,
+// or under NEXT_OPEN the open, of the bar they fall due on. Either way exits
+// are handled before entries, and open positions are closed on a symbol's last
+// bar when any exit is configured for their side.
+//
+// With MaxEntries above one, entry signals on a held symbol add lots to its
+// position on the same side. Partial sell conditions close their share of the
+// position once, after the full exits have been checked.
+//
+// Bars are those of the strategy's Timeframe. Intraday runs still record one
+// equity point per trading day; with FlattenAtClose they close every position
+// at the close of a symbol's last bar of the day.
+//
+// The strategy's Direction decides which entry trees are checked; a symbol
+// whose long and short entries fire together goes long. Shorts pay the cost
+// model's borrow rate for every calendar day they are open.
+func runBacktestEngine(ctx context.Context, provider datafeed.MarketDataProvider, strategy *strategyEntities.StrategyEntity, run backtestRun) (*backtestResult, error) {
+	tickers, from, to, initialBalance := run.Tickers, run.From, run.To, run.InitialBalance
+
+	fromDate, err := time.Parse("2006-01-02", from)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date %q", from)
+	}
+
+	// Fetch warm-up history ahead of `from` so indicators have values on the
+	// first simulated bar.
+	multiplier, timespan := strategy.Timeframe.Bars()
+	warmFrom := datafeed.WarmupFrom(fromDate, timespan, strategyLookback(strategy)*multiplier).Format("2006-01-02")
+
+	var feeds []*symbolFeed
+	for n, ticker := range tickers {
+		feed, err := loadSymbolFeed(ctx, provider, strategy, ticker, warmFrom, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if feed != nil {
+			feeds = append(feeds, feed)
+		}
+		run.report(float64(n+1) / float64(len(tickers)) * loadShare)
+	}
+	if len(feeds) == 0 {
+		return nil, fmt.Errorf("no price data for %s between %s and %s", strings.Join(tickers, ", "), from, to)
+	}
+
+	// Trading calendar is the union of every symbol's simulated dates
+	seen := make(map[string]bool)
+	var calendar []string
+	for _, f := range feeds {
+		for _, b := range f.History[f.Start:] {
+			if !seen[b.Date] {
+				seen[b.Date] = true
+				calendar = append(calendar, b.Date)
+			}
+		}
+	}
+	sort.Strings(calendar)
+
+	// Its trading days, which the equity curve and comparisons are kept by
+	var sessions []string
+	for _, date := range calendar {
+		if n := len(sessions); n == 0 || sessions[n-1] != sessionDate(date) {
+			sessions = append(sessions, sessionDate(date))
+		}
+	}
+
+	maxPositions := strategy.MaxPositions
+	if maxPositions <= 0 || maxPositions > len(feeds) {
+		maxPositions = len(feeds)
+	}
+	nextOpen := run.Execution == strategyEntities.ExecutionNextOpen
+	flatten := strategy.FlattenAtClose && strategy.Timeframe.Intraday()
+	maxEntries := max(strategy.MaxEntries, 1)
+	var fullStops []strategyEntities.SellCondition
+	for _, sc := range strategy.SellConditions {
+		if !sc.Partial() {
+			fullStops = append(fullStops, sc)
+		}
+	}
+	var entry, shortEntry *strategyEntities.RuleGroup
+	if strategy.Direction.Long() {
+		entry = strategy.Entry()
+	}
+	if strategy.Direction.Short() {
+		shortEntry = strategy.ShortEntryRules
+	}
+
+	sim := &simulation{
+		strategy:     strategy,
+		run:          run,
+		maxPositions: maxPositions,
+		atrKey:       fmt.Sprintf("ATR_%d", sizing.ATRWindow(strategy.PositionSizing)),
+		cash:         initialBalance,
+		positions:    make(map[string]*position),
+		lastClose:    make(map[string]float64),
+	}
+
+	// Orders raised at a close, waiting for the symbol's next open (NEXT_OPEN
+	// only). Entries map to whether they open a short. An order stays pending
+	// through dates the symbol has no bar.
+	pendingEntry := make(map[string]bool)
+	pendingExit := make(map[string]bool)
+
+	peakEquity := initialBalance
+	maxDrawdown := 0.0
+
+	for day, date := range calendar {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		run.report(loadShare + float64(day)/float64(len(calendar))*(100-loadShare))
+
+		for _, f := range feeds {
+			if i, ok := f.byDate[date]; ok {
+				sim.lastClose[f.Ticker] = f.History[i].Close
+			}
+		}
+		sim.accrueBorrow(date)
+		equity := sim.equity()
+		if equity > peakEquity {
+			peakEquity = equity
+		}
+		if peakEquity > 0 {
+			dd := (peakEquity - equity) / peakEquity * 100
+			if dd > maxDrawdown {
+				maxDrawdown = dd
+			}
+		}
+
+		exited := make(map[string]bool)
+
+		if nextOpen {
+			// Earlier signals fill at the symbol's next open, exits first,
+			// along with time exits falling due today
+			for _, f := range feeds {
+				i, ok := f.byDate[date]
+				if !ok {
+					continue
+				}
+				pending := pendingExit[f.Ticker]
+				delete(pendingExit, f.Ticker)
+				pos := sim.positions[f.Ticker]
+				if pos == nil || !pending && !timeExitDue(strategy.SellConditions, pos, f, i) {
+					continue
+				}
+				bar := f.History[i]
+				sim.exit(f, &bar, bar.Open)
+				exited[f.Ticker] = true
+			}
+			for _, f := range feeds {
+				i, ok := f.byDate[date]
+				short, pending := pendingEntry[f.Ticker]
+				if !ok || !pending {
+					continue
+				}
+				delete(pendingEntry, f.Ticker)
+				pos := sim.positions[f.Ticker]
+				if pos == nil && len(sim.positions) >= maxPositions || pos != nil && (pos.Short != short || pos.Entries >= maxEntries) {
+					continue
+				}
+				bar := f.History[i]
+				sim.enter(f, &bar, bar.Open, equity, short, f.prevDate(i))
+			}
+
+			// Resting stops and targets trade intrabar
+			for _, f := range feeds {
+				pos := sim.positions[f.Ticker]
+				i, ok := f.byDate[date]
+				if pos == nil || !ok {
+					continue
+				}
+				bar := f.History[i]
+				if price, hit := stopFill(fullStops, pos, &bar, f.Store, f.prevDate(i)); hit {
+					sim.exit(f, &bar, price)
+					exited[f.Ticker] = true
+					continue
+				}
+				for j, sc := range strategy.SellConditions {
+					if !sc.Partial() || pos.Taken[j] || sim.positions[f.Ticker] == nil {
+						continue
+					}
+					if price, hit := stopFill([]strategyEntities.SellCondition{sc}, pos, &bar, f.Store, f.prevDate(i)); hit {
+						sim.partialExit(f, &bar, price, j)
+					}
+				}
+				if sim.positions[f.Ticker] == nil {
+					exited[f.Ticker] = true
+					continue
+				}
+				if pos.Short {
+					pos.track(bar.Low)
+				} else {
+					pos.track(bar.High)
+				}
+			}
+		}
+
+		// Signals on the close
+		for _, f := range feeds {
+			pos := sim.positions[f.Ticker]
+			i, ok := f.byDate[date]
+			if pos == nil || !ok {
+				continue
+			}
+			bar := f.History[i]
+
+			if !nextOpen {
+				// Update trailing stop peak
+				pos.track(bar.Close)
+			}
+
+			if flatten && f.sessionEnd(i) {
+				sim.exit(f, &bar, bar.Close)
+				exited[f.Ticker] = true
+				continue
+			}
+
+			// No exits configured → hold to end
+			if !exitsConfigured(strategy, pos.Short) {
+				continue
+			}
+
+			switch {
+			case i == len(f.History)-1:
+				// Force close on the symbol's last bar
+				sim.exit(f, &bar, bar.Close)
+				exited[f.Ticker] = true
+			case !nextOpen && closeExitTriggered(strategy, pos, f, i):
+				sim.exit(f, &bar, bar.Close)
+				exited[f.Ticker] = true
+			case nextOpen && signalExitTriggered(strategy, pos.Short, f, i):
+				pendingExit[f.Ticker] = true
+			case !nextOpen:
+				for _, j := range partialExitsTriggered(strategy, pos, f, i) {
+					if sim.positions[f.Ticker] != nil {
+						sim.partialExit(f, &bar, bar.Close, j)
+					}
+				}
+				exited[f.Ticker] = sim.positions[f.Ticker] == nil
+			}
+		}
+
+		opening := 0 // pending entries into symbols not yet held
+		for ticker := range pendingEntry {
+			if sim.positions[ticker] == nil {
+				opening++
+			}
+		}
+		for _, f := range feeds {
+			i, ok := f.byDate[date]
+			if !ok || exited[f.Ticker] || flatten && f.sessionEnd(i) {
+				continue
+			}
+			pos := sim.positions[f.Ticker]
+			if pos == nil && len(sim.positions)+opening >= maxPositions || pos != nil && pos.Entries >= maxEntries {
+				continue
+			}
+			bar := f.History[i]
+			var short bool
+			switch {
+			case (pos == nil || !pos.Short) && f.met(entry, i):
+			case (pos == nil || pos.Short) && f.met(shortEntry, i):
+				short = true
+			default:
+				continue
+			}
+
+			if !nextOpen {
+				sim.enter(f, &bar, bar.Close, equity, short, bar.Date)
+			} else if i < len(f.History)-1 {
+				pendingEntry[f.Ticker] = short
+				if pos == nil {
+					opening++
+				}
+			}
+		}
+
+		// One point per trading day, as of its last bar
+		if day == len(calendar)-1 || sessionDate(calendar[day+1]) != sessionDate(date) {
+			sim.curve = append(sim.curve, metrics.Point{Date: sessionDate(date), Equity: sim.equity(), Cash: sim.cash, Invested: len(sim.positions) > 0})
+		}
+	}
+
+	// Mark-to-market any open positions
+	finalBalance := sim.equity()
+	roi := (finalBalance - initialBalance) / initialBalance * 100
+	trades := sim.trades
+
+	symbols := make([]strategyEntities.BacktestSymbolResult, len(feeds))
+	index := make(map[string]int, len(feeds))
+	for i, f := range feeds {
+		symbols[i].Ticker = f.Ticker
+		index[f.Ticker] = i
+	}
+
+	result := &backtestResult{
+		FinalBalance: finalBalance,
+		ROI:          roi,
+		TotalTrades:  len(trades),
+		MaxDrawdown:  maxDrawdown,
+		Trades:       trades,
+		Symbols:      symbols,
+		Equity:       sim.curve,
+		Metrics:      metrics.Compute(initialBalance, sim.curve, sim.roundTrips),
+		RoundTrips:   sim.roundTrips,
+	}
+	for _, t := range trades {
+		result.TotalCommission += t.Commission
+		result.TotalFees += t.Fees
+		result.TotalSlippage += t.Slippage
+		result.TotalBorrow += t.Borrow
+
+		s := &symbols[index[t.Ticker]]
+		s.TotalTrades++
+		if t.Closes() {
+			s.RealizedPnL += t.PnL
+			if t.PnL >= 0 {
+				result.WinningTrades++
+				s.WinningTrades++
+			} else {
+				result.LosingTrades++
+				s.LosingTrades++
+			}
+		}
+	}
+	for i := range symbols {
+		symbols[i].Contribution = symbols[i].RealizedPnL / initialBalance * 100
+	}
+
+	if run.BuyAndHold {
+		series := make([]map[string]float64, len(feeds))
+		held := make([]string, len(feeds))
+		for i, f := range feeds {
+			held[i] = f.Ticker
+			series[i] = make(map[string]float64, len(f.byDate))
+			for _, b := range f.History[f.Start:] {
+				series[i][sessionDate(b.Date)] = b.Close // the day's last close wins
+			}
+		}
+		curve := holdCurve(series, sessions, initialBalance, run.Costs)
+		result.BuyAndHold = compareCurves(held, initialBalance, sim.curve, curve)
+	}
+	if run.Benchmark != "" {
+		closes, err := benchmarkCloses(ctx, provider, run.Benchmark, from, to)
+		if err != nil {
+			return nil, err
+		}
+		// An index is a reference, not something the strategy would trade: no costs
+		curve := holdCurve([]map[string]float64{closes}, sessions, initialBalance, nil)
+		result.Benchmark = compareCurves([]string{run.Benchmark}, initialBalance, sim.curve, curve)
+	}
+
+	return result, nil
+}
+
+// affordableFill prices an order of qty shares on side, trimming qty until the
+// notional plus commission and fees fits in cash. Shorts set their notional
+// aside as collateral, so the same limit applies to both sides.
+func affordableFill(model *costs.Model, side string, price, qty, cash float64) (costs.Fill, float64) {
+	fill := model.Apply(side, price, qty)
+	for qty >= 1 && qty*fill.Price+fill.Costs() > cash {
+		qty--
+		fill = model.Apply(side, price, qty)
+	}
+	return fill, qty
+}
@@ -0,0 +1,674 @@
+package routes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"code.cacheflow.internal/datafeed"
+	"code.cacheflow.internal/portfolio/costs"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// stubProvider serves canned daily bars keyed by ticker.
+type stubProvider struct {
+	datafeed.MarketDataProvider
+	bars map[string][]datafeed.Bar
+}
+
+func (p *stubProvider) Bars(_ context.Context, r datafeed.BarsRequest) ([]datafeed.Bar, error) {
+	bars, ok := p.bars[r.Ticker+"/"+r.Timespan] // another timeframe, when given
+	if !ok {
+		bars = p.bars[r.Ticker]
+	}
+	var out []datafeed.Bar
+	for _, b := range bars {
+		d := msToDate(b.Timestamp)
+		if d >= r.From && d <= r.To {
+			out = append(out, b)
+		}
+	}
+	return out, nil
+}
+
+// risingBars returns n daily bars from 2024-01-01 closing at start, start+step, ...
+func risingBars(n int, start, step float64) []datafeed.Bar {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	bars := make([]datafeed.Bar, n)
+	for i := range bars {
+		c := start + step*float64(i)
+		bars[i] = datafeed.Bar{
+			Timestamp: day.AddDate(0, 0, i).UnixMilli(),
+			Open:      c,
+			High:      c,
+			Low:       c,
+			Close:     c,
+			Volume:    1000,
+		}
+	}
+	return bars
+}
+
+func momentumStrategy(maxPositions int) *strategyEntities.StrategyEntity {
+	return &strategyEntities.StrategyEntity{
+		BuyRules:       []strategyEntities.Rule{{Type: strategyEntities.RulePriceAboveSMA, Window: 2}},
+		SellConditions: []strategyEntities.SellCondition{{Type: strategyEntities.SellTakeProfit, Percent: 5}},
+		MaxPositions:   maxPositions,
+	}
+}
+
+// testRun simulates 2024-01-10 through 2024-02-09 with $10,000.
+func testRun(tickers ...string) backtestRun {
+	return backtestRun{Tickers: tickers, From: "2024-01-10", To: "2024-02-09", InitialBalance: 10000}
+}
+
+func TestPortfolioBacktestSharesCash(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{
+		"AAA": risingBars(40, 100, 1),
+		"BBB": risingBars(40, 50, 1),
+	}}
+
+	tests := []struct {
+		Name         string
+		MaxPositions int
+		FirstBuys    []string
+	}{
+		{
+			Name:         "One slot goes to the first symbol",
+			MaxPositions: 1,
+			FirstBuys:    []string{"AAA"},
+		},
+		{
+			Name:         "Every symbol gets a slot",
+			MaxPositions: 0,
+			FirstBuys:    []string{"AAA", "BBB"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			result, err := runBacktestEngine(context.Background(), provider, momentumStrategy(tt.MaxPositions), testRun("AAA", "BBB"))
+			assert.NoError(t, err)
+
+			var firstDay []string
+			for _, tr := range result.Trades {
+				if tr.Date == "2024-01-10" && tr.Type == "BUY" {
+					firstDay = append(firstDay, tr.Ticker)
+				}
+				assert.GreaterOrEqual(t, tr.CashAfter, 0.0)
+			}
+			assert.Equal(t, tt.FirstBuys, firstDay)
+			assert.Len(t, result.Symbols, 2)
+			assert.Greater(t, result.FinalBalance, 10000.0)
+		})
+	}
+}
+
+func TestSingleTickerBacktestInvestsAllCash(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(40, 100, 1)}}
+
+	result, err := runBacktestEngine(context.Background(), provider, momentumStrategy(0), testRun("AAA"))
+	assert.NoError(t, err)
+
+	buy := result.Trades[0]
+	assert.Equal(t, "BUY", buy.Type)
+	assert.Equal(t, 109.0, buy.Price) // close on 2024-01-10
+	assert.Equal(t, 91.0, buy.Shares) // floor(10000 / 109)
+}
+
+func TestBacktestAppliesPositionSizing(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(40, 100, 1)}}
+	strategy := momentumStrategy(0)
+	strategy.PositionSizing = &strategyEntities.PositionSizing{Model: strategyEntities.SizingFixedDollar, Amount: 1000}
+
+	result, err := runBacktestEngine(context.Background(), provider, strategy, testRun("AAA"))
+	assert.NoError(t, err)
+	assert.Equal(t, 9.0, result.Trades[0].Shares) // floor(1000 / 109)
+}
+
+func TestBacktestChargesCosts(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(40, 100, 1)}}
+	run := testRun("AAA")
+	run.Costs = &costs.Model{PerTrade: 10, SlippageBps: 10}
+
+	result, err := runBacktestEngine(context.Background(), provider, momentumStrategy(0), run)
+	assert.NoError(t, err)
+
+	buy, sell := result.Trades[0], result.Trades[1]
+	assert.InDelta(t, 109.109, buy.Price, 1e-9)
+	assert.Equal(t, 91.0, buy.Shares) // 91 × 109.109 + 10 fits in $10,000
+	assert.Equal(t, 10.0, buy.Commission)
+
+	basis := buy.Value + buy.Commission
+	assert.InDelta(t, sell.Value-sell.Commission-basis, sell.PnL, 1e-9)
+	assert.InDelta(t, 10*float64(len(result.Trades)), result.TotalCommission, 1e-9)
+}
+
+func TestStopFill(t *testing.T) {
+	strategy := &strategyEntities.StrategyEntity{SellConditions: []strategyEntities.SellCondition{
+		{Type: strategyEntities.SellStopLoss, Percent: 5},
+		{Type: strategyEntities.SellTakeProfit, Percent: 10},
+	}}
+	pos := &position{EntryPrice: 100, PeakPrice: 100}
+
+	tests := []struct {
+		Name   string
+		Bar    dailyBar
+		Hit    bool
+		Expect float64
+	}{
+		{
+			Name: "Inside both levels",
+			Bar:  dailyBar{Open: 100, High: 104, Low: 96, Close: 101},
+			Hit:  false,
+		},
+		{
+			Name:   "Gap down through the stop fills at the open",
+			Bar:    dailyBar{Open: 90, High: 92, Low: 88, Close: 91},
+			Hit:    true,
+			Expect: 90,
+		},
+		{
+			Name:   "Intrabar stop fills at the stop",
+			Bar:    dailyBar{Open: 99, High: 100, Low: 94, Close: 98},
+			Hit:    true,
+			Expect: 95,
+		},
+		{
+			Name:   "Gap up through the target fills at the open",
+			Bar:    dailyBar{Open: 112, High: 115, Low: 111, Close: 113},
+			Hit:    true,
+			Expect: 112,
+		},
+		{
+			Name:   "Stop is assumed first when both are inside the bar",
+			Bar:    dailyBar{Open: 100, High: 111, Low: 94, Close: 100},
+			Hit:    true,
+			Expect: 95,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			price, hit := stopFill(strategy.SellConditions, pos, &tt.Bar, nil, "")
+			assert.Equal(t, tt.Hit, hit)
+			if tt.Hit {
+				assert.InDelta(t, tt.Expect, price, 1e-9)
+			}
+		})
+	}
+}
+
+func TestShortStopFill(t *testing.T) {
+	strategy := &strategyEntities.StrategyEntity{SellConditions: []strategyEntities.SellCondition{
+		{Type: strategyEntities.SellStopLoss, Percent: 5},
+		{Type: strategyEntities.SellTakeProfit, Percent: 10},
+		{Type: strategyEntities.SellTrailingStop, Percent: 8},
+	}}
+	pos := &position{EntryPrice: 100, PeakPrice: 95, Short: true}
+
+	tests := []struct {
+		Name   string
+		Bar    dailyBar
+		Hit    bool
+		Expect float64
+	}{
+		{
+			Name: "Inside both levels",
+			Bar:  dailyBar{Open: 100, High: 102, Low: 92, Close: 96},
+			Hit:  false,
+		},
+		{
+			Name:   "Gap up through the stop fills at the open",
+			Bar:    dailyBar{Open: 108, High: 109, Low: 107, Close: 108},
+			Hit:    true,
+			Expect: 108,
+		},
+		{
+			Name:   "Trailing stop sits above the trough",
+			Bar:    dailyBar{Open: 100, High: 103, Low: 99, Close: 101},
+			Hit:    true,
+			Expect: 102.6,
+		},
+		{
+			Name:   "Intrabar target fills at the target",
+			Bar:    dailyBar{Open: 93, High: 94, Low: 88, Close: 89},
+			Hit:    true,
+			Expect: 90,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			price, hit := stopFill(strategy.SellConditions, pos, &tt.Bar, nil, "")
+			assert.Equal(t, tt.Hit, hit)
+			if tt.Hit {
+				assert.InDelta(t, tt.Expect, price, 1e-9)
+			}
+		})
+	}
+}
+
+func TestVolatilityStopFill(t *testing.T) {
+	store := &indicatorStore{simple: map[string]map[string]float64{
+		"ATR_14": {"2024-01-02": 2, "2024-01-05": 3},
+	}}
+
+	tests := []struct {
+		Name      string
+		Condition strategyEntities.SellCondition
+		Position  position
+		Bar       dailyBar
+		Hit       bool
+		Expect    float64
+	}{
+		{
+			Name:      "ATR stop is measured in the entry signal's ATR",
+			Condition: strategyEntities.SellCondition{Type: strategyEntities.SellATRStop, ATRMultiple: 2},
+			Position:  position{EntryPrice: 100, PeakPrice: 110, SignalDate: "2024-01-02"},
+			Bar:       dailyBar{Open: 99, High: 100, Low: 95, Close: 97},
+			Hit:       true,
+			Expect:    96,
+		},
+		{
+			Name:      "Chandelier trails the peak by the latest ATR",
+			Condition: strategyEntities.SellCondition{Type: strategyEntities.SellChandelier, ATRMultiple: 3},
+			Position:  position{EntryPrice: 100, PeakPrice: 110, SignalDate: "2024-01-02"},
+			Bar:       dailyBar{Open: 104, High: 105, Low: 100, Close: 102},
+			Hit:       true,
+			Expect:    101,
+		},
+		{
+			Name:      "Break-even waits for its gain",
+			Condition: strategyEntities.SellCondition{Type: strategyEntities.SellBreakEven, Percent: 10},
+			Position:  position{EntryPrice: 100, PeakPrice: 108},
+			Bar:       dailyBar{Open: 100, High: 101, Low: 95, Close: 96},
+			Hit:       false,
+		},
+		{
+			Name:      "Armed break-even stops at the entry",
+			Condition: strategyEntities.SellCondition{Type: strategyEntities.SellBreakEven, Percent: 5},
+			Position:  position{EntryPrice: 100, PeakPrice: 108},
+			Bar:       dailyBar{Open: 102, High: 103, Low: 98, Close: 99},
+			Hit:       true,
+			Expect:    100,
+		},
+		{
+			Name:      "Short ATR stop rests above the entry",
+			Condition: strategyEntities.SellCondition{Type: strategyEntities.SellATRStop, ATRMultiple: 2},
+			Position:  position{EntryPrice: 100, PeakPrice: 95, SignalDate: "2024-01-02", Short: true},
+			Bar:       dailyBar{Open: 101, High: 106, Low: 100, Close: 105},
+			Hit:       true,
+			Expect:    104,
+		},
+		{
+			Name:      "No ATR yet places no stop",
+			Condition: strategyEntities.SellCondition{Type: strategyEntities.SellATRStop, ATRMultiple: 2},
+			Position:  position{EntryPrice: 100, PeakPrice: 100, SignalDate: "2024-01-01"},
+			Bar:       dailyBar{Open: 60, High: 61, Low: 50, Close: 55},
+			Hit:       false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			price, hit := stopFill([]strategyEntities.SellCondition{tt.Condition}, &tt.Position, &tt.Bar, store, "2024-01-05")
+			assert.Equal(t, tt.Hit, hit)
+			if tt.Hit {
+				assert.InDelta(t, tt.Expect, price, 1e-9)
+			}
+		})
+	}
+}
+
+func TestShortBacktest(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(40, 200, -1)}}
+	strategy := &strategyEntities.StrategyEntity{
+		Direction:       strategyEntities.DirectionShort,
+		ShortEntryRules: &strategyEntities.RuleGroup{Rule: &strategyEntities.Rule{Type: strategyEntities.RulePriceBelowSMA, Window: 2}},
+		SellConditions:  []strategyEntities.SellCondition{{Type: strategyEntities.SellTakeProfit, Percent: 5}},
+	}
+	run := testRun("AAA")
+	run.Costs = &costs.Model{BorrowRate: 3.65}
+
+	result, err := runBacktestEngine(context.Background(), provider, strategy, run)
+	assert.NoError(t, err)
+
+	short, cover := result.Trades[0], result.Trades[1]
+	assert.Equal(t, "SHORT", short.Type)
+	assert.Equal(t, 191.0, short.Price) // close on 2024-01-10
+	assert.Equal(t, 52.0, short.Shares) // floor(10000 / 191) set aside as collateral
+	assert.InDelta(t, 10000-52*191.0, short.CashAfter, 1e-9)
+
+	// Covered at 181, 5% below entry, ten days later
+	assert.Equal(t, "COVER", cover.Type)
+	assert.Equal(t, "2024-01-20", cover.Date)
+	assert.InDelta(t, 52*191*0.0365*10/365, cover.Borrow, 1e-9)
+	assert.InDelta(t, 52*(191-181.0)-cover.Borrow, cover.PnL, 1e-9)
+	assert.Greater(t, result.FinalBalance, 10000.0)
+	assert.GreaterOrEqual(t, result.TotalBorrow, cover.Borrow)
+
+	// A long-only strategy with the same rules never trades
+	strategy.Direction = strategyEntities.DirectionLong
+	result, err = runBacktestEngine(context.Background(), provider, strategy, run)
+	assert.NoError(t, err)
+	assert.Empty(t, result.Trades)
+}
+
+func TestPyramidingClosesLotsFirstInFirstOut(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(40, 100, 1)}}
+	strategy := momentumStrategy(0)
+	strategy.MaxEntries = 3
+	strategy.PositionSizing = &strategyEntities.PositionSizing{Model: strategyEntities.SizingFixedDollar, Amount: 1000}
+	run := testRun("AAA")
+	run.Costs = &costs.Model{PerTrade: 9}
+
+	result, err := runBacktestEngine(context.Background(), provider, strategy, run)
+	assert.NoError(t, err)
+
+	// Entries at 109, 110 and 111 average 110, so the 5% target is hit at 116 on the 17th
+	for n, tr := range result.Trades[:3] {
+		assert.Equal(t, "BUY", tr.Type)
+		assert.Equal(t, n+1, tr.Lot)
+		assert.Equal(t, 9.0, tr.Shares)
+	}
+	var commission float64
+	for n, tr := range result.Trades[3:6] {
+		entry := result.Trades[n]
+		assert.Equal(t, "SELL", tr.Type)
+		assert.Equal(t, "2024-01-17", tr.Date)
+		assert.Equal(t, n+1, tr.Lot)
+		assert.Equal(t, entry.Date, tr.EntryDate)
+		assert.InDelta(t, 9*(116-entry.Price)-3-9, tr.PnL, 1e-9) // each lot bears a third of the exit commission
+		commission += tr.Commission
+	}
+	assert.InDelta(t, 9.0, commission, 1e-9)
+}
+
+func TestPartialExit(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(40, 100, 1)}}
+	strategy := momentumStrategy(0)
+	strategy.SellConditions = []strategyEntities.SellCondition{
+		{Type: strategyEntities.SellTakeProfit, Percent: 5, ClosePercent: 50},
+		{Type: strategyEntities.SellTakeProfit, Percent: 10},
+	}
+
+	result, err := runBacktestEngine(context.Background(), provider, strategy, testRun("AAA"))
+	assert.NoError(t, err)
+
+	buy, half, rest := result.Trades[0], result.Trades[1], result.Trades[2]
+	assert.Equal(t, 91.0, buy.Shares)
+
+	// Half at 115, once, then the remainder at 120
+	assert.Equal(t, "SELL", half.Type)
+	assert.Equal(t, "2024-01-16", half.Date)
+	assert.Equal(t, 45.0, half.Shares)
+	assert.InDelta(t, 45*(115-109.0), half.PnL, 1e-9)
+
+	assert.Equal(t, "SELL", rest.Type)
+	assert.Equal(t, "2024-01-21", rest.Date)
+	assert.Equal(t, 46.0, rest.Shares)
+	assert.InDelta(t, 46*(120-109.0), rest.PnL, 1e-9)
+}
+
+func TestTimeExits(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(40, 100, 1)}}
+
+	tests := []struct {
+		Name      string
+		Execution strategyEntities.ExecutionModel
+		Condition strategyEntities.SellCondition
+		ExitDate  string
+		ExitPrice float64
+	}{
+		{
+			Name:      "Bars held",
+			Condition: strategyEntities.SellCondition{Type: strategyEntities.SellMaxHolding, Bars: 3},
+			ExitDate:  "2024-01-13",
+			ExitPrice: 112,
+		},
+		{
+			Name:      "Calendar days held",
+			Condition: strategyEntities.SellCondition{Type: strategyEntities.SellMaxHolding, Days: 5},
+			ExitDate:  "2024-01-15",
+			ExitPrice: 114,
+		},
+		{
+			Name:      "Exit on a date",
+			Condition: strategyEntities.SellCondition{Type: strategyEntities.SellExitOnDate, Date: "2024-01-12"},
+			ExitDate:  "2024-01-12",
+			ExitPrice: 111,
+		},
+		{
+			Name:      "Next open fills at the open the exit falls due",
+			Execution: strategyEntities.ExecutionNextOpen,
+			Condition: strategyEntities.SellCondition{Type: strategyEntities.SellMaxHolding, Bars: 3},
+			ExitDate:  "2024-01-14",
+			ExitPrice: 113,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			strategy := momentumStrategy(0)
+			strategy.SellConditions = []strategyEntities.SellCondition{tt.Condition}
+			run := testRun("AAA")
+			run.Execution = tt.Execution
+
+			result, err := runBacktestEngine(context.Background(), provider, strategy, run)
+			assert.NoError(t, err)
+
+			exit := result.Trades[1]
+			assert.Equal(t, "SELL", exit.Type)
+			assert.Equal(t, tt.ExitDate, exit.Date)
+			assert.Equal(t, tt.ExitPrice, exit.Price)
+		})
+	}
+}
+
+func TestIntradayBacktestFlattensAtClose(t *testing.T) {
+	// Hourly bars from 07:00 to 17:00 exchange time; only 09:00 to 15:00 touch
+	// the regular session
+	var bars []datafeed.Bar
+	price := 100.0
+	for _, day := range []int{10, 11} {
+		for hour := 7; hour <= 17; hour++ {
+			bars = append(bars, datafeed.Bar{
+				Timestamp: time.Date(2024, 1, day, hour, 0, 0, 0, marketZone).UnixMilli(),
+				Open:      price, High: price, Low: price, Close: price, Volume: 1000,
+			})
+			price += 0.1
+		}
+	}
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": bars}}
+	strategy := momentumStrategy(0)
+	strategy.Timeframe = strategyEntities.Timeframe1Hour
+	strategy.FlattenAtClose = true
+	run := backtestRun{Tickers: []string{"AAA"}, From: "2024-01-10", To: "2024-01-11", InitialBalance: 10000}
+
+	result, err := runBacktestEngine(context.Background(), provider, strategy, run)
+	assert.NoError(t, err)
+
+	var trades []string
+	for _, tr := range result.Trades {
+		trades = append(trades, tr.Type+" "+tr.Date)
+	}
+	assert.Equal(t, []string{
+		"BUY 2024-01-10 10:00",
+		"SELL 2024-01-10 15:00",
+		"BUY 2024-01-11 09:00",
+		"SELL 2024-01-11 15:00",
+	}, trades)
+
+	// The curve stays daily
+	assert.Len(t, result.Equity, 2)
+	assert.Equal(t, "2024-01-10", result.Equity[0].Date)
+	assert.False(t, result.Equity[0].Invested)
+}
+
+func TestAlignFrame(t *testing.T) {
+	at := func(day int) dailyBar {
+		return dailyBar{TsMs: time.Date(2024, 1, day, 0, 0, 0, 0, time.UTC).UnixMilli()}
+	}
+	// Monday to Friday and the next Monday, against the weeks from Sundays
+	history := []dailyBar{at(8), at(9), at(10), at(11), at(12), at(15)}
+	weeks := []dailyBar{at(7), at(14)}
+
+	aligned := alignFrame(history, 24*time.Hour, weeks, 7*24*time.Hour)
+	assert.Equal(t, []int{-1, -1, -1, -1, 0, 0}, aligned)
+}
+
+func TestWeeklyRuleSeesCompletedWeeksOnly(t *testing.T) {
+	var weeks []datafeed.Bar
+	for k := 0; k < 8; k++ {
+		c := 100 + 10*float64(k)
+		weeks = append(weeks, datafeed.Bar{
+			Timestamp: time.Date(2023, 12, 31+7*k, 0, 0, 0, 0, time.UTC).UnixMilli(),
+			Open:      c, High: c, Low: c, Close: c, Volume: 5000,
+		})
+	}
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{
+		"AAA":      risingBars(40, 100, 1),
+		"AAA/week": weeks,
+	}}
+	strategy := momentumStrategy(0)
+	strategy.BuyRules[0].Timeframe = strategyEntities.TimeframeWeekly
+
+	result, err := runBacktestEngine(context.Background(), provider, strategy, testRun("AAA"))
+	assert.NoError(t, err)
+
+	// The week from 2024-01-07 is the second one, the first the SMA has a
+	// value for, and completes with the 13th's bar
+	assert.Equal(t, "BUY", result.Trades[0].Type)
+	assert.Equal(t, "2024-01-13", result.Trades[0].Date)
+}
+
+func TestNextOpenExecution(t *testing.T) {
+	bars := risingBars(40, 100, 1)
+	for i := range bars {
+		bars[i].Open = bars[i].Close - 0.5
+		bars[i].High = bars[i].Close + 0.5
+		bars[i].Low = bars[i].Open - 0.5
+	}
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": bars}}
+	run := testRun("AAA")
+	run.Execution = strategyEntities.ExecutionNextOpen
+
+	result, err := runBacktestEngine(context.Background(), provider, momentumStrategy(0), run)
+	assert.NoError(t, err)
+
+	// Signal on the 2024-01-10 close, filled at the 2024-01-11 open
+	buy, sell := result.Trades[0], result.Trades[1]
+	assert.Equal(t, "2024-01-11", buy.Date)
+	assert.Equal(t, 109.5, buy.Price)
+	// 5% target at 114.975 trades intrabar once the high reaches it
+	assert.Equal(t, "SELL", sell.Type)
+	assert.InDelta(t, 114.975, sell.Price, 1e-9)
+}
+
+func TestNextOpenOrdersWaitForTheSymbolsNextBar(t *testing.T) {
+	// AAA rises to 70 on 2024-01-21 and then falls, with no bars on 01-11 or
+	// 01-23; BBB trades every day and never signals
+	var aaa []datafeed.Bar
+	for i, b := range risingBars(40, 50, 1) {
+		if i > 20 {
+			c := 70 - float64(i-20)
+			b.Open, b.High, b.Low, b.Close = c, c, c, c
+		}
+		if d := msToDate(b.Timestamp); d != "2024-01-11" && d != "2024-01-23" {
+			aaa = append(aaa, b)
+		}
+	}
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{
+		"AAA": aaa,
+		"BBB": risingBars(40, 100, 0),
+	}}
+	strategy := &strategyEntities.StrategyEntity{
+		BuyRules:  []strategyEntities.Rule{{Type: strategyEntities.RulePriceAboveSMA, Window: 2}},
+		ExitRules: &strategyEntities.RuleGroup{Rule: &strategyEntities.Rule{Type: strategyEntities.RulePriceBelowSMA, Window: 2}},
+	}
+	run := testRun("AAA", "BBB")
+	run.Execution = strategyEntities.ExecutionNextOpen
+
+	result, err := runBacktestEngine(context.Background(), provider, strategy, run)
+	assert.NoError(t, err)
+
+	// Signalled on the 01-10 and 01-22 closes, filled at AAA's next opens
+	assert.Len(t, result.Trades, 2)
+	buy, sell := result.Trades[0], result.Trades[1]
+	assert.Equal(t, "BUY", buy.Type)
+	assert.Equal(t, "2024-01-12", buy.Date)
+	assert.Equal(t, 61.0, buy.Price)
+	assert.Equal(t, "SELL", sell.Type)
+	assert.Equal(t, "2024-01-24", sell.Date)
+	assert.Equal(t, 67.0, sell.Price)
+}
+
+func TestBacktestRecordsEquityAndMetrics(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(40, 100, 1)}}
+
+	result, err := runBacktestEngine(context.Background(), provider, momentumStrategy(0), testRun("AAA"))
+	assert.NoError(t, err)
+
+	assert.Len(t, result.Equity, 31) // 2024-01-10 through 2024-02-09
+	assert.Equal(t, "2024-01-10", result.Equity[0].Date)
+	assert.Equal(t, result.FinalBalance, result.Equity[len(result.Equity)-1].Equity)
+	assert.Greater(t, result.Metrics.CAGR, 0.0)
+	assert.Greater(t, result.Metrics.Exposure, 0.0)
+	assert.Greater(t, result.Metrics.AvgHoldingDays, 0.0)
+	assert.Equal(t, 0.0, result.Metrics.AvgLoss) // prices only rise
+}
+
+func TestBacktestComparesBuyAndHoldAndBenchmark(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{
+		"AAA": risingBars(40, 100, 1),
+		"SPY": risingBars(40, 400, 2),
+	}}
+	run := testRun("AAA")
+	run.BuyAndHold = true
+	run.Benchmark = "SPY"
+
+	result, err := runBacktestEngine(context.Background(), provider, momentumStrategy(0), run)
+	assert.NoError(t, err)
+
+	hold := result.BuyAndHold
+	assert.Equal(t, []string{"AAA"}, hold.Tickers)
+	assert.Equal(t, 10000-91*109.0+91*139.0, hold.FinalBalance) // 91 shares from 109 to 139
+	assert.Len(t, hold.Equity, len(result.Equity))
+	assert.Greater(t, hold.ROI, result.ROI) // take-profit exits leave cash idle
+
+	bench := result.Benchmark
+	assert.Equal(t, []string{"SPY"}, bench.Tickers)
+	assert.Len(t, bench.Equity, len(result.Equity))
+	assert.Equal(t, result.Equity[0].Date, bench.Equity[0].Date)
+
+	_, err = runBacktestEngine(context.Background(), provider, momentumStrategy(0), backtestRun{
+		Tickers: []string{"AAA"}, From: "2024-01-10", To: "2024-02-09", InitialBalance: 10000, Benchmark: "NOPE",
+	})
+	assert.Error(t, err)
+}
+
+func TestBacktestReportsProgressAndCancels(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(40, 100, 1)}}
+
+	var reported []float64
+	run := testRun("AAA")
+	run.Progress = func(percent float64) { reported = append(reported, percent) }
+
+	_, err := runBacktestEngine(context.Background(), provider, momentumStrategy(0), run)
+	assert.NoError(t, err)
+	assert.Equal(t, loadShare, reported[0])
+	assert.IsNonDecreasing(t, reported)
+	assert.Less(t, reported[len(reported)-1], 100.0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	run.Progress = func(percent float64) {
+		if percent > loadShare {
+			cancel()
+		}
+	}
+	_, err = runBacktestEngine(ctx, provider, momentumStrategy(0), run)
+	assert.ErrorIs(t, err, context.Canceled)
+}
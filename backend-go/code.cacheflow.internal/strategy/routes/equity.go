@@ -0,0 +1,140 @@
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	accountEntities "code.cacheflow.internal/account/entities"
+	datastores "code.cacheflow.internal/datastores/mongo"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/strategy/metrics"
+	"code.cacheflow.internal/util/httpx"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ── Equity Curve ──────────────────────────────────────────────────────────────
+
+const (
+	defaultEquityPoints = 500
+	maxEquityPoints     = 5000
+)
+
+// equitySeries derives the stored chart series from the engine's curve.
+func equitySeries(initial float64, curve []metrics.Point) []strategyEntities.BacktestEquityPoint {
+	points := make([]strategyEntities.BacktestEquityPoint, len(curve))
+	peak := initial
+	for i, p := range curve {
+		peak = max(peak, p.Equity)
+		points[i] = strategyEntities.BacktestEquityPoint{Date: p.Date, Equity: p.Equity, Cash: p.Cash}
+		if p.Equity > 0 {
+			points[i].Exposure = (p.Equity - p.Cash) / p.Equity * 100
+		}
+		if peak > 0 {
+			points[i].Drawdown = (peak - p.Equity) / peak * 100
+		}
+	}
+	return points
+}
+
+// downsampleIndices picks at most n indices of points by splitting the series
+// into buckets and keeping each bucket's deepest drawdown, so troughs survive.
+// The first and last points are always kept.
+func downsampleIndices(points []strategyEntities.BacktestEquityPoint, n int) []int {
+	if n < 3 || len(points) <= n {
+		idx := make([]int, len(points))
+		for i := range idx {
+			idx[i] = i
+		}
+		return idx
+	}
+
+	idx := make([]int, 0, n)
+	idx = append(idx, 0)
+
+	inner := len(points) - 2
+	buckets := n - 2
+	for b := 0; b < buckets; b++ {
+		lo, hi := 1+b*inner/buckets, 1+(b+1)*inner/buckets
+		if lo == hi {
+			continue
+		}
+		pick := lo
+		for i := lo + 1; i < hi; i++ {
+			if points[i].Drawdown > points[pick].Drawdown {
+				pick = i
+			}
+		}
+		idx = append(idx, pick)
+	}
+
+	return append(idx, len(points)-1)
+}
+
+// pickPoints returns series at idx; comparison series shorter than the
+// strategy's curve (saved by older versions) are dropped.
+func pickPoints(series []strategyEntities.BacktestEquityPoint, idx []int, total int) []strategyEntities.BacktestEquityPoint {
+	if len(series) != total {
+		return nil
+	}
+	out := make([]strategyEntities.BacktestEquityPoint, len(idx))
+	for i, j := range idx {
+		out[i] = series[j]
+	}
+	return out
+}
+
+// GetBacktestEquity returns a backtest's daily equity, cash, exposure and
+// drawdown series, downsampled to max_points (default 500) for charting.
+// Buy-and-hold and benchmark series, when saved, are sampled on the same dates.
+func GetBacktestEquity(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	q := req.URL.Query()
+	backtestUUID := strings.TrimSpace(q.Get("backtest_uuid"))
+	if backtestUUID == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("backtest_uuid is required", nil))
+		return
+	}
+
+	maxPoints := defaultEquityPoints
+	if v := strings.TrimSpace(q.Get("max_points")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 3 || n > maxEquityPoints {
+			httpx.WriteError(res, req, httpx.BadRequest("max_points must be between 3 and 5000", nil))
+			return
+		}
+		maxPoints = n
+	}
+
+	var equity strategyEntities.BacktestEquityEntity
+	if err := db.Collection(datastores.BacktestEquity).FindOne(req.Context(),
+		bson.M{"backtest_uuid": backtestUUID, "account_id": *account.AccountID}).Decode(&equity); err != nil {
+		httpx.WriteError(res, req, httpx.NotFound("equity curve not found"))
+		return
+	}
+
+	total := len(equity.Points)
+	idx := downsampleIndices(equity.Points, maxPoints)
+
+	httpx.WriteJSON(res, http.StatusOK, map[string]any{
+		"backtest_uuid": equity.BacktestUUID,
+		"total_points":  total,
+		"points":        pickPoints(equity.Points, idx, total),
+		"buy_and_hold":  pickPoints(equity.BuyAndHold, idx, total),
+		"benchmark":     pickPoints(equity.Benchmark, idx, total),
+	})
+}
@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"fmt"
+	"testing"
+
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/strategy/metrics"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEquitySeries(t *testing.T) {
+	points := equitySeries(100, []metrics.Point{
+		{Date: "2024-01-01", Equity: 120, Cash: 30},
+		{Date: "2024-01-02", Equity: 90, Cash: 90},
+	})
+
+	assert.InDelta(t, 75, points[0].Exposure, 1e-9)
+	assert.InDelta(t, 0, points[0].Drawdown, 1e-9)
+	assert.InDelta(t, 0, points[1].Exposure, 1e-9)
+	assert.InDelta(t, 25, points[1].Drawdown, 1e-9)
+}
+
+func TestDownsampleEquity(t *testing.T) {
+	points := make([]strategyEntities.BacktestEquityPoint, 100)
+	for i := range points {
+		points[i].Date = fmt.Sprintf("d%03d", i)
+	}
+	points[42].Drawdown = 30
+
+	tests := []struct {
+		Name string
+		N    int
+		Len  int
+	}{
+		{Name: "Short series is untouched", N: 200, Len: 100},
+		{Name: "Long series is bucketed", N: 10, Len: 10},
+		{Name: "Too few points requested", N: 2, Len: 100},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			out := pickPoints(points, downsampleIndices(points, tt.N), len(points))
+			assert.Len(t, out, tt.Len)
+			assert.Equal(t, points[0], out[0])
+			assert.Equal(t, points[99], out[len(out)-1])
+			assert.Contains(t, out, points[42])
+		})
+	}
+}
+
+func TestPickPointsDropsMisalignedSeries(t *testing.T) {
+	points := make([]strategyEntities.BacktestEquityPoint, 5)
+
+	assert.Len(t, pickPoints(points, []int{0, 4}, 5), 2)
+	assert.Nil(t, pickPoints(points[:3], []int{0, 4}, 5))
+}
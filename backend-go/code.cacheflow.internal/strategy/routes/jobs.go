@@ -0,0 +1,498 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	accountEntities "code.cacheflow.internal/account/entities"
+	"code.cacheflow.internal/datafeed"
+	datastores "code.cacheflow.internal/datastores/mongo"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/util/httpx"
+
+	"github.com/charmbracelet/log"
+	"github.com/pborman/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ── Backtest Jobs ─────────────────────────────────────────────────────────────
+
+const (
+	maxActiveJobsPerAccount = 10
+	maxListedJobs           = 50
+	jobPollInterval         = 5 * time.Second
+	jobProgressInterval     = time.Second
+
+	// A running job renews its lease every jobHeartbeatInterval, through
+	// progress updates or on its own while loading data
+	jobHeartbeatInterval = 15 * time.Second
+	jobLease             = 2 * time.Minute
+)
+
+var (
+	// jobWake nudges idle workers when a job is submitted.
+	jobWake = make(chan struct{}, 16)
+
+	// jobWorkerID identifies this process as the owner of the jobs it claims.
+	jobWorkerID = uuid.New()
+
+	// runningJobs maps job UUID → cancel func for jobs running in this process.
+	runningJobs sync.Map
+)
+
+var activeJobStatuses = []strategyEntities.BacktestJobStatus{strategyEntities.JobQueued, strategyEntities.JobRunning}
+
+// StartBacktestWorkers starts workers that execute queued backtest jobs until
+// ctx is cancelled. Jobs are claimed from Mongo under a lease, so several
+// server instances can share a queue; a job whose owner stops renewing its
+// lease, such as one left by a crashed process, is re-queued.
+func StartBacktestWorkers(ctx context.Context, workers int) {
+	logger := log.NewWithOptions(os.Stderr, log.Options{
+		ReportTimestamp: true,
+		TimeFormat:      "2006-01-02 15:04:05",
+		Prefix:          "STRATEGY (JOBS)",
+	})
+
+	db := datastores.GetMongoDatabase(ctx)
+	if db == nil {
+		logger.Error("mongo database is nil, backtest workers not started")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(jobLease)
+		defer ticker.Stop()
+		for {
+			requeueExpiredJobs(ctx, db, logger)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	for range workers {
+		go backtestWorker(ctx, db, logger)
+	}
+	logger.Info("backtest workers started", "workers", workers, "worker_id", jobWorkerID)
+}
+
+// requeueExpiredJobs returns RUNNING jobs whose lease has lapsed to the queue.
+// Jobs without a heartbeat were claimed before leases existed.
+func requeueExpiredJobs(ctx context.Context, db *mongo.Database, logger *log.Logger) {
+	res, err := db.Collection(datastores.BacktestJobs).UpdateMany(ctx,
+		bson.M{
+			"status": strategyEntities.JobRunning,
+			"$or": bson.A{
+				bson.M{"heartbeat_at": bson.M{"$lt": time.Now().UTC().Add(-jobLease)}},
+				bson.M{"heartbeat_at": bson.M{"$exists": false}},
+			},
+		},
+		bson.M{
+			"$set":   bson.M{"status": strategyEntities.JobQueued, "progress": 0},
+			"$unset": bson.M{"started_at": "", "worker_id": "", "heartbeat_at": ""},
+		})
+	if err != nil {
+		logger.Error("failed to re-queue interrupted backtest jobs", "err", err)
+	} else if res.ModifiedCount > 0 {
+		logger.Info("re-queued interrupted backtest jobs", "count", res.ModifiedCount)
+	}
+}
+
+func backtestWorker(ctx context.Context, db *mongo.Database, logger *log.Logger) {
+	for {
+		job, err := claimBacktestJob(ctx, db)
+		if err != nil {
+			logger.Error("failed to claim backtest job", "err", err)
+		}
+		if job != nil {
+			runBacktestJob(ctx, db, job, logger)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-jobWake:
+		case <-time.After(jobPollInterval):
+		}
+	}
+}
+
+// claimBacktestJob moves the oldest queued job to RUNNING under this process's
+// lease and returns it, or nil when the queue is empty.
+func claimBacktestJob(ctx context.Context, db *mongo.Database) (*strategyEntities.BacktestJobEntity, error) {
+	now := time.Now().UTC()
+	var job strategyEntities.BacktestJobEntity
+	err := db.Collection(datastores.BacktestJobs).FindOneAndUpdate(ctx,
+		bson.M{"status": strategyEntities.JobQueued},
+		bson.M{"$set": bson.M{
+			"status":       strategyEntities.JobRunning,
+			"started_at":   now,
+			"worker_id":    jobWorkerID,
+			"heartbeat_at": now,
+		}},
+		options.FindOneAndUpdate().
+			SetSort(bson.D{{Key: "created_at", Value: 1}}).
+			SetReturnDocument(options.After),
+	).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+func runBacktestJob(ctx context.Context, db *mongo.Database, job *strategyEntities.BacktestJobEntity, logger *log.Logger) {
+	jobCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	runningJobs.Store(job.UUID, cancel)
+	defer runningJobs.Delete(job.UUID)
+
+	// renew extends the lease, applying set, and reports whether the job is
+	// still RUNNING here; a job cancelled through the API (possibly on another
+	// instance) or re-queued after its lease lapsed stops here.
+	renew := func(set bson.M) bool {
+		set["heartbeat_at"] = time.Now().UTC()
+		res, err := db.Collection(datastores.BacktestJobs).UpdateOne(jobCtx,
+			bson.M{"uuid": job.UUID, "status": strategyEntities.JobRunning, "worker_id": jobWorkerID},
+			bson.M{"$set": set})
+		if err == nil && res.MatchedCount == 0 {
+			cancel()
+			return false
+		}
+		return true
+	}
+	touch := func(percent float64) bool {
+		return renew(bson.M{"progress": math.Round(percent)})
+	}
+	go func() {
+		ticker := time.NewTicker(jobHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-jobCtx.Done():
+				return
+			case <-ticker.C:
+				renew(bson.M{})
+			}
+		}
+	}()
+
+	prepared, err := prepareBacktest(jobCtx, db, job.AccountID, job.Request)
+	if err != nil {
+		finishBacktestJob(ctx, db, job, bson.M{"status": strategyEntities.JobFailed, "error": jobError(err)}, logger)
+		return
+	}
+
+	var lastTouch time.Time
+	prepared.Run.Progress = func(percent float64) {
+		if time.Since(lastTouch) >= jobProgressInterval {
+			lastTouch = time.Now()
+			touch(percent)
+		}
+	}
+
+	var result *backtestResult
+	var report any
+	switch job.Kind {
+	case strategyEntities.JobOptimize:
+		report, err = runOptimizeJob(jobCtx, job, prepared)
+	case strategyEntities.JobWalkForward:
+		report, err = runWalkForwardJob(jobCtx, job, prepared)
+	default:
+		result, err = runBacktestEngine(jobCtx, datafeed.GetProvider(), &prepared.Strategy, prepared.Run)
+		if err != nil {
+			err = fmt.Errorf("backtest failed: %s", err)
+		}
+	}
+	switch {
+	case ctx.Err() != nil:
+		// Shutting down; hand the job back for another worker
+		releaseBacktestJob(db, job, logger)
+		return
+	case jobCtx.Err() != nil:
+		logger.Info("backtest job cancelled", "job", job.UUID)
+		return
+	case err != nil:
+		finishBacktestJob(ctx, db, job, bson.M{"status": strategyEntities.JobFailed, "error": jobError(err)}, logger)
+		return
+	}
+
+	// Don't save results for a job cancelled after its last progress update
+	if !touch(100) {
+		return
+	}
+
+	if report != nil {
+		raw, err := json.Marshal(report)
+		if err != nil {
+			finishBacktestJob(ctx, db, job, bson.M{"status": strategyEntities.JobFailed, "error": "failed to encode report"}, logger)
+			return
+		}
+		finishBacktestJob(ctx, db, job, bson.M{"status": strategyEntities.JobSucceeded, "result": json.RawMessage(raw)}, logger)
+		return
+	}
+
+	record, err := saveBacktest(ctx, db, prepared, result)
+	if err != nil {
+		finishBacktestJob(ctx, db, job, bson.M{"status": strategyEntities.JobFailed, "error": jobError(err)}, logger)
+		return
+	}
+	finishBacktestJob(ctx, db, job, bson.M{"status": strategyEntities.JobSucceeded, "backtest_uuid": record.UUID}, logger)
+}
+
+// releaseBacktestJob re-queues a job this process is running, for shutdown.
+func releaseBacktestJob(db *mongo.Database, job *strategyEntities.BacktestJobEntity, logger *log.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := db.Collection(datastores.BacktestJobs).UpdateOne(ctx,
+		bson.M{"uuid": job.UUID, "status": strategyEntities.JobRunning, "worker_id": jobWorkerID},
+		bson.M{
+			"$set":   bson.M{"status": strategyEntities.JobQueued, "progress": 0},
+			"$unset": bson.M{"started_at": "", "worker_id": "", "heartbeat_at": ""},
+		}); err != nil {
+		logger.Error("failed to re-queue backtest job", "job", job.UUID, "err", err)
+	}
+}
+
+// finishBacktestJob moves a job this process is running to a final state.
+func finishBacktestJob(ctx context.Context, db *mongo.Database, job *strategyEntities.BacktestJobEntity, set bson.M, logger *log.Logger) {
+	set["finished_at"] = time.Now().UTC()
+	if _, err := db.Collection(datastores.BacktestJobs).UpdateOne(ctx,
+		bson.M{"uuid": job.UUID, "status": strategyEntities.JobRunning, "worker_id": jobWorkerID},
+		bson.M{"$set": set}); err != nil {
+		logger.Error("failed to finish backtest job", "job", job.UUID, "err", err)
+	}
+}
+
+// queueJob checks the account's limit of active jobs, then inserts job as
+// QUEUED and wakes a worker. Errors are *httpx.Error.
+func queueJob(ctx context.Context, db *mongo.Database, job *strategyEntities.BacktestJobEntity) error {
+	active, err := db.Collection(datastores.BacktestJobs).CountDocuments(ctx,
+		bson.M{"account_id": job.AccountID, "status": bson.M{"$in": activeJobStatuses}})
+	if err != nil {
+		return httpx.Internal("failed to count backtest jobs")
+	}
+	if active >= maxActiveJobsPerAccount {
+		return httpx.Conflict(fmt.Sprintf("at most %d backtest jobs may be queued or running", maxActiveJobsPerAccount), nil)
+	}
+
+	job.UUID = uuid.New()
+	job.Status = strategyEntities.JobQueued
+	job.CreatedAt = time.Now().UTC()
+	if _, err := db.Collection(datastores.BacktestJobs).InsertOne(ctx, job); err != nil {
+		return httpx.Internal("failed to queue backtest")
+	}
+
+	select {
+	case jobWake <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// jobError is the user-facing message of err.
+func jobError(err error) string {
+	var he *httpx.Error
+	if errors.As(err, &he) {
+		return he.Message
+	}
+	return err.Error()
+}
+
+// ── HTTP Handlers ─────────────────────────────────────────────────────────────
+
+// SubmitBacktestJob validates a backtest request and queues it. It responds
+// 202 with the job; poll GetBacktestJob for progress.
+func SubmitBacktestJob(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	var body strategyEntities.BacktestRequest
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("invalid request body", nil))
+		return
+	}
+
+	prepared, err := prepareBacktest(req.Context(), db, *account.AccountID, body)
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+
+	job := strategyEntities.BacktestJobEntity{
+		AccountID:    *account.AccountID,
+		StrategyUUID: body.StrategyUUID,
+		Request:      prepared.Body,
+		Kind:         strategyEntities.JobBacktest,
+	}
+	if err := queueJob(req.Context(), db, &job); err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+
+	httpx.WriteJSON(res, http.StatusAccepted, job)
+}
+
+// GetBacktestJob returns one job's status and progress.
+func GetBacktestJob(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	jobUUID := strings.TrimSpace(req.URL.Query().Get("uuid"))
+	if jobUUID == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("uuid is required", nil))
+		return
+	}
+
+	var job strategyEntities.BacktestJobEntity
+	if err := db.Collection(datastores.BacktestJobs).FindOne(req.Context(),
+		bson.M{"uuid": jobUUID, "account_id": *account.AccountID}).Decode(&job); err != nil {
+		httpx.WriteError(res, req, httpx.NotFound("backtest job not found"))
+		return
+	}
+
+	httpx.WriteJSON(res, http.StatusOK, job)
+}
+
+// GetBacktestJobs lists the account's most recent jobs, optionally for one
+// strategy. Reports are left out; GetBacktestJob returns them.
+func GetBacktestJobs(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	filter := bson.M{"account_id": *account.AccountID}
+	if strategyUUID := strings.TrimSpace(req.URL.Query().Get("strategy_uuid")); strategyUUID != "" {
+		filter["strategy_uuid"] = strategyUUID
+	}
+
+	cur, err := db.Collection(datastores.BacktestJobs).Find(req.Context(), filter,
+		options.Find().
+			SetSort(bson.D{{Key: "created_at", Value: -1}}).
+			SetLimit(maxListedJobs).
+			SetProjection(bson.M{"result": 0}))
+	if err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to fetch backtest jobs"))
+		return
+	}
+	defer cur.Close(req.Context())
+
+	var jobs []strategyEntities.BacktestJobEntity
+	if err := cur.All(req.Context(), &jobs); err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to decode backtest jobs"))
+		return
+	}
+	if jobs == nil {
+		jobs = []strategyEntities.BacktestJobEntity{}
+	}
+
+	httpx.WriteJSON(res, http.StatusOK, map[string]any{"jobs": jobs})
+}
+
+// CancelBacktestJob cancels a queued or running job.
+func CancelBacktestJob(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	var body struct {
+		UUID string `json:"uuid"`
+	}
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("invalid request body", nil))
+		return
+	}
+	if body.UUID == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("uuid is required", nil))
+		return
+	}
+
+	var job strategyEntities.BacktestJobEntity
+	err := db.Collection(datastores.BacktestJobs).FindOneAndUpdate(req.Context(),
+		bson.M{
+			"uuid":       body.UUID,
+			"account_id": *account.AccountID,
+			"status":     bson.M{"$in": activeJobStatuses},
+		},
+		bson.M{"$set": bson.M{"status": strategyEntities.JobCancelled, "finished_at": time.Now().UTC()}},
+		options.FindOneAndUpdate().SetReturnDocument(options.After),
+	).Decode(&job)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		count, _ := db.Collection(datastores.BacktestJobs).CountDocuments(req.Context(),
+			bson.M{"uuid": body.UUID, "account_id": *account.AccountID})
+		if count == 0 {
+			httpx.WriteError(res, req, httpx.NotFound("backtest job not found"))
+		} else {
+			httpx.WriteError(res, req, httpx.Conflict("backtest job already finished", nil))
+		}
+		return
+	}
+	if err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to cancel backtest job"))
+		return
+	}
+
+	// Stop it now if it runs here; other instances notice on their next progress update
+	if cancel, ok := runningJobs.Load(job.UUID); ok {
+		cancel.(context.CancelFunc)()
+	}
+
+	httpx.WriteJSON(res, http.StatusOK, job)
+}
@@ -0,0 +1,660 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // America/New_York on hosts without a zoneinfo database
+
+	accountEntities "code.cacheflow.internal/account/entities"
+	"code.cacheflow.internal/datafeed"
+	datastores "code.cacheflow.internal/datastores/mongo"
+	"code.cacheflow.internal/portfolio/costs"
+	portfolioEntities "code.cacheflow.internal/portfolio/management/entities"
+	orderEntities "code.cacheflow.internal/portfolio/order/entities"
+	orderHandler "code.cacheflow.internal/portfolio/order/handler"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/strategy/sizing"
+	"code.cacheflow.internal/util/httpx"
+
+	"github.com/charmbracelet/log"
+	"github.com/pborman/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ── Live Paper Trading ────────────────────────────────────────────────────────
+
+const (
+	// liveSettleDelay is how long after the close a day's bar is trusted as final.
+	liveSettleDelay = 15 * time.Minute
+
+	defaultListedDecisions = 100
+	maxListedDecisions     = 1000
+)
+
+// marketZone is the exchange's time zone for market hours.
+var marketZone = func() *time.Location {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		return time.FixedZone("EST", -5*60*60)
+	}
+	return loc
+}()
+
+// marketOpen reports whether now is inside regular US trading hours.
+// Exchange holidays are not modelled; on those days no new bar arrives.
+func marketOpen(now time.Time) bool {
+	t := now.In(marketZone)
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	minutes := t.Hour()*60 + t.Minute()
+	return minutes >= 9*60+30 && minutes < 16*60
+}
+
+// barSettled reports whether the daily bar dated date is complete at now.
+func barSettled(date string, now time.Time) bool {
+	t := now.In(marketZone)
+	today := t.Format("2006-01-02")
+	if date != today {
+		return date < today
+	}
+	return t.Sub(time.Date(t.Year(), t.Month(), t.Day(), 16, 0, 0, 0, marketZone)) >= liveSettleDelay
+}
+
+// liveBroker places the orders of live strategies.
+type liveBroker interface {
+	PlaceOrder(ctx context.Context, req orderHandler.OrderRequest) (*orderEntities.OrderEntity, error)
+	ActiveShares(ticker, portfolioUUID string) (int64, error)
+}
+
+// paperBroker fills orders through the same path as ExecuteOrder.
+type paperBroker struct{}
+
+func (paperBroker) PlaceOrder(ctx context.Context, req orderHandler.OrderRequest) (*orderEntities.OrderEntity, error) {
+	return orderHandler.PlaceOrder(ctx, req)
+}
+
+func (paperBroker) ActiveShares(ticker, portfolioUUID string) (int64, error) {
+	return orderHandler.GetActiveShares(ticker, portfolioUUID)
+}
+
+// liveAccount is the cash and cost schedule of the portfolio a live strategy trades.
+type liveAccount struct {
+	Cash  float64
+	Costs *costs.Model
+}
+
+// liveFeed loads ticker's daily history for an evaluation at now. Bars that
+// have not settled are dropped; with forming set, today's bar is rebuilt from
+// the snapshot instead. settled is the date of the newest complete bar.
+func liveFeed(ctx context.Context, provider datafeed.MarketDataProvider, strategy *strategyEntities.StrategyEntity, ticker string, now time.Time, forming bool) (feed *symbolFeed, settled string, err error) {
+	today := now.In(marketZone)
+	warmFrom := datafeed.WarmupFrom(today, "day", strategyLookback(strategy)).Format("2006-01-02")
+
+	history, err := fetchDailyBars(ctx, provider, ticker, warmFrom, today.Format("2006-01-02"))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to fetch bars for %s: %w", ticker, err)
+	}
+	if n := len(history); n > 0 && !barSettled(history[n-1].Date, now) {
+		history = history[:n-1]
+	}
+	if n := len(history); n > 0 {
+		settled = history[n-1].Date
+	}
+
+	if forming {
+		snap, err := provider.Snapshot(ctx, ticker)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to fetch snapshot for %s: %w", ticker, err)
+		}
+		if snap.LastPrice > 0 {
+			open := snap.DayOpen
+			if open <= 0 {
+				open = snap.LastPrice
+			}
+			high := math.Max(snap.DayHigh, snap.LastPrice)
+			low := snap.DayLow
+			if low <= 0 || low > snap.LastPrice {
+				low = snap.LastPrice
+			}
+			history = append(history, dailyBar{
+				Date:  today.Format("2006-01-02"),
+				Open:  open,
+				High:  high,
+				Low:   low,
+				Close: snap.LastPrice,
+				Vol:   snap.DayVolume,
+				VWAP:  (high + low + snap.LastPrice) / 3,
+				TsMs:  now.UnixMilli(),
+			})
+		}
+	}
+
+	if len(history) == 0 {
+		return nil, "", fmt.Errorf("no price data for %s", ticker)
+	}
+	return &symbolFeed{
+		Ticker:  ticker,
+		History: history,
+		Start:   len(history) - 1,
+		Store:   loadIndicators(strategy, strategyTimeframe(strategy), history),
+	}, settled, nil
+}
+
+// evaluateLive runs one live evaluation of strategy over tickers and returns
+// the decisions it took, updating state in place. It mirrors the backtester's
+// SAME_CLOSE rules on the newest bar: exits first, at most MaxPositions
+// holdings, and no re-entry on the bar of an exit.
+//
+// DAILY strategies only see settled bars and are evaluated once per new bar;
+// nil decisions mean there was nothing new. INTRADAY strategies are evaluated
+// on every call during market hours against the forming bar.
+func evaluateLive(ctx context.Context, provider datafeed.MarketDataProvider, broker liveBroker, strategy *strategyEntities.StrategyEntity, state *strategyEntities.LiveStateEntity, tickers []string, account liveAccount, now time.Time) []strategyEntities.LiveDecisionEntity {
+	forming := strategy.Live != nil && strategy.Live.Cadence == strategyEntities.LiveIntraday && marketOpen(now)
+
+	var decisions []strategyEntities.LiveDecisionEntity
+	decide := func(ticker, barDate string, action strategyEntities.LiveAction, reason string, order *orderEntities.OrderEntity) {
+		d := strategyEntities.LiveDecisionEntity{
+			UUID:         uuid.New(),
+			StrategyUUID: strategy.UUID,
+			AccountID:    strategy.AccountID,
+			Ticker:       ticker,
+			BarDate:      barDate,
+			Action:       action,
+			Reason:       reason,
+			CreatedAt:    now,
+		}
+		if order != nil {
+			d.Price = *order.Price
+			d.Quantity = *order.Quantity
+			d.OrderUUID = *order.UUID
+		}
+		decisions = append(decisions, d)
+	}
+
+	// Held symbols dropped from the universe still need their exits
+	symbols := append([]string(nil), tickers...)
+	for _, p := range state.Positions {
+		if !containsTicker(symbols, p.Ticker) {
+			symbols = append(symbols, p.Ticker)
+		}
+	}
+
+	feeds := make(map[string]*symbolFeed, len(symbols))
+	newest := ""
+	for _, ticker := range symbols {
+		feed, settled, err := liveFeed(ctx, provider, strategy, ticker, now, forming)
+		if err != nil {
+			decide(ticker, "", strategyEntities.LiveError, err.Error(), nil)
+			continue
+		}
+		feeds[ticker] = feed
+		newest = max(newest, settled)
+	}
+	if !forming && newest <= state.LastBarDate {
+		return nil
+	}
+
+	if state.LastExit == nil {
+		state.LastExit = make(map[string]string)
+	}
+	fillCosts := func(order *orderEntities.OrderEntity) float64 {
+		total := 0.0
+		if order.Commission != nil {
+			total += *order.Commission
+		}
+		if order.Fees != nil {
+			total += *order.Fees
+		}
+		return total
+	}
+
+	// Exits
+	hasExits := len(strategy.SellConditions) > 0 || strategy.ExitRules != nil
+	for _, held := range append([]strategyEntities.LivePosition(nil), state.Positions...) {
+		feed := feeds[held.Ticker]
+		if feed == nil {
+			continue
+		}
+		i := len(feed.History) - 1
+		bar := feed.History[i]
+
+		p := state.Position(held.Ticker)
+		p.PeakPrice = math.Max(p.PeakPrice, bar.Close)
+		pos := &position{Shares: float64(p.Shares), EntryPrice: p.EntryPrice, PeakPrice: p.PeakPrice, EntryDate: p.EntryDate}
+		if !hasExits || !closeExitTriggered(strategy, pos, feed, i) {
+			decide(held.Ticker, bar.Date, strategyEntities.LiveHold, "", nil)
+			continue
+		}
+
+		// Orders placed by hand may have sold some of the position already
+		active, err := broker.ActiveShares(held.Ticker, state.PortfolioUUID)
+		if err != nil {
+			decide(held.Ticker, bar.Date, strategyEntities.LiveError, "failed to get active shares", nil)
+			continue
+		}
+		qty := min(p.Shares, active)
+		if qty <= 0 {
+			decide(held.Ticker, bar.Date, strategyEntities.LiveSkip, "position is no longer held in the portfolio", nil)
+			state.Close(held.Ticker)
+			continue
+		}
+
+		order, err := broker.PlaceOrder(ctx, orderHandler.OrderRequest{
+			AccountID:     state.AccountID,
+			PortfolioUUID: state.PortfolioUUID,
+			Ticker:        held.Ticker,
+			Side:          "SELL",
+			Quantity:      qty,
+			StrategyUUID:  strategy.UUID,
+		})
+		if err != nil {
+			decide(held.Ticker, bar.Date, strategyEntities.LiveError, err.Error(), nil)
+			continue
+		}
+		account.Cash += *order.TotalCost - fillCosts(order)
+		if basis := p.EntryPrice * float64(qty); order.Realized != nil && basis > 0 {
+			state.ClosedReturns = append(state.ClosedReturns, *order.Realized/basis*100)
+		}
+		state.LastExit[held.Ticker] = bar.Date
+		state.Close(held.Ticker)
+		decide(held.Ticker, bar.Date, strategyEntities.LiveSell, "exit conditions met", order)
+	}
+
+	// Entries
+	maxPositions := strategy.MaxPositions
+	if maxPositions <= 0 || maxPositions > len(tickers) {
+		maxPositions = len(tickers)
+	}
+	atrKey := fmt.Sprintf("ATR_%d", sizing.ATRWindow(strategy.PositionSizing))
+	entry := strategy.Entry()
+	for _, ticker := range tickers {
+		feed := feeds[ticker]
+		if feed == nil || state.Position(ticker) != nil {
+			continue
+		}
+		i := len(feed.History) - 1
+		bar := feed.History[i]
+
+		if !feed.met(entry, i) {
+			decide(ticker, bar.Date, strategyEntities.LiveNoSignal, "", nil)
+			continue
+		}
+		if state.LastExit[ticker] == bar.Date {
+			decide(ticker, bar.Date, strategyEntities.LiveSkip, "exited on this bar", nil)
+			continue
+		}
+		if len(state.Positions) >= maxPositions {
+			decide(ticker, bar.Date, strategyEntities.LiveSkip, "max positions reached", nil)
+			continue
+		}
+
+		equity := account.Cash
+		for _, p := range state.Positions {
+			if f := feeds[p.Ticker]; f != nil {
+				equity += float64(p.Shares) * f.History[len(f.History)-1].Close
+			}
+		}
+		atr, _ := feed.Store.get(atrKey, bar.Date)
+		qty := sizing.Shares(strategy.PositionSizing, sizing.Inputs{
+			Price:        account.Costs.Apply(costs.Buy, bar.Close, 1).Price,
+			Cash:         account.Cash,
+			Equity:       equity,
+			FreeSlots:    maxPositions - len(state.Positions),
+			ATR:          atr,
+			ClosedReturn: state.ClosedReturns,
+		})
+		_, qty = affordableFill(account.Costs, costs.Buy, bar.Close, qty, account.Cash)
+		if qty < 1 {
+			decide(ticker, bar.Date, strategyEntities.LiveSkip, "not enough cash for one share", nil)
+			continue
+		}
+
+		order, err := broker.PlaceOrder(ctx, orderHandler.OrderRequest{
+			AccountID:     state.AccountID,
+			PortfolioUUID: state.PortfolioUUID,
+			Ticker:        ticker,
+			Side:          "BUY",
+			Quantity:      int64(qty),
+			StrategyUUID:  strategy.UUID,
+		})
+		if err != nil {
+			decide(ticker, bar.Date, strategyEntities.LiveError, err.Error(), nil)
+			continue
+		}
+		account.Cash -= *order.TotalCost + fillCosts(order)
+		state.Positions = append(state.Positions, strategyEntities.LivePosition{
+			Ticker:     ticker,
+			Shares:     *order.Quantity,
+			EntryPrice: *order.Price,
+			PeakPrice:  bar.Close,
+			EntryDate:  bar.Date,
+			OrderUUID:  *order.UUID,
+		})
+		decide(ticker, bar.Date, strategyEntities.LiveBuy, "entry rules met", order)
+	}
+
+	state.LastBarDate = max(state.LastBarDate, newest)
+	state.EvaluatedAt = &now
+	return decisions
+}
+
+func containsTicker(tickers []string, ticker string) bool {
+	for _, t := range tickers {
+		if t == ticker {
+			return true
+		}
+	}
+	return false
+}
+
+// RunLiveTrading evaluates every strategy with live trading enabled on each
+// tick until ctx is cancelled. DAILY strategies act once per settled bar, so
+// the interval only bounds how soon after the close they trade; INTRADAY
+// strategies act on every tick during market hours.
+func RunLiveTrading(ctx context.Context, provider datafeed.MarketDataProvider, interval time.Duration) {
+	logger := log.NewWithOptions(os.Stderr, log.Options{
+		ReportTimestamp: true,
+		TimeFormat:      "2006-01-02 15:04:05",
+		Prefix:          "STRATEGY (LIVE)",
+	})
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		runLiveStrategies(ctx, provider, logger)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func runLiveStrategies(ctx context.Context, provider datafeed.MarketDataProvider, logger *log.Logger) {
+	db := datastores.GetMongoDatabase(ctx)
+	if db == nil {
+		return
+	}
+
+	cur, err := db.Collection(datastores.Strategies).Find(ctx, bson.M{"live.enabled": true})
+	if err != nil {
+		logger.Error("failed to load live strategies", "err", err)
+		return
+	}
+	var strategies []strategyEntities.StrategyEntity
+	if err := cur.All(ctx, &strategies); err != nil {
+		logger.Error("failed to decode live strategies", "err", err)
+		return
+	}
+
+	for i := range strategies {
+		if ctx.Err() != nil {
+			return
+		}
+		runLiveStrategy(ctx, db, provider, &strategies[i], time.Now(), logger)
+	}
+}
+
+func runLiveStrategy(ctx context.Context, db *mongo.Database, provider datafeed.MarketDataProvider, strategy *strategyEntities.StrategyEntity, now time.Time, logger *log.Logger) {
+	state := strategyEntities.LiveStateEntity{
+		StrategyUUID:  strategy.UUID,
+		AccountID:     strategy.AccountID,
+		PortfolioUUID: strategy.PortfolioUUID,
+	}
+	err := db.Collection(datastores.StrategyLive).FindOne(ctx, bson.M{"strategy_uuid": strategy.UUID}).Decode(&state)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		logger.Error("failed to load live state", "strategy", strategy.UUID, "err", err)
+		return
+	}
+
+	tickers, err := strategyUniverse(ctx, db, strategy)
+	if err != nil {
+		logger.Warn("live strategy has no universe", "strategy", strategy.UUID, "err", err)
+		return
+	}
+
+	var portfolio portfolioEntities.PortfolioEntity
+	if err := db.Collection(datastores.Portfolios).FindOne(ctx,
+		bson.M{"uuid": state.PortfolioUUID, "account_id": state.AccountID}).Decode(&portfolio); err != nil {
+		logger.Warn("live strategy portfolio not found", "strategy", strategy.UUID, "portfolio", state.PortfolioUUID)
+		return
+	}
+	account := liveAccount{Costs: portfolio.CostModel}
+	if portfolio.CurrentBalance != nil {
+		account.Cash = *portfolio.CurrentBalance
+	}
+
+	decisions := evaluateLive(ctx, provider, paperBroker{}, strategy, &state, tickers, account, now)
+	if len(decisions) == 0 {
+		return
+	}
+
+	if _, err := db.Collection(datastores.StrategyLive).ReplaceOne(ctx,
+		bson.M{"strategy_uuid": strategy.UUID}, state, options.Replace().SetUpsert(true)); err != nil {
+		logger.Error("failed to save live state", "strategy", strategy.UUID, "err", err)
+	}
+
+	docs := make([]any, len(decisions))
+	trades := 0
+	for i, d := range decisions {
+		docs[i] = d
+		if d.Action == strategyEntities.LiveBuy || d.Action == strategyEntities.LiveSell {
+			trades++
+		}
+	}
+	if _, err := db.Collection(datastores.StrategyDecisions).InsertMany(ctx, docs); err != nil {
+		logger.Error("failed to log live decisions", "strategy", strategy.UUID, "err", err)
+	}
+	logger.Info("evaluated live strategy", "strategy", strategy.UUID, "decisions", len(decisions), "orders", trades)
+}
+
+// ── Live Handlers ─────────────────────────────────────────────────────────────
+
+type setStrategyLiveBody struct {
+	UUID    string                       `json:"uuid"`
+	Enabled bool                         `json:"enabled"`
+	Cadence strategyEntities.LiveCadence `json:"cadence"`
+}
+
+// SetStrategyLive turns paper trading of a strategy on or off.
+func SetStrategyLive(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	var body setStrategyLiveBody
+	if err := httpx.DecodeJSON(req, &body); err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+	body.Cadence = strategyEntities.LiveCadence(strings.ToUpper(strings.TrimSpace(string(body.Cadence))))
+	if body.UUID == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("uuid is required", nil))
+		return
+	}
+	if !body.Cadence.Valid() {
+		httpx.WriteError(res, req, httpx.BadRequest("cadence must be DAILY or INTRADAY", nil))
+		return
+	}
+	if body.Cadence == "" {
+		body.Cadence = strategyEntities.LiveDaily
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	filter := bson.M{"uuid": body.UUID, "account_id": *account.AccountID}
+	var strategy strategyEntities.StrategyEntity
+	if err := db.Collection(datastores.Strategies).FindOne(req.Context(), filter).Decode(&strategy); err != nil {
+		httpx.WriteError(res, req, httpx.NotFound("strategy not found"))
+		return
+	}
+	if body.Enabled {
+		if msg := liveUnsupported(&strategy); msg != "" {
+			httpx.WriteError(res, req, httpx.BadRequest(msg, nil))
+			return
+		}
+		if _, err := strategyUniverse(req.Context(), db, &strategy); err != nil {
+			httpx.WriteError(res, req, httpx.BadRequest(err.Error(), nil))
+			return
+		}
+	}
+
+	live := strategyEntities.LiveSettings{Enabled: body.Enabled, Cadence: body.Cadence}
+	if _, err := db.Collection(datastores.Strategies).UpdateOne(req.Context(), filter,
+		bson.M{"$set": bson.M{"live": live, "updated_at": time.Now()}}); err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to update strategy"))
+		return
+	}
+
+	httpx.WriteJSON(res, http.StatusOK, live)
+}
+
+// liveUnsupported explains why the strategy cannot trade live, or returns "".
+// Live execution works on daily bars, fills at the close, holds one long lot
+// per symbol and sells it in full. It keeps only the bars its rules need, which
+// may not reach back to an ATR stop's entry.
+func liveUnsupported(s *strategyEntities.StrategyEntity) string {
+	switch {
+	case s.Direction.Short():
+		return "live trading supports long-only strategies"
+	case s.Execution == strategyEntities.ExecutionNextOpen:
+		return "live trading executes at the close only"
+	case s.MaxEntries > 1:
+		return "live trading does not support pyramiding"
+	case s.Timeframe.Intraday():
+		return "live trading supports daily bars only"
+	case len(ruleTimeframes(s)) > 0:
+		return "live trading does not support rules on other timeframes"
+	}
+	for _, sc := range s.SellConditions {
+		if sc.Partial() {
+			return "live trading does not support partial exits"
+		}
+		if sc.Type == strategyEntities.SellATRStop {
+			return "live trading does not support ATR stops"
+		}
+	}
+	return ""
+}
+
+// GetStrategyLive returns a strategy's live settings and open positions.
+func GetStrategyLive(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	strategyUUID := strings.TrimSpace(req.URL.Query().Get("strategy_uuid"))
+	if strategyUUID == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("strategy_uuid is required", nil))
+		return
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	var strategy strategyEntities.StrategyEntity
+	if err := db.Collection(datastores.Strategies).FindOne(req.Context(),
+		bson.M{"uuid": strategyUUID, "account_id": *account.AccountID}).Decode(&strategy); err != nil {
+		httpx.WriteError(res, req, httpx.NotFound("strategy not found"))
+		return
+	}
+
+	state := strategyEntities.LiveStateEntity{
+		StrategyUUID:  strategy.UUID,
+		AccountID:     strategy.AccountID,
+		PortfolioUUID: strategy.PortfolioUUID,
+	}
+	err := db.Collection(datastores.StrategyLive).FindOne(req.Context(), bson.M{"strategy_uuid": strategyUUID}).Decode(&state)
+	if err != nil && !errors.Is(err, mongo.ErrNoDocuments) {
+		httpx.WriteError(res, req, httpx.Internal("failed to fetch live state"))
+		return
+	}
+	if state.Positions == nil {
+		state.Positions = []strategyEntities.LivePosition{}
+	}
+
+	live := strategy.Live
+	if live == nil {
+		live = &strategyEntities.LiveSettings{}
+	}
+	httpx.WriteJSON(res, http.StatusOK, map[string]any{"live": live, "state": state})
+}
+
+// GetStrategyDecisions lists a live strategy's decisions, newest first.
+func GetStrategyDecisions(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	strategyUUID := strings.TrimSpace(req.URL.Query().Get("strategy_uuid"))
+	if strategyUUID == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("strategy_uuid is required", nil))
+		return
+	}
+	limit := defaultListedDecisions
+	if s := req.URL.Query().Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n <= 0 {
+			httpx.WriteError(res, req, httpx.BadRequest("limit must be a positive integer", nil))
+			return
+		}
+		limit = min(n, maxListedDecisions)
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	cur, err := db.Collection(datastores.StrategyDecisions).Find(req.Context(),
+		bson.M{"strategy_uuid": strategyUUID, "account_id": *account.AccountID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}).SetLimit(int64(limit)))
+	if err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to fetch decisions"))
+		return
+	}
+	defer cur.Close(req.Context())
+
+	var decisions []strategyEntities.LiveDecisionEntity
+	if err := cur.All(req.Context(), &decisions); err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to decode decisions"))
+		return
+	}
+	if decisions == nil {
+		decisions = []strategyEntities.LiveDecisionEntity{}
+	}
+
+	httpx.WriteJSON(res, http.StatusOK, map[string]any{"decisions": decisions})
+}
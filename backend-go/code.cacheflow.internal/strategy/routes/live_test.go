@@ -0,0 +1,207 @@
+package routes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"code.cacheflow.internal/datafeed"
+	orderEntities "code.cacheflow.internal/portfolio/order/entities"
+	orderHandler "code.cacheflow.internal/portfolio/order/handler"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// liveProvider adds snapshots to stubProvider.
+type liveProvider struct {
+	*stubProvider
+	snapshots map[string]float64
+}
+
+func (p *liveProvider) Snapshot(_ context.Context, ticker string) (*datafeed.Snapshot, error) {
+	price := p.snapshots[ticker]
+	return &datafeed.Snapshot{Ticker: ticker, LastPrice: price, DayOpen: price, DayHigh: price, DayLow: price}, nil
+}
+
+// fakeBroker fills every order at Price and records it.
+type fakeBroker struct {
+	Price  float64
+	Shares int64
+	Orders []orderHandler.OrderRequest
+}
+
+func (b *fakeBroker) PlaceOrder(_ context.Context, req orderHandler.OrderRequest) (*orderEntities.OrderEntity, error) {
+	b.Orders = append(b.Orders, req)
+	id := "order-" + req.Side
+	total := b.Price * float64(req.Quantity)
+	realized := 0.0
+	if req.Side == "SELL" {
+		realized = total - 139*float64(req.Quantity)
+	}
+	return &orderEntities.OrderEntity{UUID: &id, Price: &b.Price, Quantity: &req.Quantity, TotalCost: &total, Realized: &realized}, nil
+}
+
+func (b *fakeBroker) ActiveShares(string, string) (int64, error) {
+	return b.Shares, nil
+}
+
+func liveStrategy(cadence strategyEntities.LiveCadence) *strategyEntities.StrategyEntity {
+	strategy := momentumStrategy(0)
+	strategy.UUID = "strategy-1"
+	strategy.Live = &strategyEntities.LiveSettings{Enabled: true, Cadence: cadence}
+	return strategy
+}
+
+func TestMarketHours(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Now     time.Time
+		Open    bool
+		Settled bool // is the bar dated that day in New York complete
+	}{
+		{
+			Name:    "Before the open",
+			Now:     time.Date(2024, 2, 12, 14, 0, 0, 0, time.UTC), // 09:00 ET
+			Open:    false,
+			Settled: false,
+		},
+		{
+			Name:    "During the session",
+			Now:     time.Date(2024, 2, 12, 15, 0, 0, 0, time.UTC), // 10:00 ET
+			Open:    true,
+			Settled: false,
+		},
+		{
+			Name:    "Just after the close",
+			Now:     time.Date(2024, 2, 12, 21, 5, 0, 0, time.UTC), // 16:05 ET
+			Open:    false,
+			Settled: false,
+		},
+		{
+			Name:    "Once the bar has settled",
+			Now:     time.Date(2024, 2, 12, 21, 20, 0, 0, time.UTC), // 16:20 ET
+			Open:    false,
+			Settled: true,
+		},
+		{
+			Name:    "Weekend",
+			Now:     time.Date(2024, 2, 10, 15, 0, 0, 0, time.UTC),
+			Open:    false,
+			Settled: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Open, marketOpen(tt.Now))
+			assert.Equal(t, tt.Settled, barSettled(tt.Now.In(marketZone).Format("2006-01-02"), tt.Now))
+			assert.True(t, barSettled("2024-02-09", tt.Now))
+		})
+	}
+}
+
+func TestLiveDailyEvaluation(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(47, 100, 1)}}
+	strategy := liveStrategy(strategyEntities.LiveDaily)
+	broker := &fakeBroker{Price: 139}
+	state := &strategyEntities.LiveStateEntity{StrategyUUID: strategy.UUID, PortfolioUUID: "portfolio-1"}
+	friday := time.Date(2024, 2, 9, 22, 0, 0, 0, time.UTC) // after the 2024-02-09 bar settles
+
+	// Entry on the 2024-02-09 close of 139
+	decisions := evaluateLive(context.Background(), provider, broker, strategy, state, []string{"AAA"}, liveAccount{Cash: 10000}, friday)
+	assert.Len(t, decisions, 1)
+	assert.Equal(t, strategyEntities.LiveBuy, decisions[0].Action)
+	assert.Equal(t, "2024-02-09", decisions[0].BarDate)
+	assert.Equal(t, int64(71), decisions[0].Quantity) // floor(10000 / 139)
+	assert.Equal(t, "strategy-1", broker.Orders[0].StrategyUUID)
+	assert.Equal(t, "2024-02-09", state.LastBarDate)
+	assert.Len(t, state.Positions, 1)
+
+	// Nothing new to evaluate
+	decisions = evaluateLive(context.Background(), provider, broker, strategy, state, []string{"AAA"}, liveAccount{Cash: 131}, friday.Add(time.Hour))
+	assert.Nil(t, decisions)
+	assert.Len(t, broker.Orders, 1)
+
+	// 146 clears the 5% target on 139; no re-entry on the exit bar
+	broker.Price, broker.Shares = 146, 71
+	decisions = evaluateLive(context.Background(), provider, broker, strategy, state, []string{"AAA"}, liveAccount{Cash: 131}, friday.AddDate(0, 0, 7))
+	assert.Len(t, decisions, 2)
+	assert.Equal(t, strategyEntities.LiveSell, decisions[0].Action)
+	assert.Equal(t, int64(71), decisions[0].Quantity)
+	assert.Equal(t, strategyEntities.LiveSkip, decisions[1].Action)
+	assert.Empty(t, state.Positions)
+	assert.Equal(t, "2024-02-16", state.LastExit["AAA"])
+	assert.InDelta(t, 7.0/139*100, state.ClosedReturns[0], 1e-9)
+}
+
+func TestLiveExitSkipsSoldPositions(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(47, 100, 1)}}
+	strategy := liveStrategy(strategyEntities.LiveDaily)
+	broker := &fakeBroker{Price: 146, Shares: 0}
+	state := &strategyEntities.LiveStateEntity{
+		StrategyUUID: strategy.UUID,
+		Positions:    []strategyEntities.LivePosition{{Ticker: "AAA", Shares: 71, EntryPrice: 139, PeakPrice: 139}},
+	}
+
+	// Sold by hand since the entry: the tracked position is dropped without an order
+	decisions := evaluateLive(context.Background(), provider, broker, strategy, state, []string{"AAA"}, liveAccount{}, time.Date(2024, 2, 16, 22, 0, 0, 0, time.UTC))
+	assert.Equal(t, strategyEntities.LiveSkip, decisions[0].Action)
+	assert.Empty(t, broker.Orders)
+	assert.Empty(t, state.Positions)
+}
+
+func TestLiveIntradayUsesFormingBar(t *testing.T) {
+	provider := &liveProvider{
+		stubProvider: &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(42, 100, 1)}},
+		snapshots:    map[string]float64{"AAA": 145},
+	}
+	strategy := liveStrategy(strategyEntities.LiveIntraday)
+	broker := &fakeBroker{Price: 145}
+	state := &strategyEntities.LiveStateEntity{StrategyUUID: strategy.UUID}
+	session := time.Date(2024, 2, 12, 15, 0, 0, 0, time.UTC) // Monday 10:00 ET
+
+	decisions := evaluateLive(context.Background(), provider, broker, strategy, state, []string{"AAA"}, liveAccount{Cash: 10000}, session)
+	assert.Equal(t, strategyEntities.LiveBuy, decisions[0].Action)
+	assert.Equal(t, "2024-02-12", decisions[0].BarDate)
+	assert.Equal(t, 145.0, state.Positions[0].PeakPrice)
+	assert.Equal(t, "2024-02-11", state.LastBarDate) // the forming bar is not settled
+
+	// Every tick in the session is evaluated
+	decisions = evaluateLive(context.Background(), provider, broker, strategy, state, []string{"AAA"}, liveAccount{}, session.Add(15*time.Minute))
+	assert.Equal(t, strategyEntities.LiveHold, decisions[0].Action)
+}
+
+func TestLiveUnsupported(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Strategy strategyEntities.StrategyEntity
+		Expect   string
+	}{
+		{
+			Name:     "Daily long at the close",
+			Strategy: strategyEntities.StrategyEntity{Execution: strategyEntities.ExecutionSameClose},
+		},
+		{
+			Name:     "Next-open fills",
+			Strategy: strategyEntities.StrategyEntity{Execution: strategyEntities.ExecutionNextOpen},
+			Expect:   "live trading executes at the close only",
+		},
+		{
+			Name:     "Short side",
+			Strategy: strategyEntities.StrategyEntity{Direction: strategyEntities.DirectionLongShort},
+			Expect:   "live trading supports long-only strategies",
+		},
+		{
+			Name:     "Intraday bars",
+			Strategy: strategyEntities.StrategyEntity{Timeframe: strategyEntities.Timeframe1Hour},
+			Expect:   "live trading supports daily bars only",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Expect, liveUnsupported(&tt.Strategy))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package routes
+
+import (
+	"context"
+	"os"
+
+	datastores "code.cacheflow.internal/datastores/mongo"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+
+	"github.com/charmbracelet/log"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// MigrateRuleTrees back-fills entry_rules on strategies saved before rule
+// trees existed, converting their flat buy_rules into the equivalent AND
+// group. Documents that already have a tree are left untouched, so it is safe
+// to run on every startup.
+func MigrateRuleTrees(ctx context.Context) {
+	logger := log.NewWithOptions(os.Stderr, log.Options{
+		ReportTimestamp: true,
+		TimeFormat:      "2006-01-02 15:04:05",
+		Prefix:          "STRATEGY (MIGRATE)",
+	})
+
+	db := datastores.GetMongoDatabase(ctx)
+	if db == nil {
+		return
+	}
+
+	filter := bson.M{
+		"entry_rules": bson.M{"$exists": false},
+		"buy_rules.0": bson.M{"$exists": true},
+	}
+	cur, err := db.Collection(datastores.Strategies).Find(ctx, filter)
+	if err != nil {
+		logger.Error("failed to load legacy strategies", "err", err)
+		return
+	}
+	defer cur.Close(ctx)
+
+	migrated := 0
+	for cur.Next(ctx) {
+		var strategy strategyEntities.StrategyEntity
+		if err := cur.Decode(&strategy); err != nil {
+			logger.Error("failed to decode strategy", "err", err)
+			continue
+		}
+		_, err := db.Collection(datastores.Strategies).UpdateOne(ctx,
+			bson.M{"uuid": strategy.UUID},
+			bson.M{"$set": bson.M{"entry_rules": strategyEntities.AllOf(strategy.BuyRules)}})
+		if err != nil {
+			logger.Error("failed to migrate strategy", "uuid", strategy.UUID, "err", err)
+			continue
+		}
+		migrated++
+	}
+	if migrated > 0 {
+		logger.Info("migrated strategies to rule trees", "count", migrated)
+	}
+}
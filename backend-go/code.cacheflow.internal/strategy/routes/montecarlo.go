@@ -2,52 +2,37 @@ package routes
 
 import (
 	"encoding/json"
-	"math"
-	"math/rand"
 	"net/http"
-	"sort"
 	"strings"
+	"time"
 
 	accountEntities "code.cacheflow.internal/account/entities"
 	datastores "code.cacheflow.internal/datastores/mongo"
 	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/strategy/metrics"
+	"code.cacheflow.internal/strategy/montecarlo"
 	"code.cacheflow.internal/util/httpx"
 
+	"github.com/pborman/uuid"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 type monteCarloBody struct {
 	BacktestUUID   string  `json:"backtest_uuid"`
-	NumSimulations int     `json:"num_simulations"`
 	InitialBalance float64 `json:"initial_balance"`
+	montecarlo.Config
 }
 
-type MonteCarloResult struct {
-	NumSimulations int       `json:"num_simulations"`
-	InitialBalance float64   `json:"initial_balance"`
-	Median         float64   `json:"median"`
-	P10            float64   `json:"p10"`
-	P25            float64   `json:"p25"`
-	P75            float64   `json:"p75"`
-	P90            float64   `json:"p90"`
-	Worst          float64   `json:"worst"`
-	Best           float64   `json:"best"`
-	ProbProfit     float64   `json:"prob_profit"` // % of sims that ended above initial balance
-	FinalValues    []float64 `json:"final_values"` // all simulation outcomes (for distribution chart)
-}
-
-func percentileF(sorted []float64, p float64) float64 {
-	if len(sorted) == 0 {
-		return 0
-	}
-	idx := p * float64(len(sorted)-1)
-	lo := int(math.Floor(idx))
-	hi := int(math.Ceil(idx))
-	if lo == hi {
-		return sorted[lo]
+// tradeReturns are the fractional returns of a backtest's closed trades.
+func tradeReturns(bt *strategyEntities.BacktestEntity) []float64 {
+	var returns []float64
+	for _, t := range bt.Trades {
+		if t.Closes() {
+			returns = append(returns, t.PnLPercent/100)
+		}
 	}
-	frac := idx - float64(lo)
-	return sorted[lo]*(1-frac) + sorted[hi]*frac
+	return returns
 }
 
 func RunMonteCarlo(res http.ResponseWriter, req *http.Request) {
@@ -76,8 +61,9 @@ func RunMonteCarlo(res http.ResponseWriter, req *http.Request) {
 		httpx.WriteError(res, req, httpx.BadRequest("backtest_uuid is required", nil))
 		return
 	}
-	if body.NumSimulations <= 0 || body.NumSimulations > 10000 {
-		body.NumSimulations = 1000
+	if err := body.Config.Normalize(); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest(err.Error(), nil))
+		return
 	}
 
 	var bt strategyEntities.BacktestEntity
@@ -92,63 +78,88 @@ func RunMonteCarlo(res http.ResponseWriter, req *http.Request) {
 		initialBalance = bt.InitialBalance
 	}
 
-	// Extract per-trade return multipliers from SELL trades
-	var returnMultipliers []float64
-	for _, t := range bt.Trades {
-		if t.Type == "SELL" {
-			// The fraction of capital that was in the trade: value = shares * buy_price
-			// We approximate: each trade uses available cash; return multiplier from pnl_percent
-			mult := 1 + t.PnLPercent/100
-			returnMultipliers = append(returnMultipliers, mult)
+	var samples []float64
+	if body.Config.Daily() {
+		var equity strategyEntities.BacktestEquityEntity
+		if err := db.Collection(datastores.BacktestEquity).FindOne(req.Context(),
+			bson.M{"backtest_uuid": bt.UUID, "account_id": *account.AccountID}).Decode(&equity); err != nil {
+			httpx.WriteError(res, req, httpx.BadRequest("this backtest has no saved equity curve; rerun it to use block bootstrap", nil))
+			return
+		}
+		curve := make([]metrics.Point, len(equity.Points))
+		for i, p := range equity.Points {
+			curve[i] = metrics.Point{Date: p.Date, Equity: p.Equity}
+		}
+		samples = metrics.DailyReturns(bt.InitialBalance, curve)
+	} else {
+		samples = tradeReturns(&bt)
+		if len(samples) == 0 {
+			httpx.WriteError(res, req, httpx.BadRequest("no completed trades in this backtest", nil))
+			return
 		}
 	}
 
-	if len(returnMultipliers) == 0 {
-		httpx.WriteError(res, req, httpx.BadRequest("no completed trades in this backtest", nil))
+	result, err := montecarlo.Simulate(body.Config, initialBalance, samples)
+	if err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest(err.Error(), nil))
 		return
 	}
 
-	// Run N simulations: shuffle trade order each time, compound returns
-	finalValues := make([]float64, body.NumSimulations)
-	shuffled := make([]float64, len(returnMultipliers))
+	record := strategyEntities.MonteCarloEntity{
+		UUID:         uuid.New(),
+		BacktestUUID: bt.UUID,
+		StrategyUUID: bt.StrategyUUID,
+		AccountID:    *account.AccountID,
+		Result:       *result,
+		CreatedAt:    time.Now().UTC(),
+	}
+	if _, err := db.Collection(datastores.MonteCarlo).InsertOne(req.Context(), record); err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to save monte carlo run"))
+		return
+	}
 
-	for i := 0; i < body.NumSimulations; i++ {
-		copy(shuffled, returnMultipliers)
-		rand.Shuffle(len(shuffled), func(a, b int) { shuffled[a], shuffled[b] = shuffled[b], shuffled[a] })
+	httpx.WriteJSON(res, http.StatusOK, record)
+}
 
-		equity := initialBalance
-		for _, mult := range shuffled {
-			// Each trade: invest a fixed fraction of equity (simplified: 100% per trade)
-			equity *= mult
-			if equity < 0 {
-				equity = 0
-			}
-		}
-		finalValues[i] = equity
+// GetMonteCarloRuns lists the saved Monte Carlo runs of a backtest, newest first.
+func GetMonteCarloRuns(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
 	}
 
-	sort.Float64s(finalValues)
+	db := datastores.GetMongoDatabase(req.Context())
 
-	profitCount := 0
-	for _, v := range finalValues {
-		if v > initialBalance {
-			profitCount++
-		}
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	backtestUUID := strings.TrimSpace(req.URL.Query().Get("backtest_uuid"))
+	if backtestUUID == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("backtest_uuid is required", nil))
+		return
+	}
+
+	cur, err := db.Collection(datastores.MonteCarlo).Find(req.Context(),
+		bson.M{"backtest_uuid": backtestUUID, "account_id": *account.AccountID},
+		options.Find().SetSort(bson.D{{Key: "created_at", Value: -1}}))
+	if err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to fetch monte carlo runs"))
+		return
 	}
+	defer cur.Close(req.Context())
 
-	result := MonteCarloResult{
-		NumSimulations: body.NumSimulations,
-		InitialBalance: initialBalance,
-		Median:         percentileF(finalValues, 0.50),
-		P10:            percentileF(finalValues, 0.10),
-		P25:            percentileF(finalValues, 0.25),
-		P75:            percentileF(finalValues, 0.75),
-		P90:            percentileF(finalValues, 0.90),
-		Worst:          finalValues[0],
-		Best:           finalValues[len(finalValues)-1],
-		ProbProfit:     float64(profitCount) / float64(body.NumSimulations) * 100,
-		FinalValues:    finalValues,
+	var runs []strategyEntities.MonteCarloEntity
+	if err := cur.All(req.Context(), &runs); err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to decode monte carlo runs"))
+		return
+	}
+	if runs == nil {
+		runs = []strategyEntities.MonteCarloEntity{}
 	}
 
-	httpx.WriteJSON(res, http.StatusOK, result)
+	httpx.WriteJSON(res, http.StatusOK, map[string]any{"runs": runs})
 }
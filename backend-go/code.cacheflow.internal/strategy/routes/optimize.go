@@ -0,0 +1,312 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	accountEntities "code.cacheflow.internal/account/entities"
+	"code.cacheflow.internal/datafeed"
+	datastores "code.cacheflow.internal/datastores/mongo"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/strategy/metrics"
+	"code.cacheflow.internal/strategy/optimize"
+	"code.cacheflow.internal/util/httpx"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ── Optimization ──────────────────────────────────────────────────────────────
+
+// optimizeWorkers bounds how many candidate backtests run at once.
+const optimizeWorkers = 4
+
+const (
+	searchGrid   = "GRID"
+	searchRandom = "RANDOM"
+)
+
+type optimizeBody struct {
+	strategyEntities.BacktestRequest
+	optimizeSettings
+}
+
+// optimizeSettings describes the search, kept on the job as submitted with
+// the seed filled in.
+type optimizeSettings struct {
+	Params    []optimize.Param   `json:"params"`
+	Search    string             `json:"search"`     // GRID (default) or RANDOM
+	Samples   int                `json:"samples"`    // RANDOM: candidates to draw
+	Seed      int64              `json:"seed"`       // RANDOM: 0 picks one
+	Objective optimize.Objective `json:"objective"`  // default SHARPE
+	MinTrades int                `json:"min_trades"` // candidates with fewer trades rank last
+}
+
+// candidates validates the search settings, fills in defaults and expands the
+// parameter space.
+func (b *optimizeSettings) candidates() ([]optimize.Candidate, error) {
+	if !b.Objective.Valid() {
+		return nil, errors.New("unknown objective")
+	}
+	if b.Objective == "" {
+		b.Objective = optimize.ObjectiveSharpe
+	}
+
+	switch strings.ToUpper(b.Search) {
+	case "", searchGrid:
+		return optimize.Grid(b.Params)
+	case searchRandom:
+		if b.Seed == 0 {
+			b.Seed = time.Now().UnixNano()
+		}
+		return optimize.Random(b.Params, b.Samples, rand.New(rand.NewSource(b.Seed)))
+	}
+	return nil, fmt.Errorf("search must be %s or %s", searchGrid, searchRandom)
+}
+
+// OptimizeResult is one candidate's outcome. Params maps each path to the
+// value it took.
+type OptimizeResult struct {
+	Rank         int                `json:"rank"`
+	Params       map[string]float64 `json:"params"`
+	Score        float64            `json:"score"`
+	ROI          float64            `json:"roi"`
+	FinalBalance float64            `json:"final_balance"`
+	TotalTrades  int                `json:"total_trades"`
+	MaxDrawdown  float64            `json:"max_drawdown"`
+	Metrics      metrics.Stats      `json:"metrics"`
+	Error        string             `json:"error,omitempty"`
+
+	qualified bool
+}
+
+// rankResults sorts results best first: qualified runs by score, then runs
+// with too few trades, then failures.
+func rankResults(results []*OptimizeResult) {
+	tier := func(r *OptimizeResult) int {
+		switch {
+		case r.Error != "":
+			return 2
+		case !r.qualified:
+			return 1
+		}
+		return 0
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		ti, tj := tier(results[i]), tier(results[j])
+		if ti != tj {
+			return ti < tj
+		}
+		return results[i].Score > results[j].Score
+	})
+	for i, r := range results {
+		r.Rank = i + 1
+	}
+}
+
+// memoProvider caches Bars responses for the lifetime of one request, so a
+// candidate sweep fetches each series once.
+type memoProvider struct {
+	datafeed.MarketDataProvider
+
+	mu   sync.Mutex
+	bars map[datafeed.BarsRequest]*memoBars
+}
+
+type memoBars struct {
+	once sync.Once
+	bars []datafeed.Bar
+	err  error
+}
+
+func newMemoProvider(p datafeed.MarketDataProvider) *memoProvider {
+	return &memoProvider{MarketDataProvider: p, bars: make(map[datafeed.BarsRequest]*memoBars)}
+}
+
+func (p *memoProvider) Bars(ctx context.Context, r datafeed.BarsRequest) ([]datafeed.Bar, error) {
+	p.mu.Lock()
+	m, ok := p.bars[r]
+	if !ok {
+		m = &memoBars{}
+		p.bars[r] = m
+	}
+	p.mu.Unlock()
+
+	m.once.Do(func() { m.bars, m.err = p.MarketDataProvider.Bars(ctx, r) })
+	return m.bars, m.err
+}
+
+// candidateStrategy applies c to base. Editing buy_rules rebuilds the entry
+// tree from them, since the tree supersedes the flat list.
+func candidateStrategy(base *strategyEntities.StrategyEntity, params []optimize.Param, c optimize.Candidate) (*strategyEntities.StrategyEntity, error) {
+	var s strategyEntities.StrategyEntity
+	if err := optimize.Apply(base, params, c, &s); err != nil {
+		return nil, err
+	}
+	for _, p := range params {
+		if strings.HasPrefix(p.Path, "buy_rules.") {
+			s.EntryRules = strategyEntities.AllOf(s.BuyRules)
+			break
+		}
+	}
+	if errs := s.ValidateRules(); errs != nil {
+		return nil, errs
+	}
+	if s.PositionSizing != nil {
+		if err := s.PositionSizing.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+// runOptimization backtests every candidate on a bounded worker pool and
+// returns the results ranked best first. Candidates are scored on their own
+// runs, so the passive comparisons are skipped. run.Progress, if set, hears
+// the share of candidates done.
+func runOptimization(ctx context.Context, provider datafeed.MarketDataProvider, base *strategyEntities.StrategyEntity, run backtestRun, params []optimize.Param, candidates []optimize.Candidate, objective optimize.Objective, minTrades int) []*OptimizeResult {
+	provider = newMemoProvider(provider)
+	progress := run.Progress
+	run.BuyAndHold, run.Benchmark, run.Progress = false, "", nil
+	results := make([]*OptimizeResult, len(candidates))
+
+	var mu sync.Mutex
+	done := 0
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for range min(optimizeWorkers, len(candidates)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				results[i] = runCandidate(ctx, provider, base, run, params, candidates[i], objective, minTrades)
+				if progress != nil {
+					mu.Lock()
+					done++
+					progress(float64(done) / float64(len(candidates)) * 100)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for i := range candidates {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	rankResults(results)
+	return results
+}
+
+func runCandidate(ctx context.Context, provider datafeed.MarketDataProvider, base *strategyEntities.StrategyEntity, run backtestRun, params []optimize.Param, c optimize.Candidate, objective optimize.Objective, minTrades int) *OptimizeResult {
+	r := &OptimizeResult{Params: make(map[string]float64, len(params))}
+	for i, p := range params {
+		r.Params[p.Path] = c[i]
+	}
+
+	strategy, err := candidateStrategy(base, params, c)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+	result, err := runBacktestEngine(ctx, provider, strategy, run)
+	if err != nil {
+		r.Error = err.Error()
+		return r
+	}
+
+	r.ROI = result.ROI
+	r.FinalBalance = result.FinalBalance
+	r.TotalTrades = result.TotalTrades
+	r.MaxDrawdown = result.MaxDrawdown
+	r.Metrics = result.Metrics
+	r.Score = objective.Score(result.ROI, result.Metrics)
+	r.qualified = result.WinningTrades+result.LosingTrades >= minTrades
+	return r
+}
+
+// optimizeReport is the result of an optimization job.
+type optimizeReport struct {
+	Evaluated int               `json:"evaluated"`
+	Results   []*OptimizeResult `json:"results"`
+}
+
+// runOptimizeJob runs a queued optimization over the prepared backtest.
+func runOptimizeJob(ctx context.Context, job *strategyEntities.BacktestJobEntity, prepared *preparedBacktest) (*optimizeReport, error) {
+	var settings optimizeSettings
+	if err := json.Unmarshal(job.Settings, &settings); err != nil {
+		return nil, errors.New("invalid optimization settings")
+	}
+	candidates, err := settings.candidates()
+	if err != nil {
+		return nil, err
+	}
+
+	results := runOptimization(ctx, datafeed.GetProvider(), &prepared.Strategy, prepared.Run,
+		settings.Params, candidates, settings.Objective, settings.MinTrades)
+	return &optimizeReport{Evaluated: len(results), Results: results}, nil
+}
+
+// OptimizeStrategy queues a backtest of a strategy across a grid or random
+// sample of parameter values. It responds 202 with the job; once it succeeds,
+// GetBacktestJob returns the candidates ranked by the objective. Nothing is
+// saved to the strategy; apply the winning values with UpdateStrategy.
+func OptimizeStrategy(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	var body optimizeBody
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("invalid request body", nil))
+		return
+	}
+
+	if _, err := body.candidates(); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest(err.Error(), nil))
+		return
+	}
+	settings, err := json.Marshal(body.optimizeSettings)
+	if err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to encode optimization settings"))
+		return
+	}
+
+	prepared, err := prepareBacktest(req.Context(), db, *account.AccountID, body.BacktestRequest)
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+
+	job := strategyEntities.BacktestJobEntity{
+		AccountID:    *account.AccountID,
+		StrategyUUID: body.StrategyUUID,
+		Request:      prepared.Body,
+		Kind:         strategyEntities.JobOptimize,
+		Settings:     settings,
+	}
+	if err := queueJob(req.Context(), db, &job); err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+
+	httpx.WriteJSON(res, http.StatusAccepted, job)
+}
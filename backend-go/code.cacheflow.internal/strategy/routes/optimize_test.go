@@ -0,0 +1,46 @@
+package routes
+
+import (
+	"context"
+	"testing"
+
+	"code.cacheflow.internal/datafeed"
+	"code.cacheflow.internal/strategy/optimize"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunOptimizationRanksCandidates(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(40, 100, 1)}}
+	params := []optimize.Param{{Path: "sell_conditions.0.percent", Values: []float64{2, 20}}}
+	candidates, err := optimize.Grid(params)
+	assert.NoError(t, err)
+
+	run := testRun("AAA")
+	var progress []float64
+	run.Progress = func(percent float64) { progress = append(progress, percent) }
+
+	results := runOptimization(context.Background(), provider, momentumStrategy(0), run,
+		params, candidates, optimize.ObjectiveROI, 0)
+
+	assert.Equal(t, []float64{50, 100}, progress) // per candidate, not per bar
+	assert.Len(t, results, 2)
+	assert.Equal(t, 1, results[0].Rank)
+	// A steady climb rewards staying in: the 20% target beats churning at 2%
+	assert.Equal(t, 20.0, results[0].Params["sell_conditions.0.percent"])
+	assert.Greater(t, results[0].Score, results[1].Score)
+}
+
+func TestRunOptimizationRanksFailuresLast(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(40, 100, 1)}}
+	params := []optimize.Param{{Path: "buy_rules.0.window", Values: []float64{2.5, 3}}}
+	candidates, err := optimize.Grid(params)
+	assert.NoError(t, err)
+
+	results := runOptimization(context.Background(), provider, momentumStrategy(0), testRun("AAA"),
+		params, candidates, optimize.ObjectiveSharpe, 100)
+
+	assert.Empty(t, results[0].Error) // under-traded, but ran
+	assert.NotEmpty(t, results[1].Error)
+	assert.Equal(t, 2.5, results[1].Params["buy_rules.0.window"])
+}
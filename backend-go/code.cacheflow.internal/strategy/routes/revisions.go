@@ -0,0 +1,269 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	accountEntities "code.cacheflow.internal/account/entities"
+	datastores "code.cacheflow.internal/datastores/mongo"
+	"code.cacheflow.internal/portfolio/costs"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/util/httpx"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ── Revisions ─────────────────────────────────────────────────────────────────
+
+// ensureRevision makes sure the strategy's current definition is stored as a
+// revision and returns its number. Strategies saved before versioning become
+// revision 1.
+func ensureRevision(ctx context.Context, db *mongo.Database, strategy *strategyEntities.StrategyEntity) (int, error) {
+	if strategy.Revision == 0 {
+		strategy.Revision = 1
+		_, err := db.Collection(datastores.Strategies).UpdateOne(ctx,
+			bson.M{"uuid": strategy.UUID, "account_id": strategy.AccountID, "revision": bson.M{"$exists": false}},
+			bson.M{"$set": bson.M{"revision": 1}})
+		if err != nil {
+			return 0, err
+		}
+	}
+	if err := saveRevision(ctx, db, strategy); err != nil {
+		return 0, err
+	}
+	return strategy.Revision, nil
+}
+
+// saveRevision stores strategy as its current revision. A revision that is
+// already stored is left untouched; revisions never change once written.
+func saveRevision(ctx context.Context, db *mongo.Database, strategy *strategyEntities.StrategyEntity) error {
+	_, err := db.Collection(datastores.StrategyRevisions).UpdateOne(ctx,
+		bson.M{"strategy_uuid": strategy.UUID, "revision": strategy.Revision},
+		bson.M{"$setOnInsert": strategyEntities.NewRevision(strategy, time.Now().UTC())},
+		options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		// Another request stored it first
+		return nil
+	}
+	return err
+}
+
+// loadRevision fetches one stored revision. Errors are *httpx.Error.
+func loadRevision(ctx context.Context, db *mongo.Database, accountID, strategyUUID string, revision int) (*strategyEntities.StrategyRevisionEntity, error) {
+	var rev strategyEntities.StrategyRevisionEntity
+	err := db.Collection(datastores.StrategyRevisions).FindOne(ctx,
+		bson.M{"strategy_uuid": strategyUUID, "account_id": accountID, "revision": revision}).Decode(&rev)
+	if err != nil {
+		return nil, httpx.NotFound(fmt.Sprintf("revision %d not found", revision))
+	}
+	return &rev, nil
+}
+
+// GetStrategyRevisions lists a strategy's revisions, newest first.
+func GetStrategyRevisions(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	strategyUUID := strings.TrimSpace(req.URL.Query().Get("strategy_uuid"))
+	if strategyUUID == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("strategy_uuid is required", nil))
+		return
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	var strategy strategyEntities.StrategyEntity
+	if err := db.Collection(datastores.Strategies).FindOne(req.Context(),
+		bson.M{"uuid": strategyUUID, "account_id": *account.AccountID}).Decode(&strategy); err != nil {
+		httpx.WriteError(res, req, httpx.NotFound("strategy not found"))
+		return
+	}
+	if _, err := ensureRevision(req.Context(), db, &strategy); err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to record strategy revision"))
+		return
+	}
+
+	cur, err := db.Collection(datastores.StrategyRevisions).Find(req.Context(),
+		bson.M{"strategy_uuid": strategyUUID, "account_id": *account.AccountID},
+		options.Find().SetSort(bson.D{{Key: "revision", Value: -1}}))
+	if err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to fetch revisions"))
+		return
+	}
+	defer cur.Close(req.Context())
+
+	var revisions []strategyEntities.StrategyRevisionEntity
+	if err := cur.All(req.Context(), &revisions); err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to decode revisions"))
+		return
+	}
+	if revisions == nil {
+		revisions = []strategyEntities.StrategyRevisionEntity{}
+	}
+
+	httpx.WriteJSON(res, http.StatusOK, map[string]any{"current": strategy.Revision, "revisions": revisions})
+}
+
+// DiffStrategyRevisions lists the definition fields that changed between two
+// revisions. to defaults to the current revision.
+func DiffStrategyRevisions(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	q := req.URL.Query()
+	strategyUUID := strings.TrimSpace(q.Get("strategy_uuid"))
+	if strategyUUID == "" || q.Get("from") == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("strategy_uuid and from are required", nil))
+		return
+	}
+	from, err := strconv.Atoi(q.Get("from"))
+	if err != nil || from <= 0 {
+		httpx.WriteError(res, req, httpx.BadRequest("from must be a positive revision", nil))
+		return
+	}
+	to := 0
+	if s := q.Get("to"); s != "" {
+		if to, err = strconv.Atoi(s); err != nil || to <= 0 {
+			httpx.WriteError(res, req, httpx.BadRequest("to must be a positive revision", nil))
+			return
+		}
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	if to == 0 {
+		var strategy strategyEntities.StrategyEntity
+		if err := db.Collection(datastores.Strategies).FindOne(req.Context(),
+			bson.M{"uuid": strategyUUID, "account_id": *account.AccountID}).Decode(&strategy); err != nil {
+			httpx.WriteError(res, req, httpx.NotFound("strategy not found"))
+			return
+		}
+		if to, err = ensureRevision(req.Context(), db, &strategy); err != nil {
+			httpx.WriteError(res, req, httpx.Internal("failed to record strategy revision"))
+			return
+		}
+	}
+
+	a, err := loadRevision(req.Context(), db, *account.AccountID, strategyUUID, from)
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+	b, err := loadRevision(req.Context(), db, *account.AccountID, strategyUUID, to)
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+
+	changes := strategyEntities.DiffStrategies(&a.Strategy, &b.Strategy)
+	if changes == nil {
+		changes = []strategyEntities.StrategyChange{}
+	}
+
+	httpx.WriteJSON(res, http.StatusOK, map[string]any{"from": from, "to": to, "changes": changes})
+}
+
+type rerunBacktestBody struct {
+	BacktestUUID string `json:"backtest_uuid"`
+}
+
+// RerunBacktest repeats a saved backtest against the strategy revision,
+// universe, dates, costs and execution model it originally ran with.
+func RerunBacktest(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	var body rerunBacktestBody
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("invalid request body", nil))
+		return
+	}
+	if body.BacktestUUID == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("backtest_uuid is required", nil))
+		return
+	}
+
+	var original strategyEntities.BacktestEntity
+	if err := db.Collection(datastores.Backtests).FindOne(req.Context(),
+		bson.M{"uuid": body.BacktestUUID, "account_id": *account.AccountID}).Decode(&original); err != nil {
+		httpx.WriteError(res, req, httpx.NotFound("backtest not found"))
+		return
+	}
+	if original.StrategyRevision == 0 {
+		httpx.WriteError(res, req, httpx.BadRequest("backtest predates strategy revisions and cannot be reproduced", nil))
+		return
+	}
+
+	record, err := runBacktestRequest(req.Context(), db, *account.AccountID, rerunRequest(&original))
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+
+	httpx.WriteJSON(res, http.StatusOK, record)
+}
+
+// rerunRequest rebuilds the request that produced a saved backtest.
+func rerunRequest(b *strategyEntities.BacktestEntity) strategyEntities.BacktestRequest {
+	r := strategyEntities.BacktestRequest{
+		StrategyUUID:   b.StrategyUUID,
+		Revision:       b.StrategyRevision,
+		Tickers:        b.Tickers,
+		FromDate:       b.FromDate,
+		ToDate:         b.ToDate,
+		InitialBalance: b.InitialBalance,
+		Costs:          b.CostModel,
+		Execution:      b.Execution,
+		BuyAndHold:     b.BuyAndHold != nil,
+	}
+	if len(r.Tickers) == 0 {
+		r.Ticker = b.Ticker
+	}
+	if r.Costs == nil {
+		// Saved without costs: keep it frictionless rather than use the portfolio's
+		r.Costs = &costs.Model{}
+	}
+	if r.Execution == "" {
+		r.Execution = strategyEntities.ExecutionSameClose
+	}
+	if b.Benchmark != nil && len(b.Benchmark.Tickers) > 0 {
+		r.Benchmark = b.Benchmark.Tickers[0]
+	}
+	return r
+}
@@ -0,0 +1,55 @@
+package routes
+
+import (
+	"testing"
+
+	"code.cacheflow.internal/portfolio/costs"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRerunRequest(t *testing.T) {
+	model := &costs.Model{PerTrade: 1}
+
+	tests := []struct {
+		Name     string
+		Backtest strategyEntities.BacktestEntity
+		Expect   strategyEntities.BacktestRequest
+	}{
+		{
+			Name: "Universe, comparisons and costs carry over",
+			Backtest: strategyEntities.BacktestEntity{
+				StrategyUUID: "s-1", StrategyRevision: 2, Ticker: "AAA", Tickers: []string{"AAA", "BBB"},
+				FromDate: "2024-01-01", ToDate: "2024-06-30", InitialBalance: 10000,
+				CostModel: model, Execution: strategyEntities.ExecutionNextOpen,
+				BuyAndHold: &strategyEntities.BacktestComparison{},
+				Benchmark:  &strategyEntities.BacktestComparison{Tickers: []string{"SPY"}},
+			},
+			Expect: strategyEntities.BacktestRequest{
+				StrategyUUID: "s-1", Revision: 2, Tickers: []string{"AAA", "BBB"},
+				FromDate: "2024-01-01", ToDate: "2024-06-30", InitialBalance: 10000,
+				Costs: model, Execution: strategyEntities.ExecutionNextOpen,
+				BuyAndHold: true, Benchmark: "SPY",
+			},
+		},
+		{
+			Name: "Frictionless single-ticker backtests stay that way",
+			Backtest: strategyEntities.BacktestEntity{
+				StrategyUUID: "s-1", StrategyRevision: 1, Ticker: "AAA",
+				FromDate: "2024-01-01", ToDate: "2024-06-30", InitialBalance: 5000,
+			},
+			Expect: strategyEntities.BacktestRequest{
+				StrategyUUID: "s-1", Revision: 1, Ticker: "AAA",
+				FromDate: "2024-01-01", ToDate: "2024-06-30", InitialBalance: 5000,
+				Costs: &costs.Model{}, Execution: strategyEntities.ExecutionSameClose,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Expect, rerunRequest(&tt.Backtest))
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	datastores "code.cacheflow.internal/datastores/mongo"
+	"code.cacheflow.internal/portfolio/costs"
+	portfolioEntities "code.cacheflow.internal/portfolio/management/entities"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// maxUniverseSize caps how many symbols a single backtest may trade.
+const maxUniverseSize = 50
+
+// normalizeTickers upper-cases, trims and de-duplicates tickers, keeping order.
+func normalizeTickers(tickers []string) []string {
+	seen := make(map[string]bool, len(tickers))
+	out := make([]string, 0, len(tickers))
+	for _, t := range tickers {
+		t = strings.ToUpper(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		out = append(out, t)
+	}
+	return out
+}
+
+// strategyUniverse resolves the symbols a strategy trades: its explicit ticker
+// list, else the tickers of its watchlist, else its single ticker.
+func strategyUniverse(ctx context.Context, db *mongo.Database, strategy *strategyEntities.StrategyEntity) ([]string, error) {
+	if tickers := normalizeTickers(strategy.Tickers); len(tickers) > 0 {
+		return tickers, nil
+	}
+
+	if strategy.WatchlistUUID != "" {
+		var portfolio portfolioEntities.PortfolioEntity
+		err := db.Collection(datastores.Portfolios).FindOne(ctx,
+			bson.M{"uuid": strategy.PortfolioUUID, "account_id": strategy.AccountID}).Decode(&portfolio)
+		if err != nil {
+			return nil, fmt.Errorf("portfolio for watchlist %s not found", strategy.WatchlistUUID)
+		}
+		for _, w := range portfolio.Watchlists {
+			if w == nil || w.UUID == nil || *w.UUID != strategy.WatchlistUUID {
+				continue
+			}
+			var tickers []string
+			for _, t := range w.Tickers {
+				if t != nil {
+					tickers = append(tickers, *t)
+				}
+			}
+			if tickers = normalizeTickers(tickers); len(tickers) > 0 {
+				return tickers, nil
+			}
+			return nil, fmt.Errorf("watchlist %s has no tickers", strategy.WatchlistUUID)
+		}
+		return nil, fmt.Errorf("watchlist %s not found", strategy.WatchlistUUID)
+	}
+
+	if t := normalizeTickers([]string{strategy.Ticker}); len(t) > 0 {
+		return t, nil
+	}
+	return nil, fmt.Errorf("strategy has no tickers")
+}
+
+// portfolioCostModel returns the cost model of the strategy's portfolio, or nil
+// when it has none.
+func portfolioCostModel(ctx context.Context, db *mongo.Database, strategy *strategyEntities.StrategyEntity) *costs.Model {
+	var portfolio portfolioEntities.PortfolioEntity
+	err := db.Collection(datastores.Portfolios).FindOne(ctx,
+		bson.M{"uuid": strategy.PortfolioUUID, "account_id": strategy.AccountID}).Decode(&portfolio)
+	if err != nil {
+		return nil
+	}
+	return portfolio.CostModel
+}
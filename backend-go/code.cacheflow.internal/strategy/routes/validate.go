@@ -0,0 +1,102 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"code.cacheflow.internal/strategy/dsl"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/util/httpx"
+)
+
+// ── Validate ──────────────────────────────────────────────────────────────────
+
+type validateStrategyBody struct {
+	PositionSizing *strategyEntities.PositionSizing `json:"position_sizing"`
+	Execution      strategyEntities.ExecutionModel  `json:"execution"`
+	BuyRules       []strategyEntities.Rule          `json:"buy_rules"`
+	EntryRules     *strategyEntities.RuleGroup      `json:"entry_rules"`
+	ExitRules      *strategyEntities.RuleGroup      `json:"exit_rules"`
+	SellConditions []strategyEntities.SellCondition `json:"sell_conditions"`
+	DSL            string                           `json:"dsl"`
+
+	Direction       strategyEntities.TradeDirection `json:"direction"`
+	ShortEntryRules *strategyEntities.RuleGroup     `json:"short_entry_rules"`
+	ShortExitRules  *strategyEntities.RuleGroup     `json:"short_exit_rules"`
+	MaxEntries      int                             `json:"max_entries"`
+	Timeframe       strategyEntities.Timeframe      `json:"timeframe"`
+	FlattenAtClose  bool                            `json:"flatten_at_close"`
+}
+
+// ValidateStrategy runs the checks CreateStrategy and UpdateStrategy apply to
+// a strategy's rules, sizing and execution without saving anything. Problems
+// are returned by field path with a 200 so forms can mark each input.
+func ValidateStrategy(res http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("x-cf-uid") == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	var body validateStrategyBody
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("invalid request body", nil))
+		return
+	}
+
+	httpx.WriteJSON(res, http.StatusOK, validateStrategy(body))
+}
+
+// strategyValidation is the response of ValidateStrategy. A DSL syntax error
+// is also given with its position, for editors to mark.
+type strategyValidation struct {
+	Valid    bool                         `json:"valid"`
+	Errors   strategyEntities.FieldErrors `json:"errors"`
+	DSLError *dsl.Error                   `json:"dsl_error,omitempty"`
+}
+
+func validateStrategy(body validateStrategyBody) strategyValidation {
+	errs := strategyEntities.FieldErrors{}
+
+	if strings.TrimSpace(body.DSL) != "" {
+		if len(body.DSL) > maxDSLLength {
+			errs["dsl"] = "dsl is too long"
+			return strategyValidation{Errors: errs}
+		}
+		rules, err := dsl.Parse(body.DSL)
+		if err != nil {
+			errs["dsl"] = err.Error()
+			var syntaxErr *dsl.Error
+			errors.As(err, &syntaxErr)
+			return strategyValidation{Errors: errs, DSLError: syntaxErr}
+		}
+		body.BuyRules, body.EntryRules, body.ExitRules, body.SellConditions = nil, rules.EntryRules, rules.ExitRules, rules.SellConditions
+	}
+
+	if body.PositionSizing != nil {
+		if err := body.PositionSizing.Validate(); err != nil {
+			errs["position_sizing"] = err.Error()
+		}
+	}
+	if !body.Execution.Valid() {
+		errs["execution"] = "must be SAME_CLOSE or NEXT_OPEN"
+	}
+	_, ruleErrs := checkRules(&strategyEntities.StrategyEntity{
+		Direction:       body.Direction,
+		BuyRules:        body.BuyRules,
+		EntryRules:      body.EntryRules,
+		ExitRules:       body.ExitRules,
+		SellConditions:  body.SellConditions,
+		ShortEntryRules: body.ShortEntryRules,
+		ShortExitRules:  body.ShortExitRules,
+		MaxEntries:      body.MaxEntries,
+		Timeframe:       body.Timeframe,
+		FlattenAtClose:  body.FlattenAtClose,
+	})
+	for field, msg := range ruleErrs {
+		errs[field] = msg
+	}
+
+	return strategyValidation{Valid: len(errs) == 0, Errors: errs}
+}
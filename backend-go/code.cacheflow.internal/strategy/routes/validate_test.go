@@ -0,0 +1,77 @@
+package routes
+
+import (
+	"testing"
+
+	"code.cacheflow.internal/strategy/dsl"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateStrategy(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Body   validateStrategyBody
+		Expect strategyValidation
+	}{
+		{
+			Name: "Valid DSL",
+			Body: validateStrategyBody{DSL: "BUY WHEN rsi(14) < 30;\nSELL WHEN stop_loss 5%"},
+			Expect: strategyValidation{
+				Valid:  true,
+				Errors: strategyEntities.FieldErrors{},
+			},
+		},
+		{
+			Name: "DSL syntax error",
+			Body: validateStrategyBody{DSL: "BUY rsi < 30"},
+			Expect: strategyValidation{
+				Errors: strategyEntities.FieldErrors{"dsl": `line 1, column 5: expected WHEN, found "rsi"`},
+				DSLError: &dsl.Error{
+					Pos:     dsl.Pos{Offset: 4, Line: 1, Column: 5},
+					Message: `expected WHEN, found "rsi"`,
+				},
+			},
+		},
+		{
+			Name: "DSL rule that never fires",
+			Body: validateStrategyBody{DSL: "BUY WHEN rsi < 0"},
+			Expect: strategyValidation{
+				Errors: strategyEntities.FieldErrors{"entry_rules.rule.value": "must be between 0 and 100"},
+			},
+		},
+		{
+			Name: "Short side without entry rules",
+			Body: validateStrategyBody{
+				Direction: strategyEntities.DirectionLongShort,
+				BuyRules:  []strategyEntities.Rule{{Type: strategyEntities.RuleRSIBelow, Value: 30}},
+			},
+			Expect: strategyValidation{
+				Errors: strategyEntities.FieldErrors{"short_entry_rules": "short strategies need short entry rules"},
+			},
+		},
+		{
+			Name: "Every section",
+			Body: validateStrategyBody{
+				PositionSizing: &strategyEntities.PositionSizing{Model: strategyEntities.SizingFixedDollar},
+				Execution:      "NEXT_CLOSE",
+				SellConditions: []strategyEntities.SellCondition{{Type: strategyEntities.SellTakeProfit}},
+			},
+			Expect: strategyValidation{
+				Errors: strategyEntities.FieldErrors{
+					"position_sizing":           "FIXED_DOLLAR sizing needs a positive amount",
+					"execution":                 "must be SAME_CLOSE or NEXT_OPEN",
+					"entry_rules":               "at least one buy rule is required",
+					"sell_conditions.0.percent": "must be positive",
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Expect, validateStrategy(tt.Body))
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	accountEntities "code.cacheflow.internal/account/entities"
+	"code.cacheflow.internal/datafeed"
+	datastores "code.cacheflow.internal/datastores/mongo"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/strategy/metrics"
+	"code.cacheflow.internal/strategy/optimize"
+	"code.cacheflow.internal/util/httpx"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ── Walk-Forward Analysis ─────────────────────────────────────────────────────
+
+const (
+	maxWalkForwardWindows = 24
+	maxWalkForwardRuns    = 2000 // windows × candidates
+)
+
+type walkForwardBody struct {
+	strategyEntities.BacktestRequest
+	walkForwardSettings
+}
+
+// walkForwardSettings describes the windows and the search run on each.
+type walkForwardSettings struct {
+	optimizeSettings
+
+	InSampleDays    int  `json:"in_sample_days"`
+	OutOfSampleDays int  `json:"out_of_sample_days"`
+	Anchored        bool `json:"anchored"` // grow the in-sample range from from_date instead of rolling it
+}
+
+// WalkForwardWindow is one fit-then-test step. InSample is the winning
+// candidate on the fitting range; OutOfSample is that candidate run forward.
+type WalkForwardWindow struct {
+	optimize.Window
+
+	Params      map[string]float64 `json:"params,omitempty"`
+	InSample    *OptimizeResult    `json:"in_sample,omitempty"`
+	OutOfSample *OptimizeResult    `json:"out_of_sample,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// WalkForwardReport stitches every window's out-of-sample run into one
+// account: each window starts with the balance the previous one ended on.
+// Efficiency is the out-of-sample CAGR as a fraction of the mean in-sample
+// CAGR; values well below 1 point to overfitting.
+type WalkForwardReport struct {
+	Windows      []WalkForwardWindow                    `json:"windows"`
+	FinalBalance float64                                `json:"final_balance"`
+	ROI          float64                                `json:"roi"`
+	Metrics      metrics.Stats                          `json:"metrics"`
+	Efficiency   float64                                `json:"efficiency"`
+	Stability    []optimize.Stability                   `json:"stability"`
+	Equity       []strategyEntities.BacktestEquityPoint `json:"equity"`
+}
+
+// plan expands the candidates and splits from_date through to_date into
+// windows, within the limits on both.
+func (b *walkForwardSettings) plan(r strategyEntities.BacktestRequest) ([]optimize.Candidate, []optimize.Window, error) {
+	candidates, err := b.candidates()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	from, errFrom := time.Parse("2006-01-02", r.FromDate)
+	to, errTo := time.Parse("2006-01-02", r.ToDate)
+	if errFrom != nil || errTo != nil {
+		return nil, nil, errors.New("from_date and to_date must be YYYY-MM-DD")
+	}
+	windows, err := optimize.Windows(from, to, b.InSampleDays, b.OutOfSampleDays, b.Anchored)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(windows) > maxWalkForwardWindows {
+		return nil, nil, fmt.Errorf("at most %d windows; lengthen out_of_sample_days", maxWalkForwardWindows)
+	}
+	if len(windows)*len(candidates) > maxWalkForwardRuns {
+		return nil, nil, fmt.Errorf("windows × candidates may not exceed %d", maxWalkForwardRuns)
+	}
+	return candidates, windows, nil
+}
+
+// runWalkForward optimizes on each window's in-sample range and tests the
+// winner out of sample. Windows whose optimization or test fails are reported
+// and skipped, leaving the balance unchanged. run.Progress, if set, hears the
+// share of windows done.
+func runWalkForward(ctx context.Context, provider datafeed.MarketDataProvider, base *strategyEntities.StrategyEntity, run backtestRun, body *walkForwardSettings, candidates []optimize.Candidate, windows []optimize.Window) *WalkForwardReport {
+	provider = newMemoProvider(provider)
+	progress := run.Progress
+	run.BuyAndHold, run.Benchmark, run.Progress = false, "", nil
+
+	report := &WalkForwardReport{Windows: make([]WalkForwardWindow, len(windows))}
+	balance := run.InitialBalance
+	var curve []metrics.Point
+	var trades []metrics.Trade
+	var chosen []optimize.Candidate
+	var inCAGR []float64
+
+	for i, w := range windows {
+		wf := &report.Windows[i]
+		wf.Window = w
+
+		inRun := run
+		inRun.From, inRun.To = w.InFrom, w.InTo
+		if progress != nil {
+			// The in-sample sweep is nearly all of a window's work
+			inRun.Progress = func(percent float64) {
+				progress((float64(i) + percent/100) / float64(len(windows)) * 100)
+			}
+		}
+		ranked := runOptimization(ctx, provider, base, inRun, body.Params, candidates, body.Objective, body.MinTrades)
+		best := ranked[0]
+		if best.Error != "" {
+			wf.Error = "in-sample: " + best.Error
+			continue
+		}
+		wf.InSample, wf.Params = best, best.Params
+
+		c := make(optimize.Candidate, len(body.Params))
+		for j, p := range body.Params {
+			c[j] = best.Params[p.Path]
+		}
+		strategy, err := candidateStrategy(base, body.Params, c)
+		if err != nil {
+			wf.Error = err.Error()
+			continue
+		}
+
+		outRun := run
+		outRun.From, outRun.To, outRun.InitialBalance = w.OutFrom, w.OutTo, balance
+		result, err := runBacktestEngine(ctx, provider, strategy, outRun)
+		if err != nil {
+			wf.Error = "out-of-sample: " + err.Error()
+			continue
+		}
+		wf.OutOfSample = &OptimizeResult{
+			Params:       best.Params,
+			Score:        body.Objective.Score(result.ROI, result.Metrics),
+			ROI:          result.ROI,
+			FinalBalance: result.FinalBalance,
+			TotalTrades:  result.TotalTrades,
+			MaxDrawdown:  result.MaxDrawdown,
+			Metrics:      result.Metrics,
+		}
+
+		balance = result.FinalBalance
+		curve = append(curve, result.Equity...)
+		trades = append(trades, result.RoundTrips...)
+		chosen = append(chosen, c)
+		inCAGR = append(inCAGR, best.Metrics.CAGR)
+	}
+
+	report.FinalBalance = balance
+	report.ROI = (balance - run.InitialBalance) / run.InitialBalance * 100
+	report.Metrics = metrics.Compute(run.InitialBalance, curve, trades)
+	if mean := metrics.Mean(inCAGR); mean > 0 {
+		report.Efficiency = report.Metrics.CAGR / mean
+	}
+	report.Stability = optimize.ParamStability(body.Params, chosen)
+	report.Equity = equitySeries(run.InitialBalance, curve)
+	if report.Equity == nil {
+		report.Equity = []strategyEntities.BacktestEquityPoint{}
+	}
+	return report
+}
+
+// runWalkForwardJob runs a queued walk-forward analysis over the prepared
+// backtest.
+func runWalkForwardJob(ctx context.Context, job *strategyEntities.BacktestJobEntity, prepared *preparedBacktest) (*WalkForwardReport, error) {
+	var settings walkForwardSettings
+	if err := json.Unmarshal(job.Settings, &settings); err != nil {
+		return nil, errors.New("invalid walk-forward settings")
+	}
+	candidates, windows, err := settings.plan(prepared.Body)
+	if err != nil {
+		return nil, err
+	}
+	return runWalkForward(ctx, datafeed.GetProvider(), &prepared.Strategy, prepared.Run, &settings, candidates, windows), nil
+}
+
+// WalkForward queues a walk-forward analysis: parameters are optimized on
+// rolling in-sample windows and tested on the out-of-sample window after each,
+// and the out-of-sample runs are reported stitched together. It responds 202
+// with the job; once it succeeds, GetBacktestJob returns the report.
+func WalkForward(res http.ResponseWriter, req *http.Request) {
+	email := req.Header.Get("x-cf-uid")
+	if email == "" {
+		httpx.WriteError(res, req, httpx.BadRequest("email is required", nil))
+		return
+	}
+
+	db := datastores.GetMongoDatabase(req.Context())
+
+	var account accountEntities.AccountEntity
+	if err := db.Collection(datastores.Accounts).FindOne(req.Context(), bson.M{"email": email}).Decode(&account); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("account not found", nil))
+		return
+	}
+
+	var body walkForwardBody
+	if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest("invalid request body", nil))
+		return
+	}
+
+	if _, _, err := body.plan(body.BacktestRequest); err != nil {
+		httpx.WriteError(res, req, httpx.BadRequest(err.Error(), nil))
+		return
+	}
+	settings, err := json.Marshal(body.walkForwardSettings)
+	if err != nil {
+		httpx.WriteError(res, req, httpx.Internal("failed to encode walk-forward settings"))
+		return
+	}
+
+	prepared, err := prepareBacktest(req.Context(), db, *account.AccountID, body.BacktestRequest)
+	if err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+
+	job := strategyEntities.BacktestJobEntity{
+		AccountID:    *account.AccountID,
+		StrategyUUID: body.StrategyUUID,
+		Request:      prepared.Body,
+		Kind:         strategyEntities.JobWalkForward,
+		Settings:     settings,
+	}
+	if err := queueJob(req.Context(), db, &job); err != nil {
+		httpx.WriteError(res, req, err)
+		return
+	}
+
+	httpx.WriteJSON(res, http.StatusAccepted, job)
+}
@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"code.cacheflow.internal/datafeed"
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+	"code.cacheflow.internal/strategy/optimize"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRunWalkForwardStitchesOutOfSample(t *testing.T) {
+	provider := &stubProvider{bars: map[string][]datafeed.Bar{"AAA": risingBars(80, 100, 1)}}
+	body := &walkForwardSettings{}
+	body.Params = []optimize.Param{{Path: "sell_conditions.0.percent", Values: []float64{2, 20}}}
+	body.Objective = optimize.ObjectiveROI
+	candidates, err := optimize.Grid(body.Params)
+	assert.NoError(t, err)
+
+	windows, err := optimize.Windows(
+		time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		time.Date(2024, 3, 10, 0, 0, 0, 0, time.UTC),
+		20, 10, false)
+	assert.NoError(t, err)
+
+	run := testRun("AAA")
+	run.To = "2024-03-10"
+	var progress []float64
+	run.Progress = func(percent float64) { progress = append(progress, percent) }
+	report := runWalkForward(context.Background(), provider, momentumStrategy(0), run, body, candidates, windows)
+
+	assert.IsIncreasing(t, progress)
+	assert.Equal(t, 100.0, progress[len(progress)-1])
+
+	assert.Len(t, report.Windows, len(windows))
+	for _, w := range report.Windows {
+		assert.Empty(t, w.Error)
+		assert.Equal(t, 20.0, w.Params["sell_conditions.0.percent"])
+	}
+	assert.Greater(t, report.FinalBalance, 10000.0)
+	assert.Equal(t, windows[0].OutFrom, report.Equity[0].Date)
+	assert.Equal(t, 0, report.Stability[0].Changes)
+	assert.Equal(t, report.FinalBalance, report.Equity[len(report.Equity)-1].Equity)
+}
+
+func TestWalkForwardPlan(t *testing.T) {
+	settings := walkForwardSettings{InSampleDays: 20}
+	settings.Params = []optimize.Param{{Path: "sell_conditions.0.percent", Values: []float64{2, 20}}}
+
+	tests := []struct {
+		Name    string
+		From    string
+		To      string
+		OutDays int
+		Error   string
+	}{
+		{Name: "Fits", From: "2024-01-10", To: "2024-03-10", OutDays: 10},
+		{Name: "Bad date", From: "2024-01-10", To: "March", OutDays: 10, Error: "from_date and to_date must be YYYY-MM-DD"},
+		{Name: "Too many windows", From: "2020-01-01", To: "2024-01-01", OutDays: 10, Error: "at most 24 windows; lengthen out_of_sample_days"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			s := settings
+			s.OutOfSampleDays = tt.OutDays
+			candidates, windows, err := s.plan(strategyEntities.BacktestRequest{FromDate: tt.From, ToDate: tt.To})
+			if tt.Error != "" {
+				assert.EqualError(t, err, tt.Error)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Len(t, candidates, 2)
+			assert.NotEmpty(t, windows)
+		})
+	}
+}
@@ -0,0 +1,127 @@
+// Package sizing turns a strategy's PositionSizing model into a share count.
+// It is shared by the backtester and live paper execution, so it only works
+// on plain numbers supplied by the caller.
+package sizing
+
+import (
+	"math"
+
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+)
+
+const (
+	defaultATRWindow     = 14
+	defaultATRMultiple   = 1.0
+	defaultKellyFraction = 0.5
+	defaultMinTrades     = 10
+	defaultKellyPercent  = 10.0
+)
+
+// Inputs is the account and market state at the moment of entry.
+type Inputs struct {
+	Price        float64   // expected fill price
+	Cash         float64   // cash available to spend
+	Equity       float64   // cash plus open positions at market
+	FreeSlots    int       // position slots still open (ALL_CASH only)
+	ATR          float64   // current ATR (VOLATILITY_TARGET only)
+	ClosedReturn []float64 // % return of each closed trade so far (KELLY only)
+}
+
+// ATRWindow is the ATR period a volatility-targeted model needs, or 0.
+func ATRWindow(cfg *strategyEntities.PositionSizing) int {
+	if cfg == nil || cfg.Model != strategyEntities.SizingVolatilityTarget {
+		return 0
+	}
+	if cfg.ATRWindow > 0 {
+		return cfg.ATRWindow
+	}
+	return defaultATRWindow
+}
+
+// Shares returns the whole number of shares to buy, never more than Cash
+// affords. A nil config means ALL_CASH.
+func Shares(cfg *strategyEntities.PositionSizing, in Inputs) float64 {
+	if in.Price <= 0 || in.Cash <= 0 {
+		return 0
+	}
+
+	var qty float64
+	model := strategyEntities.SizingAllCash
+	if cfg != nil && cfg.Model != "" {
+		model = cfg.Model
+	}
+
+	switch model {
+	case strategyEntities.SizingAllCash:
+		slots := max(in.FreeSlots, 1)
+		qty = in.Cash / float64(slots) / in.Price
+	case strategyEntities.SizingFixedDollar:
+		qty = cfg.Amount / in.Price
+	case strategyEntities.SizingFixedShares:
+		qty = cfg.Amount
+	case strategyEntities.SizingFixedFraction:
+		qty = in.Equity * cfg.Percent / 100 / in.Price
+	case strategyEntities.SizingVolatilityTarget:
+		multiple := cfg.ATRMultiple
+		if multiple <= 0 {
+			multiple = defaultATRMultiple
+		}
+		if in.ATR <= 0 {
+			return 0
+		}
+		qty = in.Equity * cfg.Percent / 100 / (in.ATR * multiple)
+	case strategyEntities.SizingKelly:
+		qty = in.Equity * kellyFraction(cfg, in.ClosedReturn) / in.Price
+	}
+
+	return math.Floor(math.Max(0, math.Min(qty, in.Cash/in.Price)))
+}
+
+// kellyFraction is the share of equity to commit under a fractional Kelly
+// model. Until MinTrades trades have closed it falls back to Percent.
+func kellyFraction(cfg *strategyEntities.PositionSizing, returns []float64) float64 {
+	minTrades := cfg.MinTrades
+	if minTrades <= 0 {
+		minTrades = defaultMinTrades
+	}
+	if len(returns) < minTrades {
+		pct := cfg.Percent
+		if pct <= 0 {
+			pct = defaultKellyPercent
+		}
+		return pct / 100
+	}
+
+	scale := cfg.KellyFraction
+	if scale <= 0 {
+		scale = defaultKellyFraction
+	}
+	return scale * Kelly(returns)
+}
+
+// Kelly is the full Kelly fraction W − (1 − W) / R for a set of trade returns,
+// where W is the win rate and R the average win over the average loss.
+// It is clamped to [0, 1].
+func Kelly(returns []float64) float64 {
+	var wins, losses int
+	var winSum, lossSum float64
+	for _, r := range returns {
+		if r > 0 {
+			wins++
+			winSum += r
+		} else if r < 0 {
+			losses++
+			lossSum -= r
+		}
+	}
+	if wins == 0 {
+		return 0
+	}
+	if losses == 0 {
+		return 1
+	}
+
+	w := float64(wins) / float64(wins+losses)
+	ratio := (winSum / float64(wins)) / (lossSum / float64(losses))
+	return math.Max(0, math.Min(1, w-(1-w)/ratio))
+}
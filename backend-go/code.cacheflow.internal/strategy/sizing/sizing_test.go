@@ -0,0 +1,65 @@
+package sizing
+
+import (
+	"testing"
+
+	strategyEntities "code.cacheflow.internal/strategy/entities"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShares(t *testing.T) {
+	in := Inputs{Price: 50, Cash: 10000, Equity: 20000, FreeSlots: 2, ATR: 2}
+
+	tests := []struct {
+		Name   string
+		Config *strategyEntities.PositionSizing
+		Expect float64
+	}{
+		{
+			Name:   "Default splits cash across free slots",
+			Config: nil,
+			Expect: 100,
+		},
+		{
+			Name:   "Fixed dollar",
+			Config: &strategyEntities.PositionSizing{Model: strategyEntities.SizingFixedDollar, Amount: 1234},
+			Expect: 24,
+		},
+		{
+			Name:   "Fixed fraction of equity",
+			Config: &strategyEntities.PositionSizing{Model: strategyEntities.SizingFixedFraction, Percent: 10},
+			Expect: 40,
+		},
+		{
+			Name:   "Volatility target risks 1% per 2 ATR",
+			Config: &strategyEntities.PositionSizing{Model: strategyEntities.SizingVolatilityTarget, Percent: 1, ATRMultiple: 2},
+			Expect: 50,
+		},
+		{
+			Name:   "Fixed shares capped by cash",
+			Config: &strategyEntities.PositionSizing{Model: strategyEntities.SizingFixedShares, Amount: 500},
+			Expect: 200,
+		},
+		{
+			Name:   "Kelly falls back to percent without history",
+			Config: &strategyEntities.PositionSizing{Model: strategyEntities.SizingKelly, Percent: 5},
+			Expect: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.Name, func(t *testing.T) {
+			assert.Equal(t, tt.Expect, Shares(tt.Config, in))
+		})
+	}
+}
+
+func TestKelly(t *testing.T) {
+	// 60% winners averaging +10%, losers averaging −5% → 0.6 − 0.4/2 = 0.4
+	returns := []float64{10, 10, 10, -5, -5}
+	assert.InDelta(t, 0.4, Kelly(returns), 1e-9)
+
+	assert.Equal(t, 0.0, Kelly([]float64{-1, -2}))
+	assert.Equal(t, 1.0, Kelly([]float64{1, 2}))
+}